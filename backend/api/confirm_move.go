@@ -77,6 +77,9 @@ type ConfirmMoveRequest struct {
 	BuildAction        *BuildAction        `json:"buildAction"`
 	MoveGoodsAction    *MoveGoodsAction    `json:"moveGoodsAction"`
 	ProduceGoodsAction *ProduceGoodsAction `json:"produceGoodsAction"`
+	// Version of the game the move was made against, from ViewGameResponse. If set, the move is rejected with a 409 if
+	// the game has changed since.
+	Version *int `json:"version,omitempty"`
 }
 type ConfirmMoveResponse struct {
 }
@@ -0,0 +1,131 @@
+package auction
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+)
+
+// SealedBidAuctionPhase has every player make a single secret bid, in turn order. Once everyone has bid, players go in
+// order of their bids and each pays what they bid. Ties go to the player with turn order pass, then to whoever was
+// earlier in the turn order.
+type SealedBidAuctionPhase struct{}
+
+func (*SealedBidAuctionPhase) PreAuctionHook(handler ConfirmMoveHandler) error {
+	handler.SetActivePlayer(handler.GetGameState().PlayerOrder[0])
+	return nil
+}
+
+func (*SealedBidAuctionPhase) ValidateBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+	gameState := handler.GetGameState()
+	if bidAction == nil {
+		return &api.HttpError{"missing bid action", http.StatusBadRequest}
+	}
+	if gameState.GamePhase != common.AUCTION_GAME_PHASE {
+		return &api.HttpError{fmt.Sprintf("invalid action for current phase %d", gameState.GamePhase), http.StatusPreconditionFailed}
+	}
+
+	currentPlayer := handler.GetActivePlayer()
+	if _, ok := gameState.AuctionState[currentPlayer]; ok {
+		return &api.HttpError{"you have already made your sealed bid", http.StatusBadRequest}
+	}
+	// -1 is passing, which is the same as bidding 0
+	if bidAction.Amount < -1 {
+		return &api.HttpError{fmt.Sprintf("invalid bid amount [%d]", bidAction.Amount), http.StatusBadRequest}
+	}
+	playerCash := gameState.PlayerCash[currentPlayer]
+	if bidAction.Amount > playerCash {
+		return &api.HttpError{fmt.Sprintf("bid amount [%d] greater than player's cash on hand %d", bidAction.Amount, playerCash), http.StatusBadRequest}
+	}
+	return nil
+}
+
+// Sealed bids don't have to beat anything, and passing and turn order pass are both a bid of nothing
+func (*SealedBidAuctionPhase) BidAmounts(handler ConfirmMoveHandler) []int {
+	amounts := []int{-1}
+	for amount := 1; amount <= handler.GetGameState().PlayerCash[handler.GetActivePlayer()]; amount++ {
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+func (p *SealedBidAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+	err := p.ValidateBid(handler, bidAction)
+	if err != nil {
+		return err
+	}
+	gameState := handler.GetGameState()
+	currentPlayer := handler.GetActivePlayer()
+
+	// Passing, or using turn order pass, is a bid of nothing
+	gameState.AuctionState[currentPlayer] = max(bidAction.Amount, 0)
+	handler.Log("%s makes a sealed bid.", handler.PlayerNick(currentPlayer))
+
+	currentPlayerPos := slices.Index(gameState.PlayerOrder, currentPlayer)
+	if currentPlayerPos == -1 {
+		return fmt.Errorf("failed to determine current player turn position")
+	}
+	if currentPlayerPos+1 < len(gameState.PlayerOrder) {
+		handler.SetActivePlayer(gameState.PlayerOrder[currentPlayerPos+1])
+		return nil
+	}
+
+	// Everyone has bid, so reveal the bids. The sort is stable so that other ties keep the current turn order.
+	playerOrder := slices.Clone(gameState.PlayerOrder)
+	hasTurnOrderPass := func(playerId string) bool {
+		return gameState.PlayerActions[playerId] == common.TURN_ORDER_PASS_SPECIAL_ACTION
+	}
+	slices.SortStableFunc(playerOrder, func(a, b string) int {
+		if gameState.AuctionState[a] != gameState.AuctionState[b] {
+			return gameState.AuctionState[b] - gameState.AuctionState[a]
+		}
+		if hasTurnOrderPass(a) && !hasTurnOrderPass(b) {
+			return -1
+		}
+		if hasTurnOrderPass(b) && !hasTurnOrderPass(a) {
+			return 1
+		}
+		return 0
+	})
+	for i, playerId := range playerOrder {
+		bidAmount := gameState.AuctionState[playerId]
+		gameState.PlayerCash[playerId] -= bidAmount
+		handler.Log("%s bid $%d, becoming player number %d.", handler.PlayerNick(playerId), bidAmount, i+1)
+	}
+	finishAuction(handler, playerOrder)
+
+	return nil
+}
+
+// PayFullBidAuctionPhase is the standard auction, except that every player pays their full last bid when they drop
+// out, rather than the last player paying nothing and the players in the middle paying half
+type PayFullBidAuctionPhase struct {
+	StandardAuctionPhase
+}
+
+func NewPayFullBidAuctionPhase() *PayFullBidAuctionPhase {
+	return &PayFullBidAuctionPhase{StandardAuctionPhase{payFullBid: true}}
+}
+
+// FixedOrderAuctionPhase skips the auction, so the turn order never changes
+type FixedOrderAuctionPhase struct{}
+
+func (*FixedOrderAuctionPhase) PreAuctionHook(handler ConfirmMoveHandler) error {
+	finishAuction(handler, handler.GetGameState().PlayerOrder)
+	return nil
+}
+
+func (*FixedOrderAuctionPhase) ValidateBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+	return &api.HttpError{"there is no auction in this game", http.StatusBadRequest}
+}
+
+func (p *FixedOrderAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+	return p.ValidateBid(handler, bidAction)
+}
+
+func (*FixedOrderAuctionPhase) BidAmounts(handler ConfirmMoveHandler) []int {
+	return nil
+}
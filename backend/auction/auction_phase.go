@@ -19,10 +19,18 @@ type ConfirmMoveHandler interface {
 
 type AuctionPhase interface {
 	PreAuctionHook(handler ConfirmMoveHandler) error
+	// ValidateBid checks whether the active player may make the given bid, without changing the game state
+	ValidateBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error
 	HandleBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error
+	// BidAmounts lists the bids worth considering for the active player, with each distinct bid listed once. Some may
+	// still fail ValidateBid.
+	BidAmounts(handler ConfirmMoveHandler) []int
 }
 
-type StandardAuctionPhase struct{}
+type StandardAuctionPhase struct {
+	// Set for the variant where players pay their full bid wherever they drop out
+	payFullBid bool
+}
 
 func (s *StandardAuctionPhase) PreAuctionHook(handler ConfirmMoveHandler) error {
 	err := s.advanceCurrentPlayerForBidPhase(handler, -1)
@@ -32,7 +40,7 @@ func (s *StandardAuctionPhase) PreAuctionHook(handler ConfirmMoveHandler) error
 	return nil
 }
 
-func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+func (s *StandardAuctionPhase) ValidateBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
 	gameState := handler.GetGameState()
 	if bidAction == nil {
 		return &api.HttpError{"missing bid action", http.StatusBadRequest}
@@ -41,6 +49,53 @@ func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *
 		return &api.HttpError{fmt.Sprintf("invalid action for current phase %d", gameState.GamePhase), http.StatusPreconditionFailed}
 	}
 
+	currentPlayer := handler.GetActivePlayer()
+	if bidAction.Amount == 0 {
+		// Bid amount of 0 indicates use of turn-order-pass
+		if gameState.PlayerActions[currentPlayer] != common.TURN_ORDER_PASS_SPECIAL_ACTION {
+			return &api.HttpError{"current player cannot use turn order pass", http.StatusBadRequest}
+		}
+	} else if bidAction.Amount > 0 {
+		playerCash := gameState.PlayerCash[currentPlayer]
+		if bidAction.Amount > playerCash {
+			return &api.HttpError{fmt.Sprintf("bid amount [%d] greater than player's cash on hand %d", bidAction.Amount, playerCash), http.StatusBadRequest}
+		}
+
+		currentHighBid := 0
+		for _, bidAmount := range gameState.AuctionState {
+			if bidAmount > 0 && bidAmount > currentHighBid {
+				currentHighBid = bidAmount
+			}
+		}
+		if bidAction.Amount <= currentHighBid {
+			return &api.HttpError{fmt.Sprintf("bid amount [%d] not higher than current high bid %d", bidAction.Amount, currentHighBid), http.StatusBadRequest}
+		}
+	}
+	return nil
+}
+
+func (s *StandardAuctionPhase) BidAmounts(handler ConfirmMoveHandler) []int {
+	gameState := handler.GetGameState()
+	// Pass and turn-order-pass
+	amounts := []int{-1, 0}
+	currentHighBid := 0
+	for _, bidAmount := range gameState.AuctionState {
+		if bidAmount > currentHighBid {
+			currentHighBid = bidAmount
+		}
+	}
+	for amount := currentHighBid + 1; amount <= gameState.PlayerCash[handler.GetActivePlayer()]; amount++ {
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *api.BidAction) error {
+	err := s.ValidateBid(handler, bidAction)
+	if err != nil {
+		return err
+	}
+	gameState := handler.GetGameState()
 	currentPlayer := handler.GetActivePlayer()
 
 	// If the user is passing
@@ -54,7 +109,7 @@ func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *
 		}
 
 		lastBid := gameState.AuctionState[currentPlayer]
-		cashToPay := calculateCashToPayForBid(lastBid, passCount, len(gameState.PlayerOrder))
+		cashToPay := s.cashToPayForBid(lastBid, passCount, len(gameState.PlayerOrder))
 
 		gameState.PlayerCash[currentPlayer] -= cashToPay
 		// Set auction state to pass order (-1 first to pass, -2 second to pass, etc)
@@ -67,32 +122,12 @@ func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *
 		// Bid amount of 0 indicates use of turn-order-pass
 		handler.Log("%s uses turn order pass.", handler.PlayerNick(currentPlayer))
 
-		if gameState.PlayerActions[currentPlayer] != common.TURN_ORDER_PASS_SPECIAL_ACTION {
-			return &api.HttpError{"current player cannot use turn order pass", http.StatusBadRequest}
-		}
-
 		// Do not update this user's bid amount, we just advance the active player
 		// Remove user's turn-order-pass action
 		gameState.PlayerActions[currentPlayer] = ""
 
 	} else {
 		// User is increasing their bid
-		playerCash := gameState.PlayerCash[currentPlayer]
-		if bidAction.Amount > playerCash {
-			return &api.HttpError{fmt.Sprintf("bid amount [%d] greater than player's cash on hand %d", bidAction.Amount, playerCash), http.StatusBadRequest}
-		}
-
-		currentHighBid := 0
-		for _, bidAmount := range gameState.AuctionState {
-			if bidAmount > 0 && bidAmount > currentHighBid {
-				currentHighBid = bidAmount
-			}
-		}
-		if bidAction.Amount <= currentHighBid {
-			return &api.HttpError{fmt.Sprintf("bid amount [%d] not higher than current high bid %d", bidAction.Amount, currentHighBid), http.StatusBadRequest}
-		}
-
-		// Update this user's bid
 		gameState.AuctionState[currentPlayer] = bidAction.Amount
 
 		handler.Log("%s bids $%d.", handler.PlayerNick(currentPlayer), bidAction.Amount)
@@ -102,7 +137,7 @@ func (s *StandardAuctionPhase) HandleBid(handler ConfirmMoveHandler, bidAction *
 	if currentPlayerPos == -1 {
 		return fmt.Errorf("failed to determine current player turn position")
 	}
-	err := s.advanceCurrentPlayerForBidPhase(handler, currentPlayerPos)
+	err = s.advanceCurrentPlayerForBidPhase(handler, currentPlayerPos)
 	if err != nil {
 		return err
 	}
@@ -142,7 +177,7 @@ func (s *StandardAuctionPhase) advanceCurrentPlayerForBidPhase(handler ConfirmMo
 		// If the user does not have enough to outbid and does not have TOP, they auto-pass
 		if gameState.PlayerCash[userId] <= 0 || gameState.PlayerCash[userId] <= currentHighBid {
 			if gameState.PlayerActions[userId] != common.TURN_ORDER_PASS_SPECIAL_ACTION {
-				cashToPay := calculateCashToPayForBid(gameState.AuctionState[userId], passCount, len(gameState.PlayerOrder))
+				cashToPay := s.cashToPayForBid(gameState.AuctionState[userId], passCount, len(gameState.PlayerOrder))
 				gameState.PlayerCash[userId] -= cashToPay
 				gameState.AuctionState[userId] = (-1 * passCount) - 1
 				passCount += 1
@@ -163,7 +198,7 @@ func (s *StandardAuctionPhase) advanceCurrentPlayerForBidPhase(handler ConfirmMo
 		// Implicitly pass the remaining player
 		for _, playerId := range gameState.PlayerOrder {
 			if bidAmount := gameState.AuctionState[playerId]; bidAmount >= 0 {
-				gameState.PlayerCash[playerId] -= calculateCashToPayForBid(bidAmount, passCount, len(gameState.PlayerOrder))
+				gameState.PlayerCash[playerId] -= s.cashToPayForBid(bidAmount, passCount, len(gameState.PlayerOrder))
 				gameState.AuctionState[playerId] = (-1 * passCount) - 1
 				passCount += 1
 
@@ -176,23 +211,12 @@ func (s *StandardAuctionPhase) advanceCurrentPlayerForBidPhase(handler ConfirmMo
 	// All players have passed, so advance to next phase
 	if passCount == len(gameState.PlayerOrder) {
 		// Get the new player order from the auction state
-		gameState.PlayerOrder = gameState.PlayerOrder[:len(gameState.AuctionState)]
+		playerOrder := make([]string, len(gameState.AuctionState))
 		for userId, bidAmount := range gameState.AuctionState {
 			// bidAmount of -1 should be first from end, bidAmount of -2 next from end, etc
-			gameState.PlayerOrder[len(gameState.AuctionState)+bidAmount] = userId
-		}
-		// Then reset the auction state
-		for userId := range gameState.AuctionState {
-			delete(gameState.AuctionState, userId)
-		}
-		// Set the active player to the new first player
-		handler.SetActivePlayer(gameState.PlayerOrder[0])
-		// Advance the game phase
-		gameState.GamePhase = common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE
-		// Force-remove any chosen special actions as we advance into that phase
-		for userId := range gameState.PlayerActions {
-			gameState.PlayerActions[userId] = ""
+			playerOrder[len(gameState.AuctionState)+bidAmount] = userId
 		}
+		finishAuction(handler, playerOrder)
 	} else {
 		// Otherwise set the active player to the next player determined above.
 		// If nextPlayer is empty, it means we looped already the way back around to currentPlayerPos; this should only
@@ -209,6 +233,31 @@ func (s *StandardAuctionPhase) advanceCurrentPlayerForBidPhase(handler ConfirmMo
 	return nil
 }
 
+func (s *StandardAuctionPhase) cashToPayForBid(bidAmount int, passCount int, playerCount int) int {
+	if s.payFullBid {
+		return bidAmount
+	}
+	return calculateCashToPayForBid(bidAmount, passCount, playerCount)
+}
+
+// finishAuction sets the player order decided by the auction and moves on to choosing special actions
+func finishAuction(handler ConfirmMoveHandler, playerOrder []string) {
+	gameState := handler.GetGameState()
+	gameState.PlayerOrder = playerOrder
+	// Reset the auction state
+	for userId := range gameState.AuctionState {
+		delete(gameState.AuctionState, userId)
+	}
+	// Set the active player to the new first player
+	handler.SetActivePlayer(gameState.PlayerOrder[0])
+	// Advance the game phase
+	gameState.GamePhase = common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE
+	// Force-remove any chosen special actions as we advance into that phase
+	for userId := range gameState.PlayerActions {
+		gameState.PlayerActions[userId] = ""
+	}
+}
+
 func calculateCashToPayForBid(bidAmount int, passCount int, playerCount int) int {
 	if passCount == 0 {
 		// Last player does not pay
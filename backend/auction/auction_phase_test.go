@@ -0,0 +1,168 @@
+package auction
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConfirmMoveHandler struct {
+	gameState    *common.GameState
+	activePlayer string
+	logs         []string
+}
+
+func (h *fakeConfirmMoveHandler) Log(format string, a ...any) {
+	h.logs = append(h.logs, fmt.Sprintf(format, a...))
+}
+
+func (h *fakeConfirmMoveHandler) GetGameState() *common.GameState {
+	return h.gameState
+}
+
+func (h *fakeConfirmMoveHandler) GetActivePlayer() string {
+	return h.activePlayer
+}
+
+func (h *fakeConfirmMoveHandler) SetActivePlayer(activePlayer string) {
+	h.activePlayer = activePlayer
+}
+
+func (h *fakeConfirmMoveHandler) PlayerNick(playerId string) string {
+	return playerId
+}
+
+// newAuctionTestHandler starts an auction between players p1, p2 and p3, in that order, who each have $10
+func newAuctionTestHandler(t *testing.T, phase AuctionPhase) *fakeConfirmMoveHandler {
+	handler := &fakeConfirmMoveHandler{
+		gameState: &common.GameState{
+			PlayerOrder:   []string{"p1", "p2", "p3"},
+			PlayerCash:    map[string]int{"p1": 10, "p2": 10, "p3": 10},
+			PlayerActions: map[string]common.SpecialAction{"p1": "", "p2": "", "p3": ""},
+			AuctionState:  make(map[string]int),
+			GamePhase:     common.AUCTION_GAME_PHASE,
+		},
+	}
+	require.NoError(t, phase.PreAuctionHook(handler))
+	return handler
+}
+
+// bid has the active player make each of the given bids in turn
+func bid(t *testing.T, phase AuctionPhase, handler *fakeConfirmMoveHandler, amounts ...int) {
+	for _, amount := range amounts {
+		require.NoError(t, phase.HandleBid(handler, &api.BidAction{Amount: amount}))
+	}
+}
+
+func TestStandardAuction(t *testing.T) {
+	phase := &StandardAuctionPhase{}
+	handler := newAuctionTestHandler(t, phase)
+	assert.Equal(t, "p1", handler.activePlayer)
+
+	// p1 bids $1, p2 bids $3, p3 passes, p1 bids $4, p2 passes
+	bid(t, phase, handler, 1, 3, -1, 4, -1)
+	assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, handler.gameState.GamePhase)
+	assert.Equal(t, []string{"p1", "p2", "p3"}, handler.gameState.PlayerOrder)
+	// The first to drop out pays nothing and the last two pay in full
+	assert.Equal(t, map[string]int{"p1": 6, "p2": 7, "p3": 10}, handler.gameState.PlayerCash)
+	assert.Equal(t, "p1", handler.activePlayer)
+	assert.Empty(t, handler.gameState.AuctionState)
+}
+
+func TestPayFullBidAuction(t *testing.T) {
+	phase := NewPayFullBidAuctionPhase()
+	handler := newAuctionTestHandler(t, phase)
+
+	// p1 bids $1, p2 bids $2, p3 bids $3, p1 passes, p2 passes
+	bid(t, phase, handler, 1, 2, 3, -1, -1)
+	assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, handler.gameState.GamePhase)
+	assert.Equal(t, []string{"p3", "p2", "p1"}, handler.gameState.PlayerOrder)
+	// Even the first to drop out pays their full bid
+	assert.Equal(t, map[string]int{"p1": 9, "p2": 8, "p3": 7}, handler.gameState.PlayerCash)
+	assert.Equal(t, "p3", handler.activePlayer)
+}
+
+func TestSealedBidAuction(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		bids          []int
+		turnOrderPass string
+		expectedOrder []string
+		expectedCash  map[string]int
+	}{
+		{"highest bids go first", []int{2, 5, 3}, "", []string{"p2", "p3", "p1"}, map[string]int{"p1": 8, "p2": 5, "p3": 7}},
+		{"passing is a bid of nothing", []int{-1, 0, 1}, "", []string{"p3", "p1", "p2"}, map[string]int{"p1": 10, "p2": 10, "p3": 9}},
+		{"ties keep the turn order", []int{4, 4, 4}, "", []string{"p1", "p2", "p3"}, map[string]int{"p1": 6, "p2": 6, "p3": 6}},
+		{"turn order pass wins ties", []int{4, 2, 4}, "p3", []string{"p3", "p1", "p2"}, map[string]int{"p1": 6, "p2": 8, "p3": 6}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			phase := &SealedBidAuctionPhase{}
+			handler := newAuctionTestHandler(t, phase)
+			if tc.turnOrderPass != "" {
+				handler.gameState.PlayerActions[tc.turnOrderPass] = common.TURN_ORDER_PASS_SPECIAL_ACTION
+			}
+
+			for i, amount := range tc.bids {
+				assert.Equal(t, handler.gameState.PlayerOrder[i], handler.activePlayer)
+				assert.Equal(t, common.AUCTION_GAME_PHASE, handler.gameState.GamePhase)
+				bid(t, phase, handler, amount)
+			}
+			assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, handler.gameState.GamePhase)
+			assert.Equal(t, tc.expectedOrder, handler.gameState.PlayerOrder)
+			assert.Equal(t, tc.expectedCash, handler.gameState.PlayerCash)
+			assert.Equal(t, tc.expectedOrder[0], handler.activePlayer)
+			assert.Empty(t, handler.gameState.AuctionState)
+			assert.Empty(t, handler.gameState.PlayerActions[tc.turnOrderPass])
+		})
+	}
+}
+
+func TestSealedBidAuctionInvalidBids(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		priorBid bool
+		amount   int
+	}{
+		{"more than the player's cash", false, 11},
+		{"below passing", false, -2},
+		{"a second bid", true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			phase := &SealedBidAuctionPhase{}
+			handler := newAuctionTestHandler(t, phase)
+			expectedAuctionState := map[string]int{}
+			if tc.priorBid {
+				handler.gameState.AuctionState["p1"] = 2
+				expectedAuctionState["p1"] = 2
+			}
+
+			err := phase.HandleBid(handler, &api.BidAction{Amount: tc.amount})
+			var httpErr *api.HttpError
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+			assert.Equal(t, "p1", handler.activePlayer)
+			assert.Equal(t, expectedAuctionState, handler.gameState.AuctionState)
+		})
+	}
+}
+
+func TestFixedOrderAuction(t *testing.T) {
+	phase := &FixedOrderAuctionPhase{}
+	handler := newAuctionTestHandler(t, phase)
+
+	// The auction is skipped entirely
+	assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, handler.gameState.GamePhase)
+	assert.Equal(t, []string{"p1", "p2", "p3"}, handler.gameState.PlayerOrder)
+	assert.Equal(t, map[string]int{"p1": 10, "p2": 10, "p3": 10}, handler.gameState.PlayerCash)
+	assert.Equal(t, "p1", handler.activePlayer)
+
+	err := phase.HandleBid(handler, &api.BidAction{Amount: 1})
+	var httpErr *api.HttpError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/bots"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/google/uuid"
+)
+
+// Upper bound on how many moves bots make while handling a request, which is about one full turn of a six player game.
+// Games with more bot moves to make than this, e.g. once only bots are left, are continued by the run-bots task.
+const maxBotMovesPerRequest = 50
+
+// Upper bound on how many moves bots can make in a row in the run-bots task, as a guard against bots getting stuck
+const maxConsecutiveBotMoves = 1000
+
+// PreviewMove implements bots.Rules
+func (handler *confirmMoveHandler) PreviewMove(move *api.ConfirmMoveRequest) (*common.GameState, int, error) {
+	preview, err := handler.fork()
+	if err != nil {
+		return nil, 0, err
+	}
+	err = preview.handleAction(move)
+	if err != nil {
+		return nil, 0, err
+	}
+	return preview.gameState, preview.buildCost, nil
+}
+
+// getBotType returns the type of bot playing as the given user, or an empty string if the user is not a bot
+func (server *GameServer) getBotType(userId string) (string, error) {
+	stmt, err := server.db.Prepare("SELECT bot_type FROM users WHERE id=?")
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	var botType sql.NullString
+	err = stmt.QueryRow(userId).Scan(&botType)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute query: %v", err)
+	}
+	return botType.String, nil
+}
+
+// addBotPlayer creates a new bot user of the given type and joins it to the game
+func (server *GameServer) addBotPlayer(gameId string, botType string, botNumber int) error {
+	if _, err := bots.NewBot(botType); err != nil {
+		return &api.HttpError{fmt.Sprintf("invalid bot type: %s", botType), http.StatusBadRequest}
+	}
+
+	botId, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("failed to generate bot id: %v", err)
+	}
+
+	stmt, err := server.db.Prepare("INSERT INTO users (id,nickname,email_notifications_enabled,discord_turn_alerts_enabled,bot_type) VALUES(?,?,0,0,?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	// Registered nicknames are alphanumeric, so a space guarantees this won't collide with a real user
+	_, err = stmt.Exec(botId.String(), fmt.Sprintf("Bot %d", botNumber), botType)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+
+	stmt, err = server.db.Prepare("INSERT INTO game_player_map (game_id,player_user_id) VALUES (?,?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(gameId, botId.String())
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	return nil
+}
+
+// runBots makes moves on behalf of bot players for as long as a bot is the active player in the game, up to the given
+// number of moves
+func (server *GameServer) runBots(gameId string, maxMoves int) error {
+	for i := 0; i < maxMoves; i++ {
+		stmt, err := server.db.Prepare("SELECT started,finished,active_player_id FROM games WHERE id=?")
+		if err != nil {
+			return fmt.Errorf("failed to prepare query: %v", err)
+		}
+		var startedFlag int
+		var finishedFlag int
+		var activePlayer sql.NullString
+		err = stmt.QueryRow(gameId).Scan(&startedFlag, &finishedFlag, &activePlayer)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %v", err)
+		}
+		if startedFlag == 0 || finishedFlag != 0 || !activePlayer.Valid {
+			return nil
+		}
+
+		botType, err := server.getBotType(activePlayer.String)
+		if err != nil {
+			return err
+		}
+		if botType == "" {
+			return nil
+		}
+		bot, err := bots.NewBot(botType)
+		if err != nil {
+			return err
+		}
+
+		handler, err := server.newConfirmMoveHandlerForGame(gameId)
+		if err != nil {
+			return err
+		}
+		move, err := bot.SelectMove(handler.gameState, handler.gameMap, handler.activePlayer, handler)
+		if err != nil {
+			return fmt.Errorf("bot failed to select a move: %v", err)
+		}
+		move.GameId = gameId
+		err = server.performMove(handler.activePlayer, move)
+		if err != nil {
+			return fmt.Errorf("bot move was rejected: %v", err)
+		}
+	}
+	return nil
+}
+
+// runPendingBots continues every game which is waiting on a bot to move
+func (server *GameServer) runPendingBots() error {
+	stmt, err := server.db.Prepare("SELECT games.id FROM games JOIN users ON users.id=games.active_player_id WHERE games.started <> 0 AND games.finished=0 AND users.bot_type IS NOT NULL AND users.bot_type <> ''")
+	if err != nil {
+		return fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query()
+	if err != nil {
+		return fmt.Errorf("failed to query games waiting on bots: %v", err)
+	}
+	defer rows.Close()
+
+	var gameIds []string
+	for rows.Next() {
+		var gameId string
+		err = rows.Scan(&gameId)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		gameIds = append(gameIds, gameId)
+	}
+	rows.Close()
+
+	for _, gameId := range gameIds {
+		// One game getting stuck shouldn't hold up all the others
+		err = server.runBots(gameId, maxConsecutiveBotMoves)
+		if err != nil {
+			slog.Error("failed to run bot move", "error", err, "gameId", gameId)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/bots"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddBotPlayer(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 3,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+
+	// Only the owner can add bots
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: "not-a-bot"})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+
+	// The owner can add more than one bot
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	require.NoError(t, err)
+
+	res, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(res.JoinedUsers))
+	assert.Equal(t, player1, res.ActivePlayer)
+
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+}
+
+func TestBotsMoveAutomatically(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	// Whoever went first, it should now be the human player's turn
+	res, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, player1, res.ActivePlayer)
+	assert.Equal(t, common.SHARES_GAME_PHASE, res.GameState.GamePhase)
+
+	_, err = h.confirmMove(t, player1, &api.ConfirmMoveRequest{
+		GameId:       createRes.Id,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 1},
+	})
+	require.NoError(t, err)
+
+	// Both players have now taken shares, and the bot has played on until it is the human player's turn again
+	res, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, player1, res.ActivePlayer)
+	assert.Greater(t, res.GameState.GamePhase, common.SHARES_GAME_PHASE)
+	for _, playerId := range res.GameState.PlayerOrder {
+		assert.Greater(t, res.GameState.PlayerShares[playerId], 2)
+	}
+}
+
+func TestRunPendingBots(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	res, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.Equal(t, player1, res.ActivePlayer)
+	var botId string
+	for _, playerId := range res.GameState.PlayerOrder {
+		if playerId != player1 {
+			botId = playerId
+		}
+	}
+
+	// Leave the game waiting on the bot, as if a request had hit its limit on bot moves
+	_, err = h.gameServer.db.Exec("UPDATE games SET active_player_id=? WHERE id=?", botId, createRes.Id)
+	require.NoError(t, err)
+	err = h.gameServer.runBots(createRes.Id, 0)
+	require.NoError(t, err)
+	res, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, botId, res.ActivePlayer)
+
+	err = h.gameServer.runPendingBots()
+	require.NoError(t, err)
+	res, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, player1, res.ActivePlayer)
+}
@@ -0,0 +1,49 @@
+package bots
+
+import (
+	"fmt"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+const HEURISTIC_BOT_TYPE = "heuristic"
+
+// Rules gives bots access to the server's rules engine so that they don't need to re-implement move validation
+type Rules interface {
+	// LegalMoves lists the moves the active player may make. During the build phase this only includes single-step
+	// builds (and building nothing).
+	LegalMoves() ([]*api.ConfirmMoveRequest, error)
+	// LegalBuildSteps lists the single build steps that can be added to the given steps as part of the same build
+	LegalBuildSteps(steps []*api.BuildStep) ([]*api.BuildStep, error)
+	// PreviewBuildSteps is LegalBuildSteps along with the result of building each step after the given steps
+	PreviewBuildSteps(steps []*api.BuildStep) ([]*BuildStepPreview, error)
+	// PreviewMove applies the move to a copy of the game state, returning the resulting state and, for builds, the
+	// amount paid
+	PreviewMove(move *api.ConfirmMoveRequest) (*common.GameState, int, error)
+}
+
+// BuildStepPreview is a legal build step and the result of building it
+type BuildStepPreview struct {
+	Step *api.BuildStep
+	// The state after building the prior steps and this one. Only the links, urbanizations and cash are copied, so the
+	// rest of the state is shared with the game and must not be modified.
+	GameState *common.GameState
+	// The amount paid for the prior steps and this one
+	Cost int
+}
+
+// Bot decides on moves for a computer-controlled player
+type Bot interface {
+	SelectMove(gameState *common.GameState, gameMap maps.GameMap, player string, rules Rules) (*api.ConfirmMoveRequest, error)
+}
+
+func NewBot(botType string) (Bot, error) {
+	switch botType {
+	case HEURISTIC_BOT_TYPE:
+		return &HeuristicBot{}, nil
+	default:
+		return nil, fmt.Errorf("unknown bot type: %s", botType)
+	}
+}
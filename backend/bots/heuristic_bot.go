@@ -0,0 +1,345 @@
+package bots
+
+import (
+	"fmt"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+// Cash the heuristic bot tries to keep on hand for building, beyond what it needs to pay expenses
+const heuristicBotBuildReserve = 10
+
+// The heuristic bot will take the loco action until it reaches this loco
+const heuristicBotTargetLoco = 2
+
+// The heuristic bot only starts new track if it is at most this many hexes from completing a link
+const heuristicBotMaxNewLinkLength = 3
+
+// Preference order of special actions when the target loco has been reached
+var heuristicBotActionPreference = []common.SpecialAction{
+	common.FIRST_BUILD_SPECIAL_ACTION,
+	common.ENGINEER_SPECIAL_ACTION,
+	common.FIRST_MOVE_SPECIAL_ACTION,
+	common.LOCO_SPECIAL_ACTION,
+	common.URBANIZATION_SPECIAL_ACTION,
+	common.PRODUCTION_SPECIAL_ACTION,
+	common.TURN_ORDER_PASS_SPECIAL_ACTION,
+}
+
+// HeuristicBot plays a simple, conservative strategy: take only the shares it needs, never bid, build the cheapest
+// track that connects cities, and always make the delivery that earns it the most income.
+type HeuristicBot struct{}
+
+func (bot *HeuristicBot) SelectMove(gameState *common.GameState, gameMap maps.GameMap, player string, rules Rules) (*api.ConfirmMoveRequest, error) {
+	moves, err := rules.LegalMoves()
+	if err != nil {
+		return nil, err
+	}
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("no legal moves for player %s", player)
+	}
+
+	switch gameState.GamePhase {
+	case common.SHARES_GAME_PHASE:
+		return bot.selectShares(gameState, player, moves), nil
+	case common.AUCTION_GAME_PHASE:
+		return bot.selectBid(moves), nil
+	case common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE:
+		return bot.selectAction(gameState, player, moves), nil
+	case common.BUILDING_GAME_PHASE:
+		return bot.selectBuild(gameState, gameMap, player, rules)
+	case common.MOVING_GOODS_GAME_PHASE:
+		return bot.selectDelivery(gameState, player, moves, rules)
+	default:
+		return moves[0], nil
+	}
+}
+
+// expenses returns what the player will pay at the end of the turn
+func expenses(gameState *common.GameState, player string) int {
+	return gameState.PlayerShares[player] + gameState.PlayerLoco[player] - gameState.PlayerIncome[player]
+}
+
+func (bot *HeuristicBot) selectShares(gameState *common.GameState, player string, moves []*api.ConfirmMoveRequest) *api.ConfirmMoveRequest {
+	// Take the fewest shares that cover expenses and leave some cash for building
+	for _, move := range moves {
+		amount := move.SharesAction.Amount
+		cash := gameState.PlayerCash[player] + 5*amount
+		if cash >= expenses(gameState, player)+amount+heuristicBotBuildReserve {
+			return move
+		}
+	}
+	return moves[len(moves)-1]
+}
+
+func (bot *HeuristicBot) selectBid(moves []*api.ConfirmMoveRequest) *api.ConfirmMoveRequest {
+	for _, move := range moves {
+		if move.BidAction.Amount < 0 {
+			return move
+		}
+	}
+	return moves[0]
+}
+
+func (bot *HeuristicBot) selectAction(gameState *common.GameState, player string, moves []*api.ConfirmMoveRequest) *api.ConfirmMoveRequest {
+	preference := heuristicBotActionPreference
+	if gameState.PlayerLoco[player] < heuristicBotTargetLoco {
+		preference = append([]common.SpecialAction{common.LOCO_SPECIAL_ACTION}, preference...)
+	}
+	for _, action := range preference {
+		for _, move := range moves {
+			if move.ChooseAction.Action == action {
+				return move
+			}
+		}
+	}
+	return moves[0]
+}
+
+func countOwnedLinks(gameState *common.GameState, player string) (owned int, complete int) {
+	for _, link := range gameState.Links {
+		if link.Owner == player {
+			owned += 1
+			if link.Complete {
+				complete += 1
+			}
+		}
+	}
+	return owned, complete
+}
+
+// stopDistances holds, for each city and town on the map, the distance from it to every other hex over land
+type stopDistances map[common.Coordinate]map[common.Coordinate]int
+
+// Distance used for track that can't reach any city or town
+const unreachableDistance = 1000
+
+func computeStopDistances(gameMap maps.GameMap) stopDistances {
+	isStop := func(hex common.Coordinate) bool {
+		hexType := gameMap.GetHexType(hex)
+		return hexType == maps.CITY_HEX_TYPE || hexType == maps.TOWN_HEX_TYPE
+	}
+
+	distances := make(stopDistances)
+	for x := 0; x < gameMap.GetWidth(); x++ {
+		for y := 0; y < gameMap.GetHeight(); y++ {
+			stop := common.Coordinate{X: x, Y: y}
+			if !isStop(stop) {
+				continue
+			}
+
+			// Breadth-first search outwards, not passing through other cities or towns
+			dist := map[common.Coordinate]int{stop: 0}
+			queue := []common.Coordinate{stop}
+			for len(queue) > 0 {
+				hex := queue[0]
+				queue = queue[1:]
+				if !hex.Equals(stop) && isStop(hex) {
+					continue
+				}
+				for _, direction := range common.ALL_DIRECTIONS {
+					next := common.ApplyDirection(hex, direction)
+					hexType := gameMap.GetHexType(next)
+					if hexType == maps.OFFBOARD_HEX_TYPE || hexType == maps.WATER_HEX_TYPE {
+						continue
+					}
+					if _, ok := dist[next]; !ok {
+						dist[next] = dist[hex] + 1
+						queue = append(queue, next)
+					}
+				}
+			}
+			distances[stop] = dist
+		}
+	}
+	return distances
+}
+
+// remaining estimates how many more hexes of track the player needs to build to complete all of their incomplete
+// links in the given state
+func (distances stopDistances) remaining(gameState *common.GameState, player string) int {
+	total := 0
+	for _, link := range gameState.Links {
+		if link.Owner != player || link.Complete {
+			continue
+		}
+		end := link.SourceHex
+		for _, step := range link.Steps {
+			end = common.ApplyDirection(end, step)
+		}
+
+		best := unreachableDistance
+		for stop, dist := range distances {
+			if stop.Equals(link.SourceHex) {
+				continue
+			}
+			if d, ok := dist[end]; ok && d < best {
+				best = d
+			}
+		}
+		total += best
+	}
+	return total
+}
+
+// deliverableCubes counts the cubes on the board that could be delivered over complete track using at most the
+// player's loco links, which is used to judge whether new track is worth building
+func deliverableCubes(gameState *common.GameState, gameMap maps.GameMap, player string) int {
+	// Stops that are directly connected by a complete link
+	neighbors := make(map[common.Coordinate][]common.Coordinate)
+	for _, link := range gameState.Links {
+		if !link.Complete {
+			continue
+		}
+		end := link.SourceHex
+		for _, step := range link.Steps {
+			end = common.ApplyDirection(end, step)
+		}
+		neighbors[link.SourceHex] = append(neighbors[link.SourceHex], end)
+		neighbors[end] = append(neighbors[end], link.SourceHex)
+	}
+
+	count := 0
+	for _, cube := range gameState.Cubes {
+		visited := map[common.Coordinate]bool{cube.Hex: true}
+		frontier := []common.Coordinate{cube.Hex}
+		delivered := false
+		for i := 0; i < gameState.PlayerLoco[player] && !delivered && len(frontier) > 0; i++ {
+			var next []common.Coordinate
+			for _, hex := range frontier {
+				for _, neighbor := range neighbors[hex] {
+					if visited[neighbor] {
+						continue
+					}
+					visited[neighbor] = true
+					if gameMap.GetCityColorForHex(gameState, neighbor) == cube.Color {
+						delivered = true
+						break
+					}
+					next = append(next, neighbor)
+				}
+			}
+			frontier = next
+		}
+		if delivered {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (bot *HeuristicBot) selectBuild(gameState *common.GameState, gameMap maps.GameMap, player string, rules Rules) (*api.ConfirmMoveRequest, error) {
+	distances := computeStopDistances(gameMap)
+	ownedBefore, _ := countOwnedLinks(gameState, player)
+
+	// Greedily add steps, preferring ones that open up new deliveries, then ones that connect cities, then ones that
+	// bring track closer to a city, then the cheapest
+	var steps []*api.BuildStep
+	current := gameState
+	for {
+		candidates, err := rules.PreviewBuildSteps(steps)
+		if err != nil {
+			return nil, err
+		}
+		_, completeCurrent := countOwnedLinks(current, player)
+		remainingCurrent := distances.remaining(current, player)
+		deliverableCurrent := deliverableCubes(current, gameMap, player)
+
+		var best *api.BuildStep
+		var bestResult *common.GameState
+		bestDeliverable := 0
+		bestCompletes := false
+		bestRemaining := 0
+		bestCost := 0
+		for _, candidate := range candidates {
+			// Keep it simple and leave urbanizing to the human players
+			if candidate.Step.Urbanization != nil {
+				continue
+			}
+			result := candidate.GameState
+			cost := candidate.Cost
+
+			// Never give up ownership of existing track or leave too little cash to pay expenses
+			ownedAfter, completeAfter := countOwnedLinks(result, player)
+			if ownedAfter < ownedBefore+len(result.Links)-len(gameState.Links) {
+				continue
+			}
+			if result.PlayerCash[player] < expenses(result, player) {
+				continue
+			}
+
+			deliverable := deliverableCubes(result, gameMap, player)
+			completes := completeAfter > completeCurrent
+			remaining := distances.remaining(result, player)
+			if best == nil || deliverable > bestDeliverable ||
+				(deliverable == bestDeliverable && completes && !bestCompletes) ||
+				(deliverable == bestDeliverable && completes == bestCompletes && remaining < bestRemaining) ||
+				(deliverable == bestDeliverable && completes == bestCompletes && remaining == bestRemaining && cost < bestCost) {
+				best = candidate.Step
+				bestResult = result
+				bestDeliverable = deliverable
+				bestCompletes = completes
+				bestRemaining = remaining
+				bestCost = cost
+			}
+		}
+
+		if best == nil {
+			break
+		}
+		// Keep building while it opens deliveries, connects cities or gets closer to doing so. Only start new track
+		// that is close to connecting.
+		if bestDeliverable <= deliverableCurrent && !bestCompletes && bestRemaining >= remainingCurrent {
+			if len(steps) > 0 || bestRemaining-remainingCurrent > heuristicBotMaxNewLinkLength {
+				break
+			}
+		}
+		steps = append(steps, best)
+		current = bestResult
+	}
+
+	return buildMove(steps), nil
+}
+
+func buildMove(steps []*api.BuildStep) *api.ConfirmMoveRequest {
+	return &api.ConfirmMoveRequest{
+		ActionName:  api.BuildActionName,
+		BuildAction: &api.BuildAction{Steps: steps},
+	}
+}
+
+func (bot *HeuristicBot) selectDelivery(gameState *common.GameState, player string, moves []*api.ConfirmMoveRequest, rules Rules) (*api.ConfirmMoveRequest, error) {
+	var pass *api.ConfirmMoveRequest
+	var best *api.ConfirmMoveRequest
+	bestGain := 0
+
+	for _, move := range moves {
+		action := move.MoveGoodsAction
+		if len(action.Path) == 0 {
+			if !action.Loco && action.Color == common.NONE_COLOR {
+				pass = move
+			}
+			continue
+		}
+
+		result, _, err := rules.PreviewMove(move)
+		if err != nil {
+			return nil, err
+		}
+		gain := result.PlayerIncome[player] - gameState.PlayerIncome[player]
+		if gain > bestGain {
+			best = move
+			bestGain = gain
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if pass != nil {
+		return pass, nil
+	}
+	return moves[0], nil
+}
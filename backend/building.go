@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/api"
 	"github.com/JackOfMostTrades/eot/backend/common"
 	"github.com/JackOfMostTrades/eot/backend/maps"
@@ -43,8 +42,11 @@ func (performer *buildActionPerformer) attemptTownPlacement(hex common.Coordinat
 		// If it hits player existing track, then mark it as complete
 		// If it hits nothing, add an incomplete new link for the player
 
-		nextHex := applyDirection(hex, direction)
+		nextHex := common.ApplyDirection(hex, direction)
 		next := mapState.GetTileState(nextHex)
+		if next == nil {
+			return invalidMoveErr("cannot build town track off the edge of the map")
+		}
 		var link *common.Link
 		if next.isCity {
 			link = &common.Link{
@@ -92,7 +94,12 @@ func (performer *buildActionPerformer) attemptTeleportLinkPlacement(hex common.C
 	otherHex, otherDirection := performer.gameMap.GetTeleportLink(performer.gameState, hex, direction)
 	if otherHex == nil {
 		return invalidMoveErr("cannot place teleport link at %s in direction %d",
-			renderHexCoordinate(hex), direction)
+			common.RenderHexCoordinate(hex), direction)
+	}
+	// The far end of the link is built on too
+	err := performer.gameMap.CheckBuildableHex(performer.gameState, *otherHex)
+	if err != nil {
+		return invalidMoveErr("%v", err)
 	}
 
 	// Check all steps of all links and validate both sides of the teleport
@@ -105,7 +112,7 @@ func (performer *buildActionPerformer) attemptTeleportLinkPlacement(hex common.C
 			if linkHex == *otherHex && step == otherDirection {
 				return invalidMoveErr("another player has already built on this link")
 			}
-			linkHex = applyDirection(linkHex, step)
+			linkHex = common.ApplyDirection(linkHex, step)
 		}
 	}
 
@@ -134,7 +141,8 @@ func (performer *buildActionPerformer) determineTrackBuildCost(hex common.Coordi
 	cost, err := performer.gameMap.GetTrackBuildCost(performer.gameState, performer.activePlayer,
 		hexType, hex, tiles.GetTrackType(trackPlacement.Tile), len(ts.routes) != 0)
 	if err != nil {
-		return 0, fmt.Errorf("failed to determine cost for placing track tile: %v", err)
+		// The map doesn't allow this track here
+		return 0, invalidMoveErr("cannot place track tile at %s: %v", common.RenderHexCoordinate(hex), err)
 	}
 
 	return cost, nil
@@ -200,7 +208,7 @@ func (performer *buildActionPerformer) handleUrbanization(hex common.Coordinate,
 	// Check if there is adjacent incomplete link that becomes completed by this build
 	mapState := newMapState(performer.gameMap, performer.gameState)
 	for _, direction := range common.ALL_DIRECTIONS {
-		adjacentHex := applyDirection(hex, direction)
+		adjacentHex := common.ApplyDirection(hex, direction)
 		ts := mapState.GetTileState(adjacentHex)
 		if ts != nil {
 			for _, route := range ts.routes {
@@ -278,13 +286,17 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 	// Apply builds and count up the costs
 	var costs []int
 	for _, step := range buildAction.Steps {
+		err := handler.gameMap.CheckBuildableHex(gameState, step.Hex)
+		if err != nil {
+			return invalidMoveErr("%v", err)
+		}
 		if step.Urbanization != nil {
 			err := performer.handleUrbanization(step.Hex, *step.Urbanization)
 			if err != nil {
 				return err
 			}
 			handler.Log("%s urbanizes new city %c at %s",
-				handler.ActivePlayerNick(), 'A'+*step.Urbanization, renderHexCoordinate(step.Hex))
+				handler.ActivePlayerNick(), 'A'+*step.Urbanization, common.RenderHexCoordinate(step.Hex))
 		}
 		if step.TownPlacement != nil {
 			cost, err := performer.determineTownBuildCost(step.Hex, step.TownPlacement)
@@ -297,7 +309,7 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 				return err
 			}
 			handler.Log("%s added track on town hex %s",
-				handler.ActivePlayerNick(), renderHexCoordinate(step.Hex))
+				handler.ActivePlayerNick(), common.RenderHexCoordinate(step.Hex))
 		}
 		if step.TrackPlacement != nil {
 			cost, err := performer.determineTrackBuildCost(step.Hex, step.TrackPlacement)
@@ -310,7 +322,7 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 				return err
 			}
 			handler.Log("%s added track on hex %s",
-				handler.ActivePlayerNick(), renderHexCoordinate(step.Hex))
+				handler.ActivePlayerNick(), common.RenderHexCoordinate(step.Hex))
 		}
 		if step.TeleportLinkPlacement != nil {
 			cost := handler.gameMap.GetTeleportLinkBuildCost(gameState, handler.activePlayer,
@@ -324,7 +336,7 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 				return err
 			}
 			handler.Log("%s added teleport link on hex %s",
-				handler.ActivePlayerNick(), renderHexCoordinate(step.Hex))
+				handler.ActivePlayerNick(), common.RenderHexCoordinate(step.Hex))
 		}
 	}
 
@@ -334,6 +346,7 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 			totalCost, gameState.PlayerCash[performer.activePlayer])
 	}
 	gameState.PlayerCash[performer.activePlayer] -= totalCost
+	handler.buildCost = totalCost
 	handler.Log("%s paid a total of $%d for track placements.", handler.ActivePlayerNick(), totalCost)
 
 	// Verify we have not exceeded any component limits by this build
@@ -359,7 +372,7 @@ func (handler *confirmMoveHandler) performBuildAction(buildAction *api.BuildActi
 	for _, link := range gameState.Links {
 		if !link.Complete && link.Owner == handler.activePlayer && !performer.extendedLinks[link] {
 			handler.Log("%s lost ownership of an incomplete track that started at hex %s",
-				handler.ActivePlayerNick(), renderHexCoordinate(link.SourceHex))
+				handler.ActivePlayerNick(), common.RenderHexCoordinate(link.SourceHex))
 			link.Owner = ""
 		}
 	}
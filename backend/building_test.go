@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/api"
+	"slices"
 	"testing"
 
 	"github.com/JackOfMostTrades/eot/backend/tiles"
@@ -1379,3 +1381,102 @@ func TestNoLoopingConnection(t *testing.T) {
 	require.ErrorAs(t, err, &invalidMove)
 	assert.Equal(t, invalidMove.Error(), "individual links are not allowed to start and end at the same town/city")
 }
+
+// blockedHexTestMap forbids building on some hexes and has a teleport link between the cities at A1 and A3
+type blockedHexTestMap struct {
+	testMap
+	blocked []common.Coordinate
+}
+
+func (t *blockedHexTestMap) CheckBuildableHex(gameState *common.GameState, hex common.Coordinate) error {
+	if slices.Contains(t.blocked, hex) {
+		return fmt.Errorf("cannot build at %s", common.RenderHexCoordinate(hex))
+	}
+	return nil
+}
+
+func (t *blockedHexTestMap) GetTeleportLink(gameState *common.GameState, src common.Coordinate, direction common.Direction) (*common.Coordinate, common.Direction) {
+	if src == (common.Coordinate{X: 0, Y: 0}) && direction == common.SOUTH {
+		return &common.Coordinate{X: 0, Y: 2}, common.NORTH
+	}
+	if src == (common.Coordinate{X: 0, Y: 2}) && direction == common.NORTH {
+		return &common.Coordinate{X: 0, Y: 0}, common.SOUTH
+	}
+	return nil, 0
+}
+
+func (t *blockedHexTestMap) GetTeleportLinkBuildCost(gameState *common.GameState, player string, hex common.Coordinate, direction common.Direction) int {
+	if dest, _ := t.GetTeleportLink(gameState, hex, direction); dest != nil {
+		return 6
+	}
+	return 0
+}
+
+func TestBuildOnBlockedHex(t *testing.T) {
+	playerId := "player1"
+	city := 0
+	for _, tc := range []struct {
+		name    string
+		step    *api.BuildStep
+		blocked common.Coordinate
+	}{
+		{"track", &api.BuildStep{
+			Hex:            common.Coordinate{X: 0, Y: 1},
+			TrackPlacement: &api.TrackPlacement{Tile: tiles.GENTLE_CURVE_TRACK_TILE, Rotation: 4},
+		}, common.Coordinate{X: 0, Y: 1}},
+		{"town", &api.BuildStep{
+			Hex:           common.Coordinate{X: 1, Y: 1},
+			TownPlacement: &api.TownPlacement{Track: []common.Direction{common.NORTH_EAST}},
+		}, common.Coordinate{X: 1, Y: 1}},
+		{"urbanization", &api.BuildStep{
+			Hex:          common.Coordinate{X: 1, Y: 1},
+			Urbanization: &city,
+		}, common.Coordinate{X: 1, Y: 1}},
+		{"teleport link", &api.BuildStep{
+			Hex:                   common.Coordinate{X: 0, Y: 0},
+			TeleportLinkPlacement: &api.TeleportLinkPlacement{Track: common.SOUTH},
+		}, common.Coordinate{X: 0, Y: 0}},
+		{"far end of a teleport link", &api.BuildStep{
+			Hex:                   common.Coordinate{X: 0, Y: 0},
+			TeleportLinkPlacement: &api.TeleportLinkPlacement{Track: common.SOUTH},
+		}, common.Coordinate{X: 0, Y: 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			build := func(blocked []common.Coordinate) (*common.GameState, error) {
+				gameMap := &blockedHexTestMap{
+					testMap: testMap{
+						hexes: [][]maps.HexType{
+							{maps.CITY_HEX_TYPE, maps.PLAINS_HEX_TYPE, maps.CITY_HEX_TYPE},
+							{maps.PLAINS_HEX_TYPE, maps.TOWN_HEX_TYPE, maps.PLAINS_HEX_TYPE},
+							{maps.CITY_HEX_TYPE, maps.PLAINS_HEX_TYPE, maps.PLAINS_HEX_TYPE},
+						},
+					},
+					blocked: blocked,
+				}
+				gameState := &common.GameState{
+					GamePhase:     common.BUILDING_GAME_PHASE,
+					PlayerCash:    map[string]int{playerId: 10},
+					PlayerActions: map[string]common.SpecialAction{playerId: common.URBANIZATION_SPECIAL_ACTION},
+				}
+				handler := &confirmMoveHandler{
+					gameMap:      gameMap,
+					gameState:    gameState,
+					activePlayer: playerId,
+				}
+				return gameState, handler.performBuildAction(&api.BuildAction{Steps: []*api.BuildStep{tc.step}})
+			}
+
+			// The build is fine anywhere else
+			_, err := build(nil)
+			require.NoError(t, err)
+
+			gameState, err := build([]common.Coordinate{tc.blocked})
+			var invalidMove *invalidMoveError
+			require.ErrorAs(t, err, &invalidMove)
+			assert.Equal(t, "cannot build at "+common.RenderHexCoordinate(tc.blocked), invalidMove.Error())
+			assert.Empty(t, gameState.Links)
+			assert.Empty(t, gameState.Urbanizations)
+			assert.Equal(t, 10, gameState.PlayerCash[playerId])
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package common
 
+import "fmt"
+
 type Coordinate struct {
 	X int `json:"x"`
 	Y int `json:"y"`
@@ -8,3 +10,55 @@ type Coordinate struct {
 func (c Coordinate) Equals(other Coordinate) bool {
 	return c.X == other.X && c.Y == other.Y
 }
+
+// RenderHexCoordinate renders the coordinate the way the board labels it, e.g. "A1" for the top left hex
+func RenderHexCoordinate(coordinate Coordinate) string {
+	var x int
+	if coordinate.Y%2 == 0 {
+		x = coordinate.X*2 + 1
+	} else {
+		x = coordinate.X*2 + 2
+	}
+
+	yLabel := string(rune((coordinate.Y % 26) + 'A'))
+	if coordinate.Y >= 26 {
+		yLabel = "A" + yLabel
+	}
+
+	return fmt.Sprintf("%s%d", yLabel, x)
+}
+
+// ApplyDirection returns the coordinate of the adjacent hex in the given direction
+func ApplyDirection(coord Coordinate, direction Direction) Coordinate {
+	switch direction {
+	case NORTH:
+		return Coordinate{X: coord.X, Y: coord.Y - 2}
+	case NORTH_EAST:
+		if (coord.Y % 2) == 0 {
+			return Coordinate{X: coord.X, Y: coord.Y - 1}
+		} else {
+			return Coordinate{X: coord.X + 1, Y: coord.Y - 1}
+		}
+	case SOUTH_EAST:
+		if (coord.Y % 2) == 0 {
+			return Coordinate{X: coord.X, Y: coord.Y + 1}
+		} else {
+			return Coordinate{X: coord.X + 1, Y: coord.Y + 1}
+		}
+	case SOUTH:
+		return Coordinate{X: coord.X, Y: coord.Y + 2}
+	case SOUTH_WEST:
+		if (coord.Y % 2) == 0 {
+			return Coordinate{X: coord.X - 1, Y: coord.Y + 1}
+		} else {
+			return Coordinate{X: coord.X, Y: coord.Y + 1}
+		}
+	case NORTH_WEST:
+		if (coord.Y % 2) == 0 {
+			return Coordinate{X: coord.X - 1, Y: coord.Y - 1}
+		} else {
+			return Coordinate{X: coord.X, Y: coord.Y - 1}
+		}
+	}
+	panic(fmt.Errorf("unhandled direction: %v", direction))
+}
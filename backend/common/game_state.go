@@ -1,6 +1,9 @@
 package common
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+)
 
 type GamePhase int
 
@@ -28,6 +31,108 @@ const (
 var ALL_SPECIAL_ACTIONS = []SpecialAction{FIRST_MOVE_SPECIAL_ACTION, FIRST_BUILD_SPECIAL_ACTION, ENGINEER_SPECIAL_ACTION, LOCO_SPECIAL_ACTION,
 	URBANIZATION_SPECIAL_ACTION, PRODUCTION_SPECIAL_ACTION, TURN_ORDER_PASS_SPECIAL_ACTION}
 
+// MapVariant is an optional rule that can be turned on when creating a game on a map that supports it
+type MapVariant string
+
+const (
+	// Urbanization can't be chosen on the first turn
+	NO_FIRST_TURN_URBANIZATION_VARIANT MapVariant = "no_first_turn_urbanization"
+	// Cities start with the cubes listed in the map file instead of cubes drawn from the bag
+	FIXED_STARTING_CUBES_VARIANT MapVariant = "fixed_starting_cubes"
+	// Players start the first turn having already taken a third share, along with its $5
+	FIRST_TURN_EXTRA_SHARE_VARIANT MapVariant = "first_turn_extra_share"
+)
+
+var ALL_MAP_VARIANTS = []MapVariant{NO_FIRST_TURN_URBANIZATION_VARIANT, FIXED_STARTING_CUBES_VARIANT, FIRST_TURN_EXTRA_SHARE_VARIANT}
+
+const (
+	DEFAULT_STARTING_CASH   = 10
+	DEFAULT_STARTING_SHARES = 2
+
+	// The largest values the house rules can be set to
+	MAX_STARTING_CASH = 100
+	MAX_SHARES_LIMIT  = 30
+	MAX_TURN_LIMIT    = 20
+)
+
+// AuctionType is how the turn order is decided each turn
+type AuctionType string
+
+const (
+	// The map's own auction, normally the standard auction
+	STANDARD_AUCTION_TYPE AuctionType = "standard"
+	// Every player makes one secret bid and pays it, with the highest bids going first
+	SEALED_BID_AUCTION_TYPE AuctionType = "sealed_bid"
+	// The standard auction, except that every player pays their full bid
+	PAY_FULL_BID_AUCTION_TYPE AuctionType = "pay_full_bid"
+	// There is no auction and the turn order never changes
+	FIXED_ORDER_AUCTION_TYPE AuctionType = "fixed_order"
+)
+
+var ALL_AUCTION_TYPES = []AuctionType{STANDARD_AUCTION_TYPE, SEALED_BID_AUCTION_TYPE, PAY_FULL_BID_AUCTION_TYPE, FIXED_ORDER_AUCTION_TYPE}
+
+// GameOptions are house rules chosen when creating a game. Options left unset keep the normal rule.
+type GameOptions struct {
+	// Pointers since starting with no cash or no shares is a valid house rule
+	StartingCash   *int `json:"startingCash,omitempty"`
+	StartingShares *int `json:"startingShares,omitempty"`
+	// Overrides the map's limit on how many shares a player can take
+	SharesLimit int `json:"sharesLimit,omitempty"`
+	// Overrides the map's number of turns
+	TurnLimit int `json:"turnLimit,omitempty"`
+	// Takes the turn order pass special action out of the game
+	NoTurnOrderPass bool `json:"noTurnOrderPass,omitempty"`
+	// Replaces the standard auction, mostly for learning games
+	AuctionType AuctionType `json:"auctionType,omitempty"`
+}
+
+// The getters below are safe to call on nil options, which is what games created without any have
+
+func (options *GameOptions) GetStartingCash() int {
+	if options == nil || options.StartingCash == nil {
+		return DEFAULT_STARTING_CASH
+	}
+	return *options.StartingCash
+}
+
+func (options *GameOptions) GetStartingShares() int {
+	if options == nil || options.StartingShares == nil {
+		return DEFAULT_STARTING_SHARES
+	}
+	return *options.StartingShares
+}
+
+// GetSharesLimit returns the overridden shares limit, or mapLimit if it isn't overridden
+func (options *GameOptions) GetSharesLimit(mapLimit int) int {
+	if options == nil || options.SharesLimit == 0 {
+		return mapLimit
+	}
+	return options.SharesLimit
+}
+
+// GetTurnLimit returns the overridden turn limit, or mapLimit if it isn't overridden
+func (options *GameOptions) GetTurnLimit(mapLimit int) int {
+	if options == nil || options.TurnLimit == 0 {
+		return mapLimit
+	}
+	return options.TurnLimit
+}
+
+func (options *GameOptions) GetAuctionType() AuctionType {
+	if options == nil || options.AuctionType == "" {
+		return STANDARD_AUCTION_TYPE
+	}
+	return options.AuctionType
+}
+
+// IsTurnOrderPassAvailable returns false if the option is off, or if there's no auction to use it in
+func (options *GameOptions) IsTurnOrderPassAvailable() bool {
+	if options == nil {
+		return true
+	}
+	return !options.NoTurnOrderPass && options.GetAuctionType() != FIXED_ORDER_AUCTION_TYPE
+}
+
 type Link struct {
 	SourceHex Coordinate  `json:"sourceHex"`
 	Steps     []Direction `json:"steps"`
@@ -79,6 +184,14 @@ type GameState struct {
 
 	// Untyped JSON object that maps can use for map-custom state
 	MapState map[string]interface{} `json:"mapState,omitempty"`
+	// The variant rules the game was created with
+	MapVariants []MapVariant `json:"mapVariants,omitempty"`
+	// The house rules the game was created with
+	Options *GameOptions `json:"options,omitempty"`
+}
+
+func (gameState *GameState) HasMapVariant(variant MapVariant) bool {
+	return slices.Contains(gameState.MapVariants, variant)
 }
 
 func (gameState *GameState) PullCube(color Color, count int) (int, error) {
@@ -1,8 +1,12 @@
 package common
 
 import (
+	"crypto/hmac"
 	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"math/big"
 )
 
@@ -23,3 +27,48 @@ func (*CryptoRandProvider) RandN(n int) (int, error) {
 	}
 	return int(val.Int64()), nil
 }
+
+// SeededRandProvider derives every value from a secret seed, so that once the seed is revealed anyone can check the
+// values that were drawn. For the i-th draw (counting from zero) of a value less than n, it takes the first 8 bytes of
+// HMAC-SHA256(seed, "<i>:<attempt>") as a big-endian integer for attempt = 0, 1, ... until one is below the largest
+// multiple of n that fits in 64 bits, and returns that integer modulo n.
+type SeededRandProvider struct {
+	Seed []byte
+	// Number of values drawn so far
+	Count int
+}
+
+func (srp *SeededRandProvider) RandN(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid bound for random number: %d", n)
+	}
+	bound := uint64(n)
+	limit := math.MaxUint64 - math.MaxUint64%bound
+	for attempt := 0; ; attempt++ {
+		mac := hmac.New(sha256.New, srp.Seed)
+		mac.Write([]byte(fmt.Sprintf("%d:%d", srp.Count, attempt)))
+		val := binary.BigEndian.Uint64(mac.Sum(nil))
+		if val < limit {
+			srp.Count += 1
+			return int(val % bound), nil
+		}
+	}
+}
+
+// RecordingRandProvider keeps a copy of every value drawn from the underlying provider, along with the bound it was
+// drawn with, so that the draws can be stored and replayed later
+type RecordingRandProvider struct {
+	Provider RandProvider
+	Values   []int
+	Bounds   []int
+}
+
+func (rrp *RecordingRandProvider) RandN(n int) (int, error) {
+	val, err := rrp.Provider.RandN(n)
+	if err != nil {
+		return 0, err
+	}
+	rrp.Values = append(rrp.Values, val)
+	rrp.Bounds = append(rrp.Bounds, n)
+	return val, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 type DatabaseConfig struct {
+	// If set, apply any pending schema migrations at startup
 	Bootstrap     bool   `json:"bootstrap"`
 	SqlitePath    string `json:"sqlitePath"`
 	MysqlHostname string `json:"mysqlHostname"`
@@ -31,4 +32,6 @@ type Config struct {
 	Email                    *EmailConfig          `json:"email"`
 	Database                 *DatabaseConfig       `json:"database"`
 	DiscordTurnAlertsWebhook string                `json:"discordTurnAlertsWebhook"`
+	// Users who can request rollbacks of any game, not just the games they own
+	AdminUserIds []string `json:"adminUserIds"`
 }
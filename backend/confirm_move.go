@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
@@ -25,6 +26,12 @@ type confirmMoveHandler struct {
 	playerIdToNick map[string]string
 	randProvider   common.RandProvider
 	gameFinished   bool
+	// Total cost paid by the active player for a build action, if one was performed
+	buildCost int
+	// Set once the game has finished
+	finalScores []*PlayerScore
+	// Version of the game row the state was loaded from, incremented whenever the game's state is saved
+	version int
 }
 
 func (handler *confirmMoveHandler) NumPlayers() int {
@@ -53,13 +60,17 @@ func invalidMoveErr(format string, a ...any) error {
 }
 
 func newConfirmMoveHandler(server *GameServer, gameId string, gameMap maps.GameMap, gameState *common.GameState, activePlayer string) (*confirmMoveHandler, error) {
+	randProvider, err := server.getGameRandProvider(gameId)
+	if err != nil {
+		return nil, err
+	}
 	handler := &confirmMoveHandler{
 		gameId:         gameId,
 		gameMap:        gameMap,
 		gameState:      gameState,
 		activePlayer:   activePlayer,
 		playerIdToNick: make(map[string]string),
-		randProvider:   server.randProvider,
+		randProvider:   &common.RecordingRandProvider{Provider: randProvider},
 		gameFinished:   false,
 		reversible:     true,
 	}
@@ -87,6 +98,15 @@ func newConfirmMoveHandler(server *GameServer, gameId string, gameMap maps.GameM
 	return handler, nil
 }
 
+// randomValues returns the random values drawn while handling actions and the bounds they were drawn with, or nil if
+// they aren't being recorded
+func (handler *confirmMoveHandler) randomValues() ([]int, []int) {
+	if recorder, ok := handler.randProvider.(*common.RecordingRandProvider); ok {
+		return recorder.Values, recorder.Bounds
+	}
+	return nil, nil
+}
+
 func (handler *confirmMoveHandler) Log(format string, a ...any) {
 	handler.logs = append(handler.logs, fmt.Sprintf(format, a...))
 }
@@ -102,116 +122,295 @@ func (handler *confirmMoveHandler) ActivePlayerNick() string {
 	return handler.PlayerNick(handler.activePlayer)
 }
 
-func (server *GameServer) confirmMove(ctx *RequestContext, req *api.ConfirmMoveRequest) (resp *api.ConfirmMoveResponse, err error) {
-	stmt, err := server.db.Prepare("SELECT owner_user_id,map_name,started,finished,game_state,active_player_id FROM games WHERE id=?")
+// newConfirmMoveHandlerForGame loads the current state of a started (and not yet finished) game and returns a handler
+// for it, positioned at the game's current active player.
+func (server *GameServer) newConfirmMoveHandlerForGame(gameId string) (*confirmMoveHandler, error) {
+	stmt, err := server.db.Prepare("SELECT map_name,started,finished,game_state,active_player_id,version FROM games WHERE id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRow(req.GameId)
-	var ownerUserId string
+	row := stmt.QueryRow(gameId)
 	var mapName string
 	var startedFlag int
 	var finishedFlag int
-	var gameStateStr string
-	var activePlayer string
-	err = row.Scan(&ownerUserId, &mapName, &startedFlag, &finishedFlag, &gameStateStr, &activePlayer)
+	var gameStateStr sql.NullString
+	var activePlayer sql.NullString
+	var version int
+	err = row.Scan(&mapName, &startedFlag, &finishedFlag, &gameStateStr, &activePlayer, &version)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
+			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", gameId), http.StatusBadRequest}
 		}
 		return nil, fmt.Errorf("failed to fetch game row: %v", err)
 	}
 
 	if startedFlag == 0 {
-		return nil, &api.HttpError{fmt.Sprintf("cannot make a move if game hasn't started yet: %s", req.GameId), http.StatusBadRequest}
+		return nil, &api.HttpError{fmt.Sprintf("cannot make a move if game hasn't started yet: %s", gameId), http.StatusBadRequest}
 	}
 	if finishedFlag != 0 {
-		return nil, &api.HttpError{fmt.Sprintf("cannot make a move if game has finished: %s", req.GameId), http.StatusBadRequest}
+		return nil, &api.HttpError{fmt.Sprintf("cannot make a move if game has finished: %s", gameId), http.StatusBadRequest}
 	}
 
 	gameState := new(common.GameState)
-	err = json.Unmarshal([]byte(gameStateStr), gameState)
+	err = json.Unmarshal([]byte(gameStateStr.String), gameState)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse game state: %v", err)
 	}
-	if activePlayer != ctx.User.Id {
-		return nil, &api.HttpError{fmt.Sprintf("user [%s] is not the active player [%s]", ctx.User.Id, activePlayer), http.StatusPreconditionFailed}
-	}
 
-	gameMap := server.gameMaps[mapName]
+	gameMap, err := server.getGameMap(mapName)
+	if err != nil {
+		return nil, err
+	}
+	if gameMap == nil {
+		return nil, fmt.Errorf("failed to lookup map: %s", mapName)
+	}
 
-	handler, err := newConfirmMoveHandler(server, req.GameId, gameMap, gameState, activePlayer)
+	handler, err := newConfirmMoveHandler(server, gameId, gameMap, gameState, activePlayer.String)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize handler: %v", err)
 	}
+	handler.version = version
+	return handler, nil
+}
 
-	err = handler.handleAction(req)
+// fork returns a copy of this handler operating on a deep copy of the game state, so that actions can be tried out
+// without affecting the real game. The copy always uses a throwaway source of randomness.
+func (handler *confirmMoveHandler) fork() (*confirmMoveHandler, error) {
+	gameState, err := cloneGameState(handler.gameState)
+	if err != nil {
+		return nil, err
+	}
+	return &confirmMoveHandler{
+		gameId:         handler.gameId,
+		gameMap:        handler.gameMap,
+		gameState:      gameState,
+		activePlayer:   handler.activePlayer,
+		playerIdToNick: handler.playerIdToNick,
+		randProvider:   &common.CryptoRandProvider{},
+		gameFinished:   false,
+		reversible:     true,
+	}, nil
+}
+
+func (server *GameServer) confirmMove(ctx *RequestContext, req *api.ConfirmMoveRequest) (resp *api.ConfirmMoveResponse, err error) {
+	err = server.performMove(ctx.User.Id, req)
 	if err != nil {
 		return nil, err
 	}
 
-	newGameStateStr, err := json.Marshal(gameState)
+	// The move has been saved, so a failing bot shouldn't fail the request; the game will wait on the bot instead
+	err = server.runBots(req.GameId, maxBotMovesPerRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal game state: %v", err)
+		slog.Error("failed to run bot move", "error", err, "gameId", req.GameId)
 	}
 
+	return &api.ConfirmMoveResponse{}, nil
+}
+
+// performMove applies the move on behalf of the given user, who must be the active player, then saves the result and
+// notifies players
+func (server *GameServer) performMove(userId string, req *api.ConfirmMoveRequest) error {
+	handler, err := server.newConfirmMoveHandlerForGame(req.GameId)
+	if err != nil {
+		return err
+	}
+	if req.Version != nil && *req.Version != handler.version {
+		return &api.HttpError{"the game has changed since this move was made", http.StatusConflict}
+	}
+	if handler.activePlayer != userId {
+		return &api.HttpError{fmt.Sprintf("user [%s] is not the active player [%s]", userId, handler.activePlayer), http.StatusPreconditionFailed}
+	}
+
+	err = handler.handleAction(req)
+	if err != nil {
+		return err
+	}
+
+	return server.saveMove(handler, userId, req)
+}
+
+// saveMove records a move that has been applied by the handler on behalf of the given user, updates the game and
+// notifies players. The move is rejected if the game has changed since the handler loaded it.
+func (server *GameServer) saveMove(handler *confirmMoveHandler, userId string, req *api.ConfirmMoveRequest) error {
+	finishedFlag := 0
+	newGameStateStr, err := json.Marshal(handler.gameState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game state: %v", err)
+	}
+
+	var finalScoresStr sql.NullString
 	if handler.gameFinished {
 		finishedFlag = 1
 		handler.reversible = false
+
+		finalScoresBytes, err := json.Marshal(handler.finalScores)
+		if err != nil {
+			return fmt.Errorf("failed to marshal final scores: %v", err)
+		}
+		finalScoresStr = sql.NullString{String: string(finalScoresBytes), Valid: true}
 	}
 
-	// Log the action
-	stmt, err = server.db.Prepare("INSERT INTO game_log (game_id,timestamp,user_id,action,description,new_active_player,new_game_state,reversible) VALUES(?, ?, ?, ?, ?, ?, ?, ?)")
+	tx, err := server.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare query: %v", err)
+		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
-	defer stmt.Close()
-	reqString, err := json.Marshal(req)
+	defer tx.Rollback()
+
+	// Update the game state, as long as no other move got there first
+	result, err := tx.Exec("UPDATE games SET active_player_id=?,game_state=?,finished=?,final_scores=?,version=version+1 WHERE id=? AND version=?",
+		handler.activePlayer, string(newGameStateStr), finishedFlag, finalScoresStr, req.GameId, handler.version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to serialze the request for logging: %v", err)
+		return fmt.Errorf("failed to execute query: %v", err)
 	}
-	_, err = stmt.Exec(req.GameId, time.Now().Unix(), ctx.User.Id, string(reqString), strings.Join(handler.logs, "\n"),
-		handler.activePlayer, string(newGameStateStr), boolToInt(handler.reversible))
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		return fmt.Errorf("failed to get number of rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return &api.HttpError{"the game has changed since this move was made", http.StatusConflict}
+	}
+	err = saveRandDrawCount(tx, req.GameId, handler.randProvider)
+	if err != nil {
+		return err
 	}
 
-	// Update the game state
-	stmt, err = server.db.Prepare("UPDATE games SET active_player_id=?,game_state=?,finished=? WHERE id=?")
+	// Log the action. The version is only for checking against concurrent moves, so it isn't part of the logged action.
+	randomValues, randomBounds := handler.randomValues()
+	randomValuesStr, err := marshalRandomValues(randomValues)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare query: %v", err)
+		return err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(handler.activePlayer, string(newGameStateStr), finishedFlag, req.GameId)
+	randomBoundsStr, err := marshalRandomValues(randomBounds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		return err
+	}
+	loggedReq := *req
+	loggedReq.Version = nil
+	reqString, err := json.Marshal(&loggedReq)
+	if err != nil {
+		return fmt.Errorf("failed to serialze the request for logging: %v", err)
+	}
+	seq, err := nextSeq(tx, "game_log", req.GameId)
+	if err != nil {
+		return err
+	}
+	logEntry := &GameLogEntry{
+		Seq:          seq,
+		Timestamp:    int(time.Now().Unix()),
+		UserId:       userId,
+		Action:       string(reqString),
+		Description:  strings.Join(handler.logs, "\n"),
+		Reversible:   handler.reversible,
+		RandomValues: randomValues,
+		RandomBounds: randomBounds,
+	}
+	_, err = tx.Exec("INSERT INTO game_log (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		req.GameId, logEntry.Seq, logEntry.Timestamp, logEntry.UserId, logEntry.Action, logEntry.Description,
+		handler.activePlayer, string(newGameStateStr), boolToInt(logEntry.Reversible), randomValuesStr, randomBoundsStr)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	if handler.gameFinished {
+		err = updateRatings(tx, req.GameId, handler.finalScores)
+		if err != nil {
+			return err
+		}
 	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	handler.version += 1
+
+	server.publishGameEvent(req.GameId, &GameEvent{
+		Type:         GAME_EVENT_MOVE,
+		Log:          logEntry,
+		ActivePlayer: handler.activePlayer,
+		Finished:     handler.gameFinished,
+	})
 
 	// Send notifications
 	if finishedFlag != 0 {
 		userIds, err := server.getJoinedUsers(req.GameId)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get game users: %v", err)
+			return fmt.Errorf("failed to get game users: %v", err)
 		}
-		for userId := range userIds {
-			err = server.notifyPlayer(req.GameId, userId)
+		for joinedUserId := range userIds {
+			err = server.notifyPlayer(req.GameId, joinedUserId)
 			if err != nil {
-				return nil, fmt.Errorf("failed to notify user of game end: %v", err)
+				return fmt.Errorf("failed to notify user of game end: %v", err)
 			}
 		}
 	} else {
 		// Notify the next player that it is their turn if the active player changed
-		if handler.activePlayer != ctx.User.Id {
+		if handler.activePlayer != userId {
 			err = server.notifyPlayer(req.GameId, handler.activePlayer)
 			if err != nil {
-				return nil, fmt.Errorf("failed to notify user it's their turn: %v", err)
+				return fmt.Errorf("failed to notify user it's their turn: %v", err)
 			}
 		}
 	}
 
-	return &api.ConfirmMoveResponse{}, nil
+	return nil
+}
+
+// dbOrTx is implemented by both *sql.DB and *sql.Tx
+type dbOrTx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// nextSeq returns the sequence number for a new row in a game's log or chat table. Rows are ordered by sequence number
+// rather than by timestamp, since several rows can be added in the same second (e.g. by bots).
+func nextSeq(db dbOrTx, table string, gameId string) (int, error) {
+	var lastSeq sql.NullInt64
+	err := db.QueryRow("SELECT MAX(seq) FROM "+table+" WHERE game_id=?", gameId).Scan(&lastSeq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	return int(lastSeq.Int64) + 1, nil
+}
+
+type PreviewMoveResponse struct {
+	GameState    *common.GameState `json:"gameState"`
+	ActivePlayer string            `json:"activePlayer"`
+	Logs         []string          `json:"logs"`
+	BuildCost    int               `json:"buildCost"`
+	Reversible   bool              `json:"reversible"`
+	GameFinished bool              `json:"gameFinished"`
+}
+
+// previewMove runs the given move against a copy of the game state and reports the outcome, without saving anything
+// or notifying anyone.
+func (server *GameServer) previewMove(ctx *RequestContext, req *api.ConfirmMoveRequest) (resp *PreviewMoveResponse, err error) {
+	handler, err := server.newConfirmMoveHandlerForGame(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	if handler.activePlayer != ctx.User.Id {
+		return nil, &api.HttpError{fmt.Sprintf("user [%s] is not the active player [%s]", ctx.User.Id, handler.activePlayer), http.StatusPreconditionFailed}
+	}
+
+	preview, err := handler.fork()
+	if err != nil {
+		return nil, err
+	}
+	err = preview.handleAction(req)
+	if err != nil {
+		return nil, err
+	}
+	hideSealedBids(preview.gameState, ctx.User.Id)
+
+	return &PreviewMoveResponse{
+		GameState:    preview.gameState,
+		ActivePlayer: preview.activePlayer,
+		Logs:         preview.logs,
+		BuildCost:    preview.buildCost,
+		Reversible:   preview.reversible && !preview.gameFinished,
+		GameFinished: preview.gameFinished,
+	}, nil
 }
 
 func (handler *confirmMoveHandler) handleAction(req *api.ConfirmMoveRequest) error {
@@ -239,10 +438,14 @@ func (handler *confirmMoveHandler) handleAction(req *api.ConfirmMoveRequest) err
 		}
 		return err
 	}
+	if handler.gameFinished && handler.finalScores == nil {
+		handler.scoreGame()
+	}
 	return nil
 }
 
-func (handler *confirmMoveHandler) handleSharesAction(sharesAction *api.SharesAction) error {
+// checkSharesAction checks whether the active player may take the given shares, without changing the game state
+func (handler *confirmMoveHandler) checkSharesAction(sharesAction *api.SharesAction) error {
 	gameState := handler.gameState
 	if sharesAction == nil || sharesAction.Amount < 0 {
 		return &api.HttpError{"missing shares action", http.StatusBadRequest}
@@ -251,13 +454,23 @@ func (handler *confirmMoveHandler) handleSharesAction(sharesAction *api.SharesAc
 		return &api.HttpError{fmt.Sprintf("invalid action for current phase %d", gameState.GamePhase), http.StatusPreconditionFailed}
 	}
 
-	currentPlayer := handler.activePlayer
-	newSharesCount := gameState.PlayerShares[currentPlayer] + sharesAction.Amount
-	sharesLimit := handler.gameMap.GetSharesLimit()
+	newSharesCount := gameState.PlayerShares[handler.activePlayer] + sharesAction.Amount
+	sharesLimit := maps.SharesLimit(handler.gameMap, gameState)
 	if newSharesCount > sharesLimit {
 		return &api.HttpError{fmt.Sprintf("cannot take more than %d shares", sharesLimit), http.StatusBadRequest}
 	}
-	gameState.PlayerShares[currentPlayer] = newSharesCount
+	return nil
+}
+
+func (handler *confirmMoveHandler) handleSharesAction(sharesAction *api.SharesAction) error {
+	err := handler.checkSharesAction(sharesAction)
+	if err != nil {
+		return err
+	}
+
+	gameState := handler.gameState
+	currentPlayer := handler.activePlayer
+	gameState.PlayerShares[currentPlayer] += sharesAction.Amount
 	gameState.PlayerCash[currentPlayer] += 5 * sharesAction.Amount
 
 	handler.Log("%s takes %d shares.", handler.ActivePlayerNick(), sharesAction.Amount)
@@ -267,7 +480,7 @@ func (handler *confirmMoveHandler) handleSharesAction(sharesAction *api.SharesAc
 		return fmt.Errorf("failed to determine current player turn position")
 	}
 
-	err := handler.advanceCurrentPlayerForSharesPhase(currentPlayerPos)
+	err = handler.advanceCurrentPlayerForSharesPhase(currentPlayerPos)
 	if err != nil {
 		return err
 	}
@@ -281,7 +494,7 @@ func (handler *confirmMoveHandler) advanceCurrentPlayerForSharesPhase(currentPla
 		return fmt.Errorf("cannot call getNextPlayerSharesPhase() during this game phase: %d", gameState.GamePhase)
 	}
 
-	sharesLimit := handler.gameMap.GetSharesLimit()
+	sharesLimit := maps.SharesLimit(handler.gameMap, gameState)
 	nextPlayerId := ""
 	for i := currentPlayerPos + 1; i < len(gameState.PlayerOrder); i++ {
 		playerId := gameState.PlayerOrder[i]
@@ -296,7 +509,7 @@ func (handler *confirmMoveHandler) advanceCurrentPlayerForSharesPhase(currentPla
 	if nextPlayerId == "" {
 		// Advance game phase
 		gameState.GamePhase = common.AUCTION_GAME_PHASE
-		phase := handler.gameMap.GetAuctionPhase()
+		phase := maps.AuctionPhase(handler.gameMap, handler.gameState)
 		err := phase.PreAuctionHook(handler)
 		if err != nil {
 			return err
@@ -308,8 +521,12 @@ func (handler *confirmMoveHandler) advanceCurrentPlayerForSharesPhase(currentPla
 	return nil
 }
 
+func (handler *confirmMoveHandler) checkBidAction(bidAction *api.BidAction) error {
+	return maps.AuctionPhase(handler.gameMap, handler.gameState).ValidateBid(handler, bidAction)
+}
+
 func (handler *confirmMoveHandler) handleBidAction(bidAction *api.BidAction) error {
-	phase := handler.gameMap.GetAuctionPhase()
+	phase := maps.AuctionPhase(handler.gameMap, handler.gameState)
 	err := phase.HandleBid(handler, bidAction)
 	if err != nil {
 		return err
@@ -317,7 +534,9 @@ func (handler *confirmMoveHandler) handleBidAction(bidAction *api.BidAction) err
 	return nil
 }
 
-func (handler *confirmMoveHandler) handleChooseAction(chooseAction *api.ChooseAction) error {
+// checkChooseAction checks whether the active player may choose the given special action, without changing the game
+// state
+func (handler *confirmMoveHandler) checkChooseAction(chooseAction *api.ChooseAction) error {
 	gameState := handler.gameState
 	if chooseAction == nil {
 		return &api.HttpError{"missing choose action", http.StatusBadRequest}
@@ -347,7 +566,19 @@ func (handler *confirmMoveHandler) handleChooseAction(chooseAction *api.ChooseAc
 	if isChosen {
 		return &api.HttpError{fmt.Sprintf("action has already been chosen: %s", chooseAction.Action), http.StatusBadRequest}
 	}
+	if !handler.gameMap.IsSpecialActionAvailable(gameState, chooseAction.Action) {
+		return &api.HttpError{fmt.Sprintf("action is not available this turn: %s", chooseAction.Action), http.StatusBadRequest}
+	}
+	return nil
+}
 
+func (handler *confirmMoveHandler) handleChooseAction(chooseAction *api.ChooseAction) error {
+	err := handler.checkChooseAction(chooseAction)
+	if err != nil {
+		return err
+	}
+
+	gameState := handler.gameState
 	// Set the chosen action
 	gameState.PlayerActions[handler.activePlayer] = chooseAction.Action
 	handler.Log("%s chooses special action \"%s\".", handler.ActivePlayerNick(), chooseAction.Action)
@@ -466,7 +697,56 @@ func (handler *confirmMoveHandler) handleBuildAction(buildAction *api.BuildActio
 	return nil
 }
 
-func (handler *confirmMoveHandler) handleMoveGoodsAction(moveGoodsAction *api.MoveGoodsAction) error {
+// deliveryLocationColor returns the color of the city (or urbanized town) at a stop on a delivery path, which is
+// NONE_COLOR for a town that hasn't been urbanized. It returns false if goods can't stop at the hex at all.
+func (handler *confirmMoveHandler) deliveryLocationColor(loc common.Coordinate) (common.Color, bool) {
+	gameState := handler.gameState
+	gameMap := handler.gameMap
+	hexType := gameMap.GetHexType(loc)
+	var cityColor common.Color = common.NONE_COLOR
+	if hexType == maps.TOWN_HEX_TYPE {
+		var urbColor common.Color = common.NONE_COLOR
+		for _, urb := range gameState.Urbanizations {
+			if urb.Hex == loc {
+				switch urb.City {
+				case 0:
+					urbColor = common.RED
+				case 1:
+					urbColor = common.BLUE
+				case 2:
+					urbColor = common.BLACK
+				case 3:
+					urbColor = common.BLACK
+				case 4:
+					urbColor = common.YELLOW
+				case 5:
+					urbColor = common.PURPLE
+				case 6:
+					urbColor = common.BLACK
+				case 7:
+					urbColor = common.BLACK
+				}
+				break
+			}
+		}
+		cityColor = urbColor
+	} else if hexType == maps.CITY_HEX_TYPE {
+		cityColor = gameMap.GetCityColorForHex(gameState, loc)
+	} else {
+		return common.NONE_COLOR, false
+	}
+	return cityColor, true
+}
+
+// locationBlocksCube checks whether goods of the given color have to end their movement at the location
+func (handler *confirmMoveHandler) locationBlocksCube(color common.Color, loc common.Coordinate) bool {
+	cityColor, _ := handler.deliveryLocationColor(loc)
+	return cityColor == color || handler.gameMap.LocationBlocksCubePassage(color, loc)
+}
+
+// checkMoveGoodsAction checks whether the active player may make the given delivery over the given delivery graph,
+// without changing the game state
+func (handler *confirmMoveHandler) checkMoveGoodsAction(deliveryGraph *DeliveryGraph, moveGoodsAction *api.MoveGoodsAction) error {
 	gameState := handler.gameState
 	gameMap := handler.gameMap
 	if moveGoodsAction == nil {
@@ -483,105 +763,96 @@ func (handler *confirmMoveHandler) handleMoveGoodsAction(moveGoodsAction *api.Mo
 		if gameState.PlayerLoco[handler.activePlayer] >= 6 {
 			return &api.HttpError{"player's loco is already at max", http.StatusBadRequest}
 		}
+		return nil
+	}
+	if moveGoodsAction.Color == common.NONE_COLOR {
+		return nil
+	}
+
+	// Verify that there is a cube on the board of a matching color and the start location
+	foundCube := slices.ContainsFunc(gameState.Cubes, func(boardCube *common.BoardCube) bool {
+		return boardCube.Color == moveGoodsAction.Color && boardCube.Hex == moveGoodsAction.StartingLocation
+	})
+	if !foundCube {
+		return &api.HttpError{"no such cube", http.StatusBadRequest}
+	}
+
+	if len(moveGoodsAction.Path) > gameState.PlayerLoco[handler.activePlayer] {
+		return &api.HttpError{"cannot move good further than current loca", http.StatusBadRequest}
+	}
+
+	loc := moveGoodsAction.StartingLocation
+	seenCities := []common.Coordinate{loc}
+	for idx, step := range moveGoodsAction.Path {
+		link, ok := deliveryGraph.hexToDirectionToLink[loc][step]
+		if !ok {
+			return &api.HttpError{"invalid path", http.StatusBadRequest}
+		}
+
+		loc = link.destination
+		if slices.Index(seenCities, loc) != -1 {
+			return &api.HttpError{"cannot repeat a city in the delivery path", http.StatusBadRequest}
+		} else {
+			seenCities = append(seenCities, loc)
+		}
+
+		cityColor, ok := handler.deliveryLocationColor(loc)
+		if !ok {
+			return &api.HttpError{"invalid path", http.StatusBadRequest}
+		}
+
+		locBlocksCube := handler.locationBlocksCube(moveGoodsAction.Color, loc)
+		if idx != len(moveGoodsAction.Path)-1 && locBlocksCube {
+			return &api.HttpError{"cannot pass through city matching the cube color", http.StatusBadRequest}
+		}
+
+		locAcceptsCube := cityColor == moveGoodsAction.Color || gameMap.LocationCanAcceptCube(moveGoodsAction.Color, loc)
+		if idx == len(moveGoodsAction.Path)-1 && !locAcceptsCube {
+			return &api.HttpError{"ending city must match cube color", http.StatusBadRequest}
+		}
+	}
+	return nil
+}
+
+func (handler *confirmMoveHandler) handleMoveGoodsAction(moveGoodsAction *api.MoveGoodsAction) error {
+	gameState := handler.gameState
+	gameMap := handler.gameMap
+	deliveryGraph := computeDeliveryGraph(gameState, gameMap)
+	err := handler.checkMoveGoodsAction(deliveryGraph, moveGoodsAction)
+	if err != nil {
+		return err
+	}
+
+	if moveGoodsAction.Loco {
 		gameState.PlayerHasDoneLoco[handler.activePlayer] = true
 		gameState.PlayerLoco[handler.activePlayer] += 1
 		handler.Log("%s skipped delivering a good and increased their loco to %d",
 			handler.ActivePlayerNick(), gameState.PlayerLoco[handler.activePlayer])
 	} else if moveGoodsAction.Color != common.NONE_COLOR {
-
-		deliveryGraph := computeDeliveryGraph(gameState, gameMap)
-
-		// Verify that there is a cube on the board of a matching color and the start location
-		foundCube := false
+		// Remove the cube from the board
 		for idx, boardCube := range gameState.Cubes {
 			if boardCube.Color == moveGoodsAction.Color && boardCube.Hex == moveGoodsAction.StartingLocation {
 				gameState.Cubes = DeleteFromSliceUnordered(idx, gameState.Cubes)
-				foundCube = true
 				break
 			}
 		}
-		if !foundCube {
-			return &api.HttpError{"no such cube", http.StatusBadRequest}
-		}
 
 		handler.Log("%s delivered a %s good cube from %s",
-			handler.ActivePlayerNick(), moveGoodsAction.Color.String(), renderHexCoordinate(moveGoodsAction.StartingLocation))
-
-		if len(moveGoodsAction.Path) > gameState.PlayerLoco[handler.activePlayer] {
-			return &api.HttpError{"cannot move good further than current loca", http.StatusBadRequest}
-		}
+			handler.ActivePlayerNick(), moveGoodsAction.Color.String(), common.RenderHexCoordinate(moveGoodsAction.StartingLocation))
 
 		loc := moveGoodsAction.StartingLocation
-		seenCities := []common.Coordinate{loc}
-		for idx, step := range moveGoodsAction.Path {
-			if _, ok := deliveryGraph.hexToDirectionToLink[loc]; !ok {
-				return &api.HttpError{"invalid path", http.StatusBadRequest}
-			}
-			if _, ok := deliveryGraph.hexToDirectionToLink[loc][step]; !ok {
-				return &api.HttpError{"invalid path", http.StatusBadRequest}
-			}
-
+		for _, step := range moveGoodsAction.Path {
 			link := deliveryGraph.hexToDirectionToLink[loc][step]
 			loc = link.destination
-			if slices.Index(seenCities, loc) != -1 {
-				return &api.HttpError{"cannot repeat a city in the delivery path", http.StatusBadRequest}
-			} else {
-				seenCities = append(seenCities, loc)
-			}
-
-			hexType := gameMap.GetHexType(loc)
-			var cityColor common.Color = common.NONE_COLOR
-			if hexType == maps.TOWN_HEX_TYPE {
-				var urbColor common.Color = common.NONE_COLOR
-				for _, urb := range gameState.Urbanizations {
-					if urb.Hex == loc {
-						switch urb.City {
-						case 0:
-							urbColor = common.RED
-						case 1:
-							urbColor = common.BLUE
-						case 2:
-							urbColor = common.BLACK
-						case 3:
-							urbColor = common.BLACK
-						case 4:
-							urbColor = common.YELLOW
-						case 5:
-							urbColor = common.PURPLE
-						case 6:
-							urbColor = common.BLACK
-						case 7:
-							urbColor = common.BLACK
-						}
-						break
-					}
-				}
-				cityColor = urbColor
-			} else if hexType == maps.CITY_HEX_TYPE {
-				cityColor = gameMap.GetCityColorForHex(gameState, loc)
-			} else {
-				return &api.HttpError{"invalid path", http.StatusBadRequest}
-			}
-
-			locBlocksCube := cityColor == moveGoodsAction.Color || gameMap.LocationBlocksCubePassage(moveGoodsAction.Color, loc)
-			if idx != len(moveGoodsAction.Path)-1 && locBlocksCube {
-				return &api.HttpError{"cannot pass through city matching the cube color", http.StatusBadRequest}
-			}
-
-			locAcceptsCube := cityColor == moveGoodsAction.Color || gameMap.LocationCanAcceptCube(moveGoodsAction.Color, loc)
-			if idx == len(moveGoodsAction.Path)-1 && !locAcceptsCube {
-				return &api.HttpError{"ending city must match cube color", http.StatusBadRequest}
-			}
-
 			if link.player != "" {
 				gameState.PlayerIncome[link.player] += 1
-				handler.Log("The cube moved to %s giving one income to %s", renderHexCoordinate(loc), handler.PlayerNick(link.player))
+				handler.Log("The cube moved to %s giving one income to %s", common.RenderHexCoordinate(loc), handler.PlayerNick(link.player))
 			} else {
-				handler.Log("The cube moved to %s; no one gets income for it.", renderHexCoordinate(loc))
+				handler.Log("The cube moved to %s; no one gets income for it.", common.RenderHexCoordinate(loc))
 			}
 		}
 
-		handler.Log("The cube finished its movement at %s", renderHexCoordinate(loc))
+		handler.Log("The cube finished its movement at %s", common.RenderHexCoordinate(loc))
 		// Put the cube back into the bag
 		if gameMap.ShouldPutDeliveryInBag(moveGoodsAction.Color) {
 			gameState.CubeBag[moveGoodsAction.Color] += 1
@@ -865,7 +1136,7 @@ func (handler *confirmMoveHandler) executeGoodsGrowthPhase(gameMap maps.GameMap)
 				Hex:   cord,
 			})
 			handler.Log("A %s cube was moved from the goods growth chart to %s.",
-				pickedColor.String(), renderHexCoordinate(cord))
+				pickedColor.String(), common.RenderHexCoordinate(cord))
 		}
 	}
 
@@ -907,7 +1178,7 @@ func (handler *confirmMoveHandler) executeGoodsGrowthPhase(gameMap maps.GameMap)
 	handler.reversible = false
 
 	// Determine if the game is over
-	turnLimit := gameMap.GetTurnLimit(numPlayers)
+	turnLimit := maps.TurnLimit(gameMap, gameState)
 	if gameState.TurnNumber > turnLimit {
 		handler.gameFinished = true
 	}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmMoveVersion(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	version := viewRes.Version
+	staleVersion := version - 1
+	move := &api.ConfirmMoveRequest{
+		GameId:       createRes.Id,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 1},
+		Version:      &staleVersion,
+	}
+
+	// A move made against an out of date view of the game is rejected
+	_, err = h.confirmMove(t, viewRes.ActivePlayer, move)
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusConflict, httpError.Code)
+
+	move.Version = &version
+	_, err = h.confirmMove(t, viewRes.ActivePlayer, move)
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, version+1, viewRes.Version)
+
+	// Submitting the same move twice only applies it once
+	_, err = h.confirmMove(t, viewRes.ActivePlayer, move)
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusConflict, httpError.Code)
+
+	// The version isn't logged as part of the action
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.Len(t, logsRes.Logs, 2)
+	assert.NotContains(t, logsRes.Logs[1].Action, "version")
+}
+
+func TestConcurrentMoves(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	// Two submissions of a move both load the game before either is saved
+	move := &api.ConfirmMoveRequest{
+		GameId:       createRes.Id,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 1},
+	}
+	var handlers []*confirmMoveHandler
+	for i := 0; i < 2; i++ {
+		handler, err := h.gameServer.newConfirmMoveHandlerForGame(createRes.Id)
+		require.NoError(t, err)
+		err = handler.handleAction(move)
+		require.NoError(t, err)
+		handlers = append(handlers, handler)
+	}
+
+	err = h.gameServer.saveMove(handlers[0], viewRes.ActivePlayer, move)
+	require.NoError(t, err)
+	err = h.gameServer.saveMove(handlers[1], viewRes.ActivePlayer, move)
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusConflict, httpError.Code)
+
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Len(t, logsRes.Logs, 2)
+}
+
+// startAuctionTestGame starts a two-player game with the given auction and has both players take no shares
+func startAuctionTestGame(t *testing.T, h *TestHarness, auctionType common.AuctionType) (gameId string, players []string) {
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		Options:    &common.GameOptions{AuctionType: auctionType},
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	for _, player := range viewRes.GameState.PlayerOrder {
+		_, err = h.confirmMove(t, player, &api.ConfirmMoveRequest{
+			GameId:       createRes.Id,
+			ActionName:   api.SharesActionName,
+			SharesAction: &api.SharesAction{Amount: 0},
+		})
+		require.NoError(t, err)
+	}
+	return createRes.Id, viewRes.GameState.PlayerOrder
+}
+
+func TestSealedBidsAreHidden(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, players := startAuctionTestGame(t, h, common.SEALED_BID_AUCTION_TYPE)
+	_, err := h.confirmMove(t, players[0], &api.ConfirmMoveRequest{
+		GameId:     gameId,
+		ActionName: api.BidActionName,
+		BidAction:  &api.BidAction{Amount: 3},
+	})
+	require.NoError(t, err)
+
+	// Players can see their own bid, but only that the other player has bid
+	viewRes, err := h.viewGame(t, players[0], &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{players[0]: 3}, viewRes.GameState.AuctionState)
+	viewRes, err = h.viewGame(t, players[1], &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{players[0]: 0}, viewRes.GameState.AuctionState)
+	assert.Equal(t, players[1], viewRes.ActivePlayer)
+
+	// The amount is hidden from the logged bid too
+	for _, tc := range []struct {
+		viewer         string
+		expectedAmount int
+	}{
+		{players[0], 3},
+		{players[1], 0},
+	} {
+		logsRes, err := h.getGameLogs(t, tc.viewer, &GetGameLogsRequest{GameId: gameId})
+		require.NoError(t, err)
+		bidLog := logsRes.Logs[len(logsRes.Logs)-1]
+		action := new(api.ConfirmMoveRequest)
+		require.NoError(t, json.Unmarshal([]byte(bidLog.Action), action))
+		assert.Equal(t, tc.expectedAmount, action.BidAction.Amount)
+		assert.NotContains(t, bidLog.Description, "$")
+	}
+
+	// A lower bid is allowed, and the bids are settled once everyone has bid
+	_, err = h.confirmMove(t, players[1], &api.ConfirmMoveRequest{
+		GameId:     gameId,
+		ActionName: api.BidActionName,
+		BidAction:  &api.BidAction{Amount: 1},
+	})
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, players[1], &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, viewRes.GameState.GamePhase)
+	assert.Equal(t, players, viewRes.GameState.PlayerOrder)
+	assert.Equal(t, map[string]int{players[0]: 7, players[1]: 9}, viewRes.GameState.PlayerCash)
+}
+
+func TestFixedOrderSkipsAuction(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, players := startAuctionTestGame(t, h, common.FIXED_ORDER_AUCTION_TYPE)
+	viewRes, err := h.viewGame(t, players[0], &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, viewRes.GameState.GamePhase)
+	assert.Equal(t, players, viewRes.GameState.PlayerOrder)
+	assert.Equal(t, players[0], viewRes.ActivePlayer)
+
+	// Turn order pass would have nothing to do
+	_, err = h.confirmMove(t, players[0], &api.ConfirmMoveRequest{
+		GameId:       gameId,
+		ActionName:   api.ChooseActionName,
+		ChooseAction: &api.ChooseAction{Action: common.TURN_ORDER_PASS_SPECIAL_ACTION},
+	})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+	"github.com/google/uuid"
+)
+
+// Custom maps are referred to by this prefix followed by the map's id, so they can't be confused with built-in maps
+const customMapPrefix = "custom:"
+
+type MapVisibility string
+
+const (
+	// Anyone can create a game on a public map
+	PUBLIC_MAP_VISIBILITY MapVisibility = "public"
+	// Only the owner of a private map can create games on it, though anyone can play in those games
+	PRIVATE_MAP_VISIBILITY MapVisibility = "private"
+)
+
+type customMap struct {
+	name        string
+	ownerUserId string
+	visibility  MapVisibility
+	data        string
+}
+
+// CustomMapData is a custom map as sent to the frontend, which needs the map file to draw the board
+type CustomMapData struct {
+	Name string          `json:"name"`
+	Map  json.RawMessage `json:"map"`
+}
+
+type UploadMapRequest struct {
+	Name       string        `json:"name"`
+	Visibility MapVisibility `json:"visibility"`
+	// In the same format as the files of the built-in maps
+	Map json.RawMessage `json:"map"`
+}
+
+type UploadMapResponse struct {
+	// To be used as the map name when creating a game
+	MapName string `json:"mapName"`
+}
+
+func (server *GameServer) uploadMap(ctx *RequestContext, req *UploadMapRequest) (resp *UploadMapResponse, err error) {
+	if req.Name == "" {
+		return nil, &api.HttpError{"missing name parameter", http.StatusBadRequest}
+	}
+	if req.Visibility != PUBLIC_MAP_VISIBILITY && req.Visibility != PRIVATE_MAP_VISIBILITY {
+		return nil, &api.HttpError{"invalid or missing visibility parameter", http.StatusBadRequest}
+	}
+	if len(req.Map) == 0 {
+		return nil, &api.HttpError{"missing map parameter", http.StatusBadRequest}
+	}
+
+	_, problems, err := maps.ParseCustomMap(req.Map)
+	if err != nil {
+		return nil, &api.HttpError{fmt.Sprintf("failed to parse map: %v", err), http.StatusBadRequest}
+	}
+	if len(problems) > 0 {
+		return nil, &api.HttpError{fmt.Sprintf("invalid map:\n%s", strings.Join(problems, "\n")), http.StatusBadRequest}
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate id: %v", err)
+	}
+	_, err = server.db.Exec("INSERT INTO maps (id,name,owner_user_id,visibility,map_data,created_at) VALUES (?,?,?,?,?,?)",
+		id.String(), req.Name, ctx.User.Id, string(req.Visibility), string(req.Map), time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert map row: %v", err)
+	}
+
+	return &UploadMapResponse{MapName: customMapPrefix + id.String()}, nil
+}
+
+// getCustomMap returns the custom map with the given map name, or nil if there is no such map
+func (server *GameServer) getCustomMap(mapName string) (*customMap, error) {
+	id, ok := strings.CutPrefix(mapName, customMapPrefix)
+	if !ok {
+		return nil, nil
+	}
+
+	var name string
+	var ownerUserId string
+	var visibility string
+	var data string
+	err := server.db.QueryRow("SELECT name,owner_user_id,visibility,map_data FROM maps WHERE id=?", id).Scan(
+		&name, &ownerUserId, &visibility, &data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch map row: %v", err)
+	}
+	return &customMap{
+		name:        name,
+		ownerUserId: ownerUserId,
+		visibility:  MapVisibility(visibility),
+		data:        data,
+	}, nil
+}
+
+// getGameMap returns the built-in or custom map with the given name, or nil if there is no such map
+func (server *GameServer) getGameMap(mapName string) (maps.GameMap, error) {
+	if gameMap, ok := server.gameMaps[mapName]; ok {
+		return gameMap, nil
+	}
+	if gameMap, ok := server.customGameMaps.Load(mapName); ok {
+		return gameMap.(maps.GameMap), nil
+	}
+
+	custom, err := server.getCustomMap(mapName)
+	if err != nil || custom == nil {
+		return nil, err
+	}
+	gameMap, problems, err := maps.ParseCustomMap([]byte(custom.data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse map %s: %v", mapName, err)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("map %s is invalid: %s", mapName, strings.Join(problems, "; "))
+	}
+	server.customGameMaps.Store(mapName, gameMap)
+	return gameMap, nil
+}
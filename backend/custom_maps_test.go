@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadMap(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	mapData, err := os.ReadFile("maps/rust_belt.json")
+	require.NoError(t, err)
+
+	owner := h.createUser(t)
+	player2 := h.createUser(t)
+	player3 := h.createUser(t)
+
+	// Problems with the map are reported back to the uploader
+	var broken map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(mapData, &broken))
+	broken["hexes"] = json.RawMessage(`[[{"type": 6, "name": "Nowhere"}], [{"type": 2}, {"type": 2}]]`)
+	brokenData, err := json.Marshal(broken)
+	require.NoError(t, err)
+	_, err = h.uploadMap(t, owner, &UploadMapRequest{Name: "Broken", Visibility: PUBLIC_MAP_VISIBILITY, Map: brokenData})
+	var httpErr *api.HttpError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	assert.Contains(t, httpErr.Description, "city Nowhere at A1 has no color")
+	assert.Contains(t, httpErr.Description, "the row starting at B2 has 2 hexes instead of 1")
+
+	_, err = h.uploadMap(t, owner, &UploadMapRequest{Name: "Rust Belt", Visibility: "secret", Map: mapData})
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+
+	uploadRes, err := h.uploadMap(t, owner, &UploadMapRequest{Name: "Rust Belt", Visibility: PRIVATE_MAP_VISIBILITY, Map: mapData})
+	require.NoError(t, err)
+	assert.Regexp(t, "^custom:", uploadRes.MapName)
+
+	// Only the owner can create games on a private map, but anyone can play in them
+	_, err = h.createGame(t, player2, &CreateGameRequest{Name: "game-name", MinPlayers: 3, MaxPlayers: 3, MapName: uploadRes.MapName})
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	createRes, err := h.createGame(t, owner, &CreateGameRequest{Name: "game-name", MinPlayers: 3, MaxPlayers: 3, MapName: uploadRes.MapName})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player3, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, owner, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player2, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.NotNil(t, viewRes.CustomMap)
+	assert.Equal(t, "Rust Belt", viewRes.CustomMap.Name)
+	assert.JSONEq(t, string(mapData), string(viewRes.CustomMap.Map))
+	assert.Len(t, viewRes.GameState.GoodsGrowth, 20)
+
+	// The parsed map is cached, so every game on it shares the same map
+	gameMap, err := h.gameServer.getGameMap(uploadRes.MapName)
+	require.NoError(t, err)
+	cachedMap, err := h.gameServer.getGameMap(uploadRes.MapName)
+	require.NoError(t, err)
+	assert.Same(t, gameMap, cachedMap)
+
+	// Moves are played on the custom map just like on a built-in one
+	err = h.gameServer.timeoutActivePlayer(createRes.Id)
+	require.NoError(t, err)
+	err = runTask(h.gameServer, []string{"verify-game", createRes.Id})
+	require.NoError(t, err)
+}
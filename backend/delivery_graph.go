@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/common"
 	"github.com/JackOfMostTrades/eot/backend/maps"
 )
@@ -15,45 +14,11 @@ type DeliveryGraph struct {
 	hexToDirectionToLink map[common.Coordinate]map[common.Direction]DeliveryGraphLink
 }
 
-func applyDirection(coord common.Coordinate, direction common.Direction) common.Coordinate {
-	switch direction {
-	case common.NORTH:
-		return common.Coordinate{X: coord.X, Y: coord.Y - 2}
-	case common.NORTH_EAST:
-		if (coord.Y % 2) == 0 {
-			return common.Coordinate{X: coord.X, Y: coord.Y - 1}
-		} else {
-			return common.Coordinate{X: coord.X + 1, Y: coord.Y - 1}
-		}
-	case common.SOUTH_EAST:
-		if (coord.Y % 2) == 0 {
-			return common.Coordinate{X: coord.X, Y: coord.Y + 1}
-		} else {
-			return common.Coordinate{X: coord.X + 1, Y: coord.Y + 1}
-		}
-	case common.SOUTH:
-		return common.Coordinate{X: coord.X, Y: coord.Y + 2}
-	case common.SOUTH_WEST:
-		if (coord.Y % 2) == 0 {
-			return common.Coordinate{X: coord.X - 1, Y: coord.Y + 1}
-		} else {
-			return common.Coordinate{X: coord.X, Y: coord.Y + 1}
-		}
-	case common.NORTH_WEST:
-		if (coord.Y % 2) == 0 {
-			return common.Coordinate{X: coord.X - 1, Y: coord.Y - 1}
-		} else {
-			return common.Coordinate{X: coord.X, Y: coord.Y - 1}
-		}
-	}
-	panic(fmt.Errorf("unhandled direction: %v", direction))
-}
-
 func applyMapDirection(gameMap maps.GameMap, gameState *common.GameState, hex common.Coordinate, direction common.Direction) common.Coordinate {
 	if teleportDest, _ := gameMap.GetTeleportLink(gameState, hex, direction); teleportDest != nil {
 		return *teleportDest
 	} else {
-		return applyDirection(hex, direction)
+		return common.ApplyDirection(hex, direction)
 	}
 }
 
@@ -72,7 +37,7 @@ func computeDeliveryGraph(gameState *common.GameState, gameMap maps.GameMap) *De
 				dest = *teleportDest
 				lastReverseDirection = teleportDirection
 			} else {
-				dest = applyDirection(dest, step)
+				dest = common.ApplyDirection(dest, step)
 				lastReverseDirection = step.Opposite()
 			}
 		}
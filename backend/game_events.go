@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+)
+
+const (
+	GAME_EVENT_MOVE     = "move"
+	GAME_EVENT_CHAT     = "chat"
+	GAME_EVENT_ROLLBACK = "rollback"
+)
+
+// How many undelivered events a subscriber can have before it is disconnected
+const gameEventBufferSize = 16
+
+// How often to send a comment to subscribers to keep idle connections from being closed
+const gameEventKeepAliveInterval = 30 * time.Second
+
+type GameEvent struct {
+	Type string `json:"type"`
+	// Set for move events, which include undoing a move
+	Log          *GameLogEntry `json:"log,omitempty"`
+	ActivePlayer string        `json:"activePlayer,omitempty"`
+	Finished     bool          `json:"finished,omitempty"`
+	// Set for chat events
+	Chat *GameChatMessage `json:"chat,omitempty"`
+	// Set for rollback events (sent when a rollback is requested or voted on) if the rollback is still pending. Applying
+	// the rollback is sent as a move event.
+	Rollback *PendingRollback `json:"rollback,omitempty"`
+}
+
+// gameEventBroker fans out events for a game to all the subscribers currently watching that game. It only lives as
+// long as the process, so it is only useful when running as a long-lived http server.
+type gameEventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan *GameEvent]bool
+}
+
+func newGameEventBroker() *gameEventBroker {
+	return &gameEventBroker{
+		subscribers: make(map[string]map[chan *GameEvent]bool),
+	}
+}
+
+func (broker *gameEventBroker) subscribe(gameId string) chan *GameEvent {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	ch := make(chan *GameEvent, gameEventBufferSize)
+	if broker.subscribers[gameId] == nil {
+		broker.subscribers[gameId] = make(map[chan *GameEvent]bool)
+	}
+	broker.subscribers[gameId][ch] = true
+	return ch
+}
+
+func (broker *gameEventBroker) unsubscribe(gameId string, ch chan *GameEvent) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if _, ok := broker.subscribers[gameId][ch]; ok {
+		delete(broker.subscribers[gameId], ch)
+		close(ch)
+	}
+	if len(broker.subscribers[gameId]) == 0 {
+		delete(broker.subscribers, gameId)
+	}
+}
+
+// closeAll disconnects every subscriber
+func (broker *gameEventBroker) closeAll() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for gameId, subscribers := range broker.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(broker.subscribers, gameId)
+	}
+}
+
+func (broker *gameEventBroker) publish(gameId string, event *GameEvent) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for ch := range broker.subscribers[gameId] {
+		select {
+		case ch <- event:
+		default:
+			// The subscriber isn't keeping up, so drop it. The client will reconnect and reload.
+			delete(broker.subscribers[gameId], ch)
+			close(ch)
+		}
+	}
+}
+
+// publishGameEvent sends the event to anyone watching the game. This is a no-op when there is no broker, e.g. in CGI
+// mode or when running a task.
+func (server *GameServer) publishGameEvent(gameId string, event *GameEvent) {
+	if broker := server.eventBroker.Load(); broker != nil {
+		broker.publish(gameId, event)
+	}
+}
+
+func writeHttpError(w http.ResponseWriter, err error) {
+	if httpError, ok := err.(*api.HttpError); ok {
+		http.Error(w, httpError.Error(), httpError.Code)
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// gameEvents streams events for a game as server-sent events. Since EventSource clients can only make GET requests,
+// the game ID is passed as a query parameter rather than a JSON body.
+func (server *GameServer) gameEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := &RequestContext{
+		HttpRequest:  r,
+		HttpResponse: w,
+	}
+	err := server.assertAuthentication(ctx)
+	if err != nil {
+		writeHttpError(w, err)
+		return
+	}
+
+	broker := server.eventBroker.Load()
+	if broker == nil {
+		http.Error(w, "game events are not supported by this server", http.StatusNotImplemented)
+		return
+	}
+	gameId := r.URL.Query().Get("gameId")
+	if gameId == "" {
+		http.Error(w, "missing gameId parameter", http.StatusBadRequest)
+		return
+	}
+	started, err := server.checkCanViewGame(ctx, gameId)
+	if err != nil {
+		writeHttpError(w, err)
+		return
+	}
+	// The auction type is fixed when the game is created, and a game can't finish during an auction
+	secretBids, err := server.hasSecretBids(gameId)
+	if err != nil {
+		writeHttpError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := broker.subscribe(gameId)
+	defer broker.unsubscribe(gameId, ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(gameEventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			// Invite-only games are hidden from outsiders once they start, which may be after this stream was opened.
+			// Visibility can't change after the start, so stop rechecking once the game is seen to have started.
+			if !started {
+				started, err = server.checkCanViewGame(ctx, gameId)
+				if err != nil {
+					return
+				}
+			}
+			if secretBids && event.Log != nil {
+				var logEntry *GameLogEntry
+				logEntry, err = hideSealedBidLogAction(event.Log, ctx.User.Id)
+				if err != nil {
+					slog.Error("Failed to hide sealed bid", "error", err)
+					return
+				}
+				hidden := *event
+				hidden.Log = logEntry
+				event = &hidden
+			}
+			var data []byte
+			data, err = json.Marshal(event)
+			if err != nil {
+				slog.Error("Failed to marshal game event", "error", err)
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGameEventsChat(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+
+	session, err := h.gameServer.createSession(&Session{UserId: player1})
+	require.NoError(t, err)
+	httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/api/gameEvents?gameId=%s",
+		h.gameServer.httpListenPort, createRes.Id), nil)
+	require.NoError(t, err)
+	httpReq.AddCookie(&http.Cookie{
+		Name:  "eot-session",
+		Value: session,
+	})
+	res, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	_, err = h.sendGameChat(t, player1, &SendGameChatRequest{GameId: createRes.Id, Message: "hello"})
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: chat\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line, "data: "))
+
+	event := new(GameEvent)
+	err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event)
+	require.NoError(t, err)
+	assert.Equal(t, GAME_EVENT_CHAT, event.Type)
+	require.NotNil(t, event.Chat)
+	assert.Equal(t, player1, event.Chat.UserId)
+	assert.Equal(t, "hello", event.Chat.Message)
+}
+
+func TestGameEventsInviteOnly(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	outsider := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		InviteOnly: true,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	subscribe := func(userId string) *http.Response {
+		session, err := h.gameServer.createSession(&Session{UserId: userId})
+		require.NoError(t, err)
+		httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/api/gameEvents?gameId=%s",
+			h.gameServer.httpListenPort, createRes.Id), nil)
+		require.NoError(t, err)
+		httpReq.AddCookie(&http.Cookie{
+			Name:  "eot-session",
+			Value: session,
+		})
+		res, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		return res
+	}
+
+	res := subscribe(outsider)
+	res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res = subscribe(player2)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestGameEventsInviteOnlyClosedOnStart(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	outsider := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		InviteOnly: true,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	session, err := h.gameServer.createSession(&Session{UserId: outsider})
+	require.NoError(t, err)
+	httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/api/gameEvents?gameId=%s",
+		h.gameServer.httpListenPort, createRes.Id), nil)
+	require.NoError(t, err)
+	httpReq.AddCookie(&http.Cookie{
+		Name:  "eot-session",
+		Value: session,
+	})
+	res, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Before the start, events are still streamed to outsiders
+	_, err = h.sendGameChat(t, player1, &SendGameChatRequest{GameId: createRes.Id, Message: "before"})
+	require.NoError(t, err)
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: chat\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, "before")
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "\n", line)
+
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.sendGameChat(t, player1, &SendGameChatRequest{GameId: createRes.Id, Message: "after"})
+	require.NoError(t, err)
+
+	// The stream is closed on the first event after the start, without sending it
+	rest, _ := io.ReadAll(reader)
+	assert.NotContains(t, string(rest), "after")
+}
+
+func TestGameEventsHideSealedBids(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, players := startAuctionTestGame(t, h, common.SEALED_BID_AUCTION_TYPE)
+	readers := make(map[string]*bufio.Reader)
+	for _, player := range players {
+		session, err := h.gameServer.createSession(&Session{UserId: player})
+		require.NoError(t, err)
+		httpReq, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:%d/api/gameEvents?gameId=%s",
+			h.gameServer.httpListenPort, gameId), nil)
+		require.NoError(t, err)
+		httpReq.AddCookie(&http.Cookie{
+			Name:  "eot-session",
+			Value: session,
+		})
+		res, err := http.DefaultClient.Do(httpReq)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		readers[player] = bufio.NewReader(res.Body)
+	}
+
+	_, err := h.confirmMove(t, players[0], &api.ConfirmMoveRequest{
+		GameId:     gameId,
+		ActionName: api.BidActionName,
+		BidAction:  &api.BidAction{Amount: 3},
+	})
+	require.NoError(t, err)
+
+	// Only the bidder is sent the amount of their bid
+	for _, tc := range []struct {
+		viewer         string
+		expectedAmount int
+	}{
+		{players[0], 3},
+		{players[1], 0},
+	} {
+		line, err := readers[tc.viewer].ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "event: move\n", line)
+		line, err = readers[tc.viewer].ReadString('\n')
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(line, "data: "))
+
+		event := new(GameEvent)
+		err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event)
+		require.NoError(t, err)
+		require.NotNil(t, event.Log)
+		action := new(api.ConfirmMoveRequest)
+		require.NoError(t, json.Unmarshal([]byte(event.Log.Action), action))
+		assert.Equal(t, tc.expectedAmount, action.BidAction.Amount)
+		assert.NotContains(t, event.Log.Description, "$")
+	}
+}
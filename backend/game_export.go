@@ -0,0 +1,503 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+// ExportedGame is a complete, portable copy of a game. The mapName, steps and randomValues fields match
+// GameLogTestCaseDefinition, so an export can be saved into testlogs/ and replayed as a regression test. The top-level
+// randomValues are only filled in if the draws were recorded for every move.
+type ExportedGame struct {
+	MapName      string              `json:"mapName"`
+	Steps        []*ExportedGameStep `json:"steps"`
+	RandomValues []int               `json:"randomValues"`
+
+	Game    *ExportedGameInfo  `json:"game"`
+	Players []*ExportedPlayer  `json:"players"`
+	Chat    []*GameChatMessage `json:"chat"`
+	// Only for games on a custom map, so that they can be imported on a server that doesn't have the map
+	CustomMap *ExportedCustomMap `json:"customMap,omitempty"`
+}
+
+// ExportedGameStep is one game_log entry. The first step is the start of the game and has no action.
+type ExportedGameStep struct {
+	Action               *api.ConfirmMoveRequest `json:"action"`
+	ExpectedGameState    *common.GameState       `json:"expectedGameState"`
+	ExpectedActivePlayer string                  `json:"expectedActivePlayer"`
+
+	Timestamp   int    `json:"timestamp"`
+	UserId      string `json:"userId"`
+	Description string `json:"description"`
+	Reversible  bool   `json:"reversible"`
+	// Null if the draws weren't recorded for this step
+	RandomValues []int `json:"randomValues"`
+	RandomBounds []int `json:"randomBounds"`
+}
+
+// Logged undos aren't confirmed moves, so they are exported as a ConfirmMoveRequest with this action name
+const undoActionName = "undo"
+
+type ExportedGameInfo struct {
+	Id                 string            `json:"id"`
+	CreatedAt          int               `json:"createdAt"`
+	Name               string            `json:"name"`
+	MinPlayers         int               `json:"minPlayers"`
+	MaxPlayers         int               `json:"maxPlayers"`
+	OwnerUserId        string            `json:"ownerUserId"`
+	Started            bool              `json:"started"`
+	Finished           bool              `json:"finished"`
+	GameState          *common.GameState `json:"gameState"`
+	ActivePlayer       string            `json:"activePlayer"`
+	InviteOnly         bool              `json:"inviteOnly"`
+	MoveTimeLimitHours int               `json:"moveTimeLimitHours"`
+	FinalScores        []*PlayerScore    `json:"finalScores,omitempty"`
+	// For provably fair games. The seed is only exported once the game has finished.
+	RandomSeedCommitment string `json:"randomSeedCommitment,omitempty"`
+	RandomSeed           string `json:"randomSeed,omitempty"`
+	Version              int    `json:"version"`
+	// The variant rules the game was created with
+	MapVariants []common.MapVariant `json:"mapVariants,omitempty"`
+	// The house rules the game was created with
+	Options *common.GameOptions `json:"options,omitempty"`
+}
+
+type ExportedCustomMap struct {
+	Name        string        `json:"name"`
+	OwnerUserId string        `json:"ownerUserId"`
+	Visibility  MapVisibility `json:"visibility"`
+	// In the same format as the files of the built-in maps
+	Map json.RawMessage `json:"map"`
+}
+
+type ExportedPlayer struct {
+	Id       string `json:"id"`
+	Nickname string `json:"nickname"`
+	BotType  string `json:"botType,omitempty"`
+}
+
+type ExportGameRequest struct {
+	GameId string `json:"gameId"`
+}
+
+func (server *GameServer) exportGame(ctx *RequestContext, req *ExportGameRequest) (resp *ExportedGame, err error) {
+	// Anyone can export a finished public game, but other games can only be exported by their players and admins
+	var finishedFlag int
+	var inviteOnlyFlag int
+	err = server.db.QueryRow("SELECT finished,invite_only FROM games WHERE id=?", req.GameId).Scan(&finishedFlag, &inviteOnlyFlag)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if err == nil && (finishedFlag == 0 || inviteOnlyFlag != 0) {
+		err = server.assertGamePlayerOrAdmin(ctx, req.GameId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	export, err := server.exportGameData(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	hideSealedBidsInExport(export, ctx.User.Id)
+	return export, nil
+}
+
+// hideSealedBidsInExport blanks out the other players' bids in a game with a sealed-bid auction that's still being
+// played, both in the game states and in the logged bids
+func hideSealedBidsInExport(export *ExportedGame, viewer string) {
+	if export.Game.Finished || export.Game.Options.GetAuctionType() != common.SEALED_BID_AUCTION_TYPE {
+		return
+	}
+	hideSealedBids(export.Game.GameState, viewer)
+	for _, step := range export.Steps {
+		hideSealedBids(step.ExpectedGameState, viewer)
+		hideSealedBidAction(step.Action, step.UserId, viewer)
+	}
+}
+
+func (server *GameServer) exportGameData(gameId string) (*ExportedGame, error) {
+	stmt, err := server.db.Prepare("SELECT created_at,name,min_players,max_players,map_name,owner_user_id,started,finished,game_state,active_player_id,invite_only,move_time_limit_hours,final_scores,random_seed,random_seed_commitment,version,map_variants,game_options FROM games WHERE id=?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+
+	info := &ExportedGameInfo{Id: gameId}
+	export := &ExportedGame{Game: info}
+	var startedFlag int
+	var finishedFlag int
+	var gameStateStr sql.NullString
+	var activePlayer sql.NullString
+	var inviteOnlyFlag int
+	var moveTimeLimitHours sql.NullInt64
+	var finalScoresStr sql.NullString
+	var randomSeed sql.NullString
+	var randomSeedCommitment sql.NullString
+	var mapVariantsStr sql.NullString
+	var gameOptionsStr sql.NullString
+	err = stmt.QueryRow(gameId).Scan(&info.CreatedAt, &info.Name, &info.MinPlayers, &info.MaxPlayers, &export.MapName,
+		&info.OwnerUserId, &startedFlag, &finishedFlag, &gameStateStr, &activePlayer, &inviteOnlyFlag,
+		&moveTimeLimitHours, &finalScoresStr, &randomSeed, &randomSeedCommitment, &info.Version, &mapVariantsStr, &gameOptionsStr)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", gameId), http.StatusBadRequest}
+		}
+		return nil, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	info.Started = startedFlag != 0
+	info.Finished = finishedFlag != 0
+	info.ActivePlayer = activePlayer.String
+	info.InviteOnly = inviteOnlyFlag != 0
+	info.MoveTimeLimitHours = int(moveTimeLimitHours.Int64)
+	info.RandomSeedCommitment = randomSeedCommitment.String
+	info.MapVariants, err = unmarshalMapVariants(mapVariantsStr)
+	if err != nil {
+		return nil, err
+	}
+	info.Options, err = unmarshalGameOptions(gameOptionsStr)
+	if err != nil {
+		return nil, err
+	}
+	if info.Finished {
+		info.RandomSeed = randomSeed.String
+	}
+	if gameStateStr.Valid {
+		info.GameState = new(common.GameState)
+		err = json.Unmarshal([]byte(gameStateStr.String), info.GameState)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse game state: %v", err)
+		}
+	}
+	if finalScoresStr.Valid {
+		err = json.Unmarshal([]byte(finalScoresStr.String), &info.FinalScores)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse final scores: %v", err)
+		}
+	}
+
+	custom, err := server.getCustomMap(export.MapName)
+	if err != nil {
+		return nil, err
+	}
+	if custom != nil {
+		export.CustomMap = &ExportedCustomMap{
+			Name:        custom.name,
+			OwnerUserId: custom.ownerUserId,
+			Visibility:  custom.visibility,
+			Map:         json.RawMessage(custom.data),
+		}
+	}
+
+	stmt, err = server.db.Prepare("SELECT id,nickname,bot_type FROM users INNER JOIN game_player_map ON users.id=game_player_map.player_user_id WHERE game_player_map.game_id=?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(gameId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		player := new(ExportedPlayer)
+		var botType sql.NullString
+		err = rows.Scan(&player.Id, &player.Nickname, &botType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		player.BotType = botType.String
+		export.Players = append(export.Players, player)
+	}
+	rows.Close()
+
+	stmt, err = server.db.Prepare("SELECT timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds FROM game_log WHERE game_id=? ORDER BY seq ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err = stmt.Query(gameId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		step := new(ExportedGameStep)
+		var actionStr sql.NullString
+		var newActivePlayer sql.NullString
+		var newGameStateStr sql.NullString
+		var reversibleFlag int
+		var randomValuesStr sql.NullString
+		var randomBoundsStr sql.NullString
+		err = rows.Scan(&step.Timestamp, &step.UserId, &actionStr, &step.Description, &newActivePlayer,
+			&newGameStateStr, &reversibleFlag, &randomValuesStr, &randomBoundsStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		step.RandomValues, err = unmarshalRandomValues(randomValuesStr)
+		if err != nil {
+			return nil, err
+		}
+		step.RandomBounds, err = unmarshalRandomValues(randomBoundsStr)
+		if err != nil {
+			return nil, err
+		}
+		step.ExpectedActivePlayer = newActivePlayer.String
+		step.Reversible = reversibleFlag != 0
+		if actionStr.String == undoActionName || actionStr.String == rollbackActionName {
+			step.Action = &api.ConfirmMoveRequest{GameId: gameId, ActionName: api.ActionName(actionStr.String)}
+		} else if actionStr.Valid {
+			step.Action = new(api.ConfirmMoveRequest)
+			err = json.Unmarshal([]byte(actionStr.String), step.Action)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse logged action: %v", err)
+			}
+		}
+		if newGameStateStr.Valid {
+			step.ExpectedGameState = new(common.GameState)
+			err = json.Unmarshal([]byte(newGameStateStr.String), step.ExpectedGameState)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse logged game state: %v", err)
+			}
+		}
+		export.Steps = append(export.Steps, step)
+	}
+	rows.Close()
+
+	// The draws for the start of the game are already reflected in the first state, and undos and rollbacks don't replay
+	// anything
+	export.RandomValues = make([]int, 0)
+	for idx, step := range export.Steps {
+		if idx == 0 || step.Action == nil || step.Action.ActionName == undoActionName || step.Action.ActionName == rollbackActionName {
+			continue
+		}
+		if step.RandomValues == nil {
+			export.RandomValues = nil
+			break
+		}
+		export.RandomValues = append(export.RandomValues, step.RandomValues...)
+	}
+
+	stmt, err = server.db.Prepare("SELECT seq,timestamp,user_id,message FROM game_chat WHERE game_id=? ORDER BY seq ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err = stmt.Query(gameId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		message := new(GameChatMessage)
+		err = rows.Scan(&message.Seq, &message.Timestamp, &message.UserId, &message.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		export.Chat = append(export.Chat, message)
+	}
+
+	return export, nil
+}
+
+// importGame re-creates an exported game, along with any of its players that don't already exist on this server
+func (server *GameServer) importGame(export *ExportedGame) error {
+	info := export.Game
+	if info == nil || info.Id == "" {
+		return fmt.Errorf("export is missing game metadata")
+	}
+	gameMap, err := server.getGameMap(export.MapName)
+	if err != nil {
+		return err
+	}
+	// A custom map that isn't on this server is re-created with the same id, so that the game's map name still refers
+	// to it
+	importCustomMap := gameMap == nil && export.CustomMap != nil && strings.HasPrefix(export.MapName, customMapPrefix)
+	if importCustomMap {
+		_, problems, err := maps.ParseCustomMap(export.CustomMap.Map)
+		if err != nil {
+			return fmt.Errorf("failed to parse map %s: %v", export.MapName, err)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("map %s is invalid: %s", export.MapName, strings.Join(problems, "; "))
+		}
+	} else if gameMap == nil {
+		return fmt.Errorf("unknown map: %s", export.MapName)
+	}
+
+	tx, err := server.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM games WHERE id=?", info.Id).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	if count != 0 {
+		return fmt.Errorf("game %s already exists", info.Id)
+	}
+
+	if importCustomMap {
+		_, err = tx.Exec("INSERT INTO maps (id,name,owner_user_id,visibility,map_data,created_at) VALUES (?,?,?,?,?,?)",
+			strings.TrimPrefix(export.MapName, customMapPrefix), export.CustomMap.Name, export.CustomMap.OwnerUserId,
+			string(export.CustomMap.Visibility), string(export.CustomMap.Map), time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("failed to insert map row: %v", err)
+		}
+	}
+
+	for _, player := range export.Players {
+		err = tx.QueryRow("SELECT COUNT(*) FROM users WHERE id=?", player.Id).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to execute query: %v", err)
+		}
+		if count == 0 {
+			var botType sql.NullString
+			if player.BotType != "" {
+				botType = sql.NullString{String: player.BotType, Valid: true}
+			}
+			_, err = tx.Exec("INSERT INTO users (id,nickname,email_notifications_enabled,discord_turn_alerts_enabled,bot_type) VALUES (?,?,0,0,?)",
+				player.Id, player.Nickname, botType)
+			if err != nil {
+				return fmt.Errorf("failed to insert user %s: %v", player.Id, err)
+			}
+		}
+		_, err = tx.Exec("INSERT INTO game_player_map (game_id,player_user_id) VALUES (?,?)", info.Id, player.Id)
+		if err != nil {
+			return fmt.Errorf("failed to insert game_player_map row: %v", err)
+		}
+	}
+
+	var gameStateStr sql.NullString
+	if info.GameState != nil {
+		gameStateBytes, err := json.Marshal(info.GameState)
+		if err != nil {
+			return fmt.Errorf("failed to marshal game state: %v", err)
+		}
+		gameStateStr = sql.NullString{String: string(gameStateBytes), Valid: true}
+	}
+	var activePlayer sql.NullString
+	if info.ActivePlayer != "" {
+		activePlayer = sql.NullString{String: info.ActivePlayer, Valid: true}
+	}
+	// An unfinished provably fair game is exported without its seed, so it continues with the server's regular source
+	// of randomness and its remaining draws can't be checked against the commitment. It is imported as a game that
+	// isn't provably fair rather than one that would fail verification.
+	var randomSeed sql.NullString
+	var randomSeedCommitment sql.NullString
+	drawCount := 0
+	if info.RandomSeed != "" {
+		randomSeed = sql.NullString{String: info.RandomSeed, Valid: true}
+		randomSeedCommitment = sql.NullString{String: info.RandomSeedCommitment, Valid: true}
+		for _, step := range export.Steps {
+			drawCount += len(step.RandomValues)
+		}
+	}
+	var finalScoresStr sql.NullString
+	if info.FinalScores != nil {
+		finalScoresBytes, err := json.Marshal(info.FinalScores)
+		if err != nil {
+			return fmt.Errorf("failed to marshal final scores: %v", err)
+		}
+		finalScoresStr = sql.NullString{String: string(finalScoresBytes), Valid: true}
+	}
+	mapVariants, err := marshalMapVariants(info.MapVariants)
+	if err != nil {
+		return err
+	}
+	gameOptions, err := marshalGameOptions(info.Options)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO games (id,created_at,name,min_players,max_players,map_name,owner_user_id,started,finished,game_state,active_player_id,invite_only,move_time_limit_hours,final_scores,random_seed,random_seed_commitment,random_draw_count,version,map_variants,game_options) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+		info.Id, info.CreatedAt, info.Name, info.MinPlayers, info.MaxPlayers, export.MapName, info.OwnerUserId,
+		boolToInt(info.Started), boolToInt(info.Finished), gameStateStr, activePlayer, boolToInt(info.InviteOnly),
+		info.MoveTimeLimitHours, finalScoresStr, randomSeed, randomSeedCommitment, drawCount, info.Version, mapVariants, gameOptions)
+	if err != nil {
+		return fmt.Errorf("failed to insert game row: %v", err)
+	}
+
+	// Steps and chat messages are in order, so they're numbered by their position in the export
+	for idx, step := range export.Steps {
+		var actionStr sql.NullString
+		if step.Action != nil && (step.Action.ActionName == undoActionName || step.Action.ActionName == rollbackActionName) {
+			actionStr = sql.NullString{String: string(step.Action.ActionName), Valid: true}
+		} else if step.Action != nil {
+			actionBytes, err := json.Marshal(step.Action)
+			if err != nil {
+				return fmt.Errorf("failed to marshal action: %v", err)
+			}
+			actionStr = sql.NullString{String: string(actionBytes), Valid: true}
+		}
+		var newGameStateStr sql.NullString
+		if step.ExpectedGameState != nil {
+			newGameStateBytes, err := json.Marshal(step.ExpectedGameState)
+			if err != nil {
+				return fmt.Errorf("failed to marshal game state: %v", err)
+			}
+			newGameStateStr = sql.NullString{String: string(newGameStateBytes), Valid: true}
+		}
+		var randomValuesStr sql.NullString
+		if step.RandomValues != nil {
+			randomValuesStr, err = marshalRandomValues(step.RandomValues)
+			if err != nil {
+				return err
+			}
+		}
+		var randomBoundsStr sql.NullString
+		if step.RandomBounds != nil {
+			randomBoundsStr, err = marshalRandomValues(step.RandomBounds)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("INSERT INTO game_log (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds) VALUES (?,?,?,?,?,?,?,?,?,?,?)",
+			info.Id, idx+1, step.Timestamp, step.UserId, actionStr, step.Description, step.ExpectedActivePlayer,
+			newGameStateStr, boolToInt(step.Reversible), randomValuesStr, randomBoundsStr)
+		if err != nil {
+			return fmt.Errorf("failed to insert game_log row: %v", err)
+		}
+	}
+
+	for idx, message := range export.Chat {
+		_, err = tx.Exec("INSERT INTO game_chat (game_id,seq,timestamp,user_id,message) VALUES (?,?,?,?,?)",
+			info.Id, idx+1, message.Timestamp, message.UserId, message.Message)
+		if err != nil {
+			return fmt.Errorf("failed to insert game_chat row: %v", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// importGameFile imports a game from a file written by exportGame
+func (server *GameServer) importGameFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	export := new(ExportedGame)
+	err = json.NewDecoder(f).Decode(export)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return server.importGame(export)
+}
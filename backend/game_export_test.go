@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAndImportGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	err = h.gameServer.timeoutActivePlayer(createRes.Id)
+	require.NoError(t, err)
+	_, err = h.sendGameChat(t, player2, &SendGameChatRequest{GameId: createRes.Id, Message: "hello"})
+	require.NoError(t, err)
+
+	export, err := h.exportGame(t, player1, &ExportGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "rust_belt", export.MapName)
+	assert.Equal(t, "game-name", export.Game.Name)
+	assert.Equal(t, player1, export.Game.OwnerUserId)
+	assert.True(t, export.Game.Started)
+	assert.Len(t, export.Players, 2)
+	require.Len(t, export.Steps, 2)
+	assert.Nil(t, export.Steps[0].Action)
+	assert.NotNil(t, export.Steps[1].Action)
+	assert.Equal(t, export.Game.GameState, export.Steps[1].ExpectedGameState)
+	require.Len(t, export.Chat, 1)
+	assert.Equal(t, "hello", export.Chat[0].Message)
+
+	// The export can be used directly as a game log test case
+	exportBytes, err := json.Marshal(export)
+	require.NoError(t, err)
+	definition := new(GameLogTestCaseDefinition)
+	err = json.Unmarshal(exportBytes, definition)
+	require.NoError(t, err)
+	assert.Equal(t, "rust_belt", definition.MapName)
+	require.Len(t, definition.Steps, 2)
+	assert.Equal(t, export.Steps[1].ExpectedGameState, definition.Steps[1].ExpectedGameState)
+
+	viewBefore, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	logsBefore, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "export.json")
+	err = os.WriteFile(path, exportBytes, 0644)
+	require.NoError(t, err)
+
+	// Importing over an existing game is refused
+	err = runTask(h.gameServer, []string{"import-game", path})
+	assert.Error(t, err)
+
+	for _, table := range []string{"games WHERE id=?", "game_player_map WHERE game_id=?", "game_log WHERE game_id=?", "game_chat WHERE game_id=?"} {
+		_, err = h.gameServer.db.Exec("DELETE FROM "+table, createRes.Id)
+		require.NoError(t, err)
+	}
+	_, err = h.gameServer.db.Exec("DELETE FROM users WHERE id=?", player2)
+	require.NoError(t, err)
+
+	err = runTask(h.gameServer, []string{"import-game", path})
+	require.NoError(t, err)
+
+	viewAfter, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	// Joined users come back in no particular order
+	assert.ElementsMatch(t, viewBefore.JoinedUsers, viewAfter.JoinedUsers)
+	viewBefore.JoinedUsers = nil
+	viewAfter.JoinedUsers = nil
+	assert.Equal(t, viewBefore, viewAfter)
+	logsAfter, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, logsBefore, logsAfter)
+	chatRes, err := h.getGameChat(t, player1, &GetGameChatRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.Len(t, chatRes.Messages, 1)
+	assert.Equal(t, player2, chatRes.Messages[0].UserId)
+}
+
+func TestExportGameAccess(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	outsider := h.createUser(t)
+	admin := h.createUser(t)
+	h.gameServer.config.AdminUserIds = []string{admin}
+
+	for _, inviteOnly := range []bool{false, true} {
+		createRes, err := h.createGame(t, player1, &CreateGameRequest{
+			Name:       "game-name",
+			MinPlayers: 2,
+			MaxPlayers: 2,
+			MapName:    "rust_belt",
+			InviteOnly: inviteOnly,
+		})
+		require.NoError(t, err)
+		_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+		require.NoError(t, err)
+		_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+		require.NoError(t, err)
+
+		// Unfinished games can only be exported by their players and admins
+		for _, userId := range []string{player1, player2, admin} {
+			_, err = h.exportGame(t, userId, &ExportGameRequest{GameId: createRes.Id})
+			require.NoError(t, err)
+		}
+		var httpError *api.HttpError
+		_, err = h.exportGame(t, outsider, &ExportGameRequest{GameId: createRes.Id})
+		require.ErrorAs(t, err, &httpError)
+		assert.Equal(t, http.StatusForbidden, httpError.Code)
+
+		// Once finished, public games can be exported by anyone
+		_, err = h.gameServer.db.Exec("UPDATE games SET finished=1 WHERE id=?", createRes.Id)
+		require.NoError(t, err)
+		_, err = h.exportGame(t, outsider, &ExportGameRequest{GameId: createRes.Id})
+		if inviteOnly {
+			require.ErrorAs(t, err, &httpError)
+			assert.Equal(t, http.StatusForbidden, httpError.Code)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestExportHidesSealedBids(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, players := startAuctionTestGame(t, h, common.SEALED_BID_AUCTION_TYPE)
+	_, err := h.confirmMove(t, players[0], &api.ConfirmMoveRequest{
+		GameId:     gameId,
+		ActionName: api.BidActionName,
+		BidAction:  &api.BidAction{Amount: 3},
+	})
+	require.NoError(t, err)
+
+	// The other player only sees that a bid was made, in the game state, the logged states and the logged bid
+	export, err := h.exportGame(t, players[1], &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{players[0]: 0}, export.Game.GameState.AuctionState)
+	bidStep := export.Steps[len(export.Steps)-1]
+	assert.Equal(t, players[0], bidStep.UserId)
+	assert.Equal(t, map[string]int{players[0]: 0}, bidStep.ExpectedGameState.AuctionState)
+	assert.Equal(t, 0, bidStep.Action.BidAction.Amount)
+	assert.NotContains(t, bidStep.Description, "$")
+
+	// The bidder can still see their own bid
+	export, err = h.exportGame(t, players[0], &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{players[0]: 3}, export.Game.GameState.AuctionState)
+	bidStep = export.Steps[len(export.Steps)-1]
+	assert.Equal(t, map[string]int{players[0]: 3}, bidStep.ExpectedGameState.AuctionState)
+	assert.Equal(t, 3, bidStep.Action.BidAction.Amount)
+
+	// Everything is exported once the game is over
+	_, err = h.gameServer.db.Exec("UPDATE games SET finished=1 WHERE id=?", gameId)
+	require.NoError(t, err)
+	export, err = h.exportGame(t, players[1], &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	bidStep = export.Steps[len(export.Steps)-1]
+	assert.Equal(t, map[string]int{players[0]: 3}, bidStep.ExpectedGameState.AuctionState)
+	assert.Equal(t, 3, bidStep.Action.BidAction.Amount)
+}
+
+func TestExportAndImportCustomMapGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	mapData, err := os.ReadFile("maps/rust_belt.json")
+	require.NoError(t, err)
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	uploadRes, err := h.uploadMap(t, player1, &UploadMapRequest{Name: "Rust Belt", Visibility: PRIVATE_MAP_VISIBILITY, Map: mapData})
+	require.NoError(t, err)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    uploadRes.MapName,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	err = h.gameServer.timeoutActivePlayer(createRes.Id)
+	require.NoError(t, err)
+
+	export, err := h.exportGame(t, player2, &ExportGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, uploadRes.MapName, export.MapName)
+	require.NotNil(t, export.CustomMap)
+	assert.Equal(t, "Rust Belt", export.CustomMap.Name)
+	assert.Equal(t, player1, export.CustomMap.OwnerUserId)
+	assert.Equal(t, PRIVATE_MAP_VISIBILITY, export.CustomMap.Visibility)
+	assert.JSONEq(t, string(mapData), string(export.CustomMap.Map))
+
+	viewBefore, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	exportBytes, err := json.Marshal(export)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "export.json")
+	err = os.WriteFile(path, exportBytes, 0644)
+	require.NoError(t, err)
+
+	// Import onto a server that has neither the game nor its map
+	for _, table := range []string{"games WHERE id=?", "game_player_map WHERE game_id=?", "game_log WHERE game_id=?"} {
+		_, err = h.gameServer.db.Exec("DELETE FROM "+table, createRes.Id)
+		require.NoError(t, err)
+	}
+	_, err = h.gameServer.db.Exec("DELETE FROM maps")
+	require.NoError(t, err)
+	h.gameServer.customGameMaps.Delete(uploadRes.MapName)
+
+	err = runTask(h.gameServer, []string{"import-game", path})
+	require.NoError(t, err)
+
+	viewAfter, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.NotNil(t, viewAfter.CustomMap)
+	assert.Equal(t, viewBefore.CustomMap.Name, viewAfter.CustomMap.Name)
+	assert.JSONEq(t, string(viewBefore.CustomMap.Map), string(viewAfter.CustomMap.Map))
+	assert.Equal(t, viewBefore.GameState, viewAfter.GameState)
+	err = h.gameServer.timeoutActivePlayer(createRes.Id)
+	require.NoError(t, err)
+	err = runTask(h.gameServer, []string{"verify-game", createRes.Id})
+	require.NoError(t, err)
+
+	// Without the embedded map, a game on an unknown custom map can't be imported
+	_, err = h.gameServer.db.Exec("DELETE FROM games WHERE id=?", createRes.Id)
+	require.NoError(t, err)
+	export.CustomMap = nil
+	export.MapName = customMapPrefix + "missing"
+	err = h.gameServer.importGame(export)
+	assert.ErrorContains(t, err, "unknown map")
+}
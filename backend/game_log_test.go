@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -17,12 +19,16 @@ type GameStep struct {
 	Action               *api.ConfirmMoveRequest `json:"action"`
 	ExpectedGameState    *common.GameState       `json:"expectedGameState"`
 	ExpectedActivePlayer string                  `json:"expectedActivePlayer"`
+	UserId               string                  `json:"userId"`
 }
 
 type GameLogTestCaseDefinition struct {
 	MapName      string      `json:"mapName"`
 	Steps        []*GameStep `json:"steps"`
 	RandomValues []int       `json:"randomValues"`
+	// Only in exports, where they give the state the game ended up in and the map if it's a custom one
+	Game      *ExportedGameInfo  `json:"game"`
+	CustomMap *ExportedCustomMap `json:"customMap"`
 }
 
 func gameLogTestCase(definition string) func(t *testing.T) {
@@ -32,38 +38,91 @@ func gameLogTestCase(definition string) func(t *testing.T) {
 		require.NoError(t, err)
 		err = json.Unmarshal(defBytes, caseDefinition)
 		require.NoError(t, err)
+		replayGameLogTestCase(t, caseDefinition)
+	}
+}
 
+func replayGameLogTestCase(t *testing.T, caseDefinition *GameLogTestCaseDefinition) {
+	var gameMap maps.GameMap
+	if caseDefinition.CustomMap != nil {
+		var problems []string
+		var err error
+		gameMap, problems, err = maps.ParseCustomMap(caseDefinition.CustomMap.Map)
+		require.NoError(t, err)
+		require.Empty(t, problems)
+	} else {
 		gameMaps, err := maps.LoadMaps()
 		require.NoError(t, err)
-		gameMap := gameMaps[caseDefinition.MapName]
-		gameState := caseDefinition.Steps[0].ExpectedGameState
-		activePlayer := caseDefinition.Steps[0].ExpectedActivePlayer
+		gameMap = gameMaps[caseDefinition.MapName]
+	}
+	require.NotNil(t, gameMap)
+	gameState := caseDefinition.Steps[0].ExpectedGameState
+	activePlayer := caseDefinition.Steps[0].ExpectedActivePlayer
 
-		playerIdToNick := make(map[string]string)
-		for _, playerId := range gameState.PlayerOrder {
-			playerIdToNick[playerId] = playerId
-		}
+	playerIdToNick := make(map[string]string)
+	for _, playerId := range gameState.PlayerOrder {
+		playerIdToNick[playerId] = playerId
+	}
 
-		handler := &confirmMoveHandler{
-			gameMap:        gameMap,
-			gameState:      gameState,
-			activePlayer:   activePlayer,
-			randProvider:   &fixedRandProvider{values: caseDefinition.RandomValues},
-			playerIdToNick: playerIdToNick,
-			gameFinished:   false,
-		}
+	handler := &confirmMoveHandler{
+		gameMap:        gameMap,
+		gameState:      gameState,
+		activePlayer:   activePlayer,
+		randProvider:   &fixedRandProvider{values: caseDefinition.RandomValues},
+		playerIdToNick: playerIdToNick,
+		gameFinished:   false,
+	}
 
-		for idx, step := range caseDefinition.Steps[1:] {
-			assert.Equal(t, false, handler.gameFinished)
+	// The state before each move, for undos, and after every step, for rollbacks
+	var priorStates []*common.GameState
+	startState, err := cloneGameState(gameState)
+	require.NoError(t, err)
+	history := []*common.GameState{startState}
+
+	for idx, step := range caseDefinition.Steps[1:] {
+		assert.Equal(t, false, handler.gameFinished)
 
+		switch step.Action.ActionName {
+		case undoActionName:
+			// An undo restores the state from before the previous move, and gives the turn back to whoever undid it
+			require.NotEmpty(t, priorStates, "Nothing to undo on step %d", idx+1)
+			handler.gameState = priorStates[len(priorStates)-1]
+			handler.activePlayer = step.UserId
+			priorStates = priorStates[:len(priorStates)-1]
+		case rollbackActionName:
+			// A rollback restores the result of some earlier step, but the log doesn't say which one
+			target := slices.IndexFunc(history, func(state *common.GameState) bool {
+				return assert.ObjectsAreEqual(step.ExpectedGameState, state)
+			})
+			require.NotEqual(t, -1, target, "No earlier state to roll back to on step %d", idx+1)
+			handler.gameState, err = cloneGameState(history[target])
+			require.NoError(t, err)
+			handler.activePlayer = step.ExpectedActivePlayer
+		default:
+			priorState, err := cloneGameState(handler.gameState)
+			require.NoError(t, err)
+			priorStates = append(priorStates, priorState)
 			err = handler.handleAction(step.Action)
 			require.NoError(t, err)
-
-			assert.Equal(t, step.ExpectedActivePlayer, handler.activePlayer, "Unexpected active player on step %d", idx+1)
-			assert.Equal(t, step.ExpectedGameState, handler.gameState, "Unexpected game state on step %d", idx+1)
 		}
 
-		assert.Equal(t, true, handler.gameFinished)
+		assert.Equal(t, step.ExpectedActivePlayer, handler.activePlayer, "Unexpected active player on step %d", idx+1)
+		assert.Equal(t, step.ExpectedGameState, handler.gameState, "Unexpected game state on step %d", idx+1)
+		result, err := cloneGameState(handler.gameState)
+		require.NoError(t, err)
+		history = append(history, result)
+	}
+
+	// The checked in logs predate exporting the game itself, and are all of finished games
+	finished := true
+	if caseDefinition.Game != nil {
+		finished = caseDefinition.Game.Finished
+		assert.Equal(t, caseDefinition.Game.ActivePlayer, handler.activePlayer)
+		assert.Equal(t, caseDefinition.Game.GameState, handler.gameState)
+	}
+	assert.Equal(t, finished, handler.gameFinished)
+	if finished {
+		assert.Equal(t, len(gameState.PlayerShares), len(handler.finalScores))
 	}
 }
 
@@ -72,6 +131,56 @@ func TestGameLogCases(t *testing.T) {
 	t.Run("beta test, 5p, rust belt", gameLogTestCase("62915bcc-04ed-48a2-a3c5-4b54782c5efb"))
 }
 
+func TestReplayExportedGameLog(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, player1 := h.startTwoPlayerGame(t, false)
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	_, err = h.confirmMove(t, viewRes.ActivePlayer, &api.ConfirmMoveRequest{
+		GameId:       gameId,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 1},
+	})
+	require.NoError(t, err)
+	_, err = h.undoMove(t, viewRes.ActivePlayer, &UndoMoveRequest{GameId: gameId})
+	require.NoError(t, err)
+	h.playFirstTurn(t, gameId, player1)
+
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: gameId})
+	require.NoError(t, err)
+	_, err = h.rollbackGame(t, player1, &RollbackGameRequest{GameId: gameId, Seq: logsRes.Logs[2].Seq})
+	require.NoError(t, err)
+	joinedUsers, err := h.gameServer.getJoinedUsers(gameId)
+	require.NoError(t, err)
+	for userId := range joinedUsers {
+		if userId != player1 {
+			_, err = h.voteRollback(t, userId, &VoteRollbackRequest{GameId: gameId, Approve: true})
+			require.NoError(t, err)
+		}
+	}
+	err = h.gameServer.timeoutActivePlayer(gameId)
+	require.NoError(t, err)
+
+	export, err := h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	exportBytes, err := json.Marshal(export)
+	require.NoError(t, err)
+	caseDefinition := new(GameLogTestCaseDefinition)
+	err = json.Unmarshal(exportBytes, caseDefinition)
+	require.NoError(t, err)
+	var actions []api.ActionName
+	for _, step := range caseDefinition.Steps[1:] {
+		actions = append(actions, step.Action.ActionName)
+	}
+	require.Contains(t, actions, api.ActionName(undoActionName))
+	require.Contains(t, actions, api.ActionName(rollbackActionName))
+	require.False(t, caseDefinition.Game.Finished)
+
+	replayGameLogTestCase(t, caseDefinition)
+}
+
 func TestExportGameLogTestCase(t *testing.T) {
 	gameId := ""
 	if gameId == "" {
@@ -85,55 +194,63 @@ func TestExportGameLogTestCase(t *testing.T) {
 	err = json.NewDecoder(f).Decode(config)
 	require.NoError(t, err)
 
-	definition := &GameLogTestCaseDefinition{}
-
 	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s",
 		config.Database.MysqlUsername, config.Database.MysqlPassword,
 		config.Database.MysqlHostname, config.Database.MysqlDatabase))
 	require.NoError(t, err)
 
-	stmt, err := db.Prepare("SELECT map_name FROM games WHERE id=?")
-	require.NoError(t, err)
-	defer stmt.Close()
-	row := stmt.QueryRow(gameId)
-	err = row.Scan(&definition.MapName)
+	definition, err := (&GameServer{db: db}).exportGameData(gameId)
 	require.NoError(t, err)
 
-	stmt, err = db.Prepare("SELECT action,new_active_player,new_game_state FROM game_log WHERE game_id=? ORDER BY timestamp ASC")
+	f, err = os.OpenFile("testlogs/"+gameId+".json", os.O_WRONLY|os.O_CREATE, 0644)
 	require.NoError(t, err)
-	defer stmt.Close()
-	rows, err := stmt.Query(gameId)
+	defer f.Close()
+	err = json.NewEncoder(f).Encode(definition)
 	require.NoError(t, err)
-	for rows.Next() {
-		var actionStr sql.NullString
-		var newActivePlayer sql.NullString
-		var newGameStateStr sql.NullString
-		err = rows.Scan(&actionStr, &newActivePlayer, &newGameStateStr)
-		require.NoError(t, err)
+}
 
-		var action *api.ConfirmMoveRequest
-		if actionStr.Valid {
-			action = new(api.ConfirmMoveRequest)
-			err = json.Unmarshal([]byte(actionStr.String), action)
-			require.NoError(t, err)
-		}
-		var gameState *common.GameState
-		if newGameStateStr.Valid {
-			gameState = new(common.GameState)
-			err = json.Unmarshal([]byte(newGameStateStr.String), gameState)
-			require.NoError(t, err)
-		}
+func TestRecordedRandomValues(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
 
-		definition.Steps = append(definition.Steps, &GameStep{
-			Action:               action,
-			ExpectedGameState:    gameState,
-			ExpectedActivePlayer: newActivePlayer.String,
-		})
-	}
+	gameId, player1 := h.startTwoPlayerGame(t, false)
+	h.playFirstTurn(t, gameId, player1)
 
-	f, err = os.OpenFile("testlogs/"+gameId+".json", os.O_WRONLY|os.O_CREATE, 0644)
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: gameId})
 	require.NoError(t, err)
-	defer f.Close()
-	err = json.NewEncoder(f).Encode(definition)
+	// Setting up the board draws cubes, and goods growth rolls dice
+	assert.NotEmpty(t, logsRes.Logs[0].RandomValues)
+	gameMap := h.gameServer.gameMaps["rust_belt"]
+	lastLog := logsRes.Logs[len(logsRes.Logs)-1]
+	assert.Len(t, lastLog.RandomValues, 2*gameMap.GetGoodsGrowthDiceCount(2))
+
+	export, err := h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	var expectedValues []int
+	for _, step := range export.Steps[1:] {
+		require.NotNil(t, step.RandomValues)
+		expectedValues = append(expectedValues, step.RandomValues...)
+	}
+	assert.Equal(t, expectedValues, export.RandomValues)
+
+	// The recorded draws reproduce the move exactly
+	priorStep := export.Steps[len(export.Steps)-2]
+	lastStep := export.Steps[len(export.Steps)-1]
+	playerIdToNick := make(map[string]string)
+	for _, player := range export.Players {
+		playerIdToNick[player.Id] = player.Nickname
+	}
+	handler := &confirmMoveHandler{
+		gameMap:        gameMap,
+		gameState:      priorStep.ExpectedGameState,
+		activePlayer:   priorStep.ExpectedActivePlayer,
+		randProvider:   &fixedRandProvider{values: lastStep.RandomValues},
+		playerIdToNick: playerIdToNick,
+	}
+	err = handler.handleAction(lastStep.Action)
 	require.NoError(t, err)
+	assert.Equal(t, lastStep.ExpectedActivePlayer, handler.activePlayer)
+	assert.Equal(t, lastStep.ExpectedGameState, handler.gameState)
+	// The logged description also says that the move was made because the player ran out of time
+	assert.Contains(t, lastStep.Description, strings.Join(handler.logs, "\n"))
 }
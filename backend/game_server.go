@@ -6,9 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/api"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/JackOfMostTrades/eot/backend/common"
@@ -23,6 +28,9 @@ type GameServer struct {
 	randProvider   common.RandProvider
 	httpServer     *http.Server
 	httpListenPort int
+	eventBroker    atomic.Pointer[gameEventBroker]
+	// Parsed custom maps by map name. Custom maps can't be changed once uploaded, so they never need to be evicted.
+	customGameMaps sync.Map
 }
 
 type User struct {
@@ -366,6 +374,14 @@ type CreateGameRequest struct {
 	MaxPlayers int    `json:"maxPlayers"`
 	MapName    string `json:"mapName"`
 	InviteOnly bool   `json:"inviteOnly"`
+	// How long each player has to make a move before a default move is made for them, or zero for no limit
+	MoveTimeLimitHours int `json:"moveTimeLimitHours"`
+	// If set, all random draws come from a seed that is committed to now and revealed when the game finishes
+	ProvablyFair bool `json:"provablyFair"`
+	// Variant rules to play with, which the map must support
+	MapVariants []common.MapVariant `json:"mapVariants,omitempty"`
+	// House rules to play with
+	Options *common.GameOptions `json:"options,omitempty"`
 }
 
 type CreateGameResponse struct {
@@ -379,8 +395,93 @@ func (server *GameServer) createGame(ctx *RequestContext, req *CreateGameRequest
 	if req.MinPlayers == 0 || req.MaxPlayers == 0 || req.MinPlayers > req.MaxPlayers {
 		return nil, &api.HttpError{"invalid or missing minPlayers/maxPlayers parameter", http.StatusBadRequest}
 	}
+	if req.MoveTimeLimitHours < 0 {
+		return nil, &api.HttpError{"invalid moveTimeLimitHours parameter", http.StatusBadRequest}
+	}
+	gameMap, err := server.getGameMap(req.MapName)
+	if err != nil {
+		return nil, err
+	}
+	if gameMap == nil {
+		return nil, &api.HttpError{fmt.Sprintf("invalid mapName parameter: %s", req.MapName), http.StatusBadRequest}
+	}
+	// The game could start with any number of players in between, so they all need to be supported
+	supportedPlayerCounts := gameMap.GetSupportedPlayerCounts()
+	for playerCount := req.MinPlayers; playerCount <= req.MaxPlayers; playerCount++ {
+		if !slices.Contains(supportedPlayerCounts, playerCount) {
+			return nil, &api.HttpError{fmt.Sprintf("this map can't be played with %d players (it supports %v)", playerCount, supportedPlayerCounts),
+				http.StatusBadRequest}
+		}
+	}
+	for i, variant := range req.MapVariants {
+		if !slices.Contains(gameMap.GetSupportedVariants(), variant) {
+			return nil, &api.HttpError{fmt.Sprintf("this map doesn't support the %s variant", variant), http.StatusBadRequest}
+		}
+		if slices.Contains(req.MapVariants[:i], variant) {
+			return nil, &api.HttpError{fmt.Sprintf("duplicate variant: %s", variant), http.StatusBadRequest}
+		}
+	}
+	if req.Options != nil {
+		options := req.Options
+		if startingCash := options.GetStartingCash(); startingCash < 0 || startingCash > common.MAX_STARTING_CASH {
+			return nil, &api.HttpError{fmt.Sprintf("invalid options parameter: starting cash must be between 0 and %d", common.MAX_STARTING_CASH),
+				http.StatusBadRequest}
+		}
+		if options.GetStartingShares() < 0 {
+			return nil, &api.HttpError{"invalid options parameter: starting shares can't be negative", http.StatusBadRequest}
+		}
+		// Zero leaves the map's limits in place
+		if options.SharesLimit < 0 || options.SharesLimit > common.MAX_SHARES_LIMIT {
+			return nil, &api.HttpError{fmt.Sprintf("invalid options parameter: shares limit must be between 1 and %d", common.MAX_SHARES_LIMIT),
+				http.StatusBadRequest}
+		}
+		if options.TurnLimit < 0 || options.TurnLimit > common.MAX_TURN_LIMIT {
+			return nil, &api.HttpError{fmt.Sprintf("invalid options parameter: turn limit must be between 1 and %d", common.MAX_TURN_LIMIT),
+				http.StatusBadRequest}
+		}
+		if !slices.Contains(common.ALL_AUCTION_TYPES, options.GetAuctionType()) {
+			return nil, &api.HttpError{fmt.Sprintf("invalid options parameter: unknown auction type %s", options.AuctionType), http.StatusBadRequest}
+		}
+		startingShares := options.GetStartingShares()
+		if slices.Contains(req.MapVariants, common.FIRST_TURN_EXTRA_SHARE_VARIANT) {
+			startingShares += 1
+		}
+		sharesLimit := options.GetSharesLimit(gameMap.GetSharesLimit())
+		if startingShares > sharesLimit {
+			return nil, &api.HttpError{fmt.Sprintf("invalid options parameter: can't start with more than the %d shares limit", sharesLimit),
+				http.StatusBadRequest}
+		}
+	}
+	if strings.HasPrefix(req.MapName, customMapPrefix) {
+		custom, err := server.getCustomMap(req.MapName)
+		if err != nil {
+			return nil, err
+		}
+		if custom.visibility == PRIVATE_MAP_VISIBILITY && custom.ownerUserId != ctx.User.Id {
+			return nil, &api.HttpError{fmt.Sprintf("invalid mapName parameter: %s", req.MapName), http.StatusBadRequest}
+		}
+	}
+
+	var randomSeed sql.NullString
+	var randomSeedCommitment sql.NullString
+	if req.ProvablyFair {
+		seed, commitment, err := newRandomSeed()
+		if err != nil {
+			return nil, err
+		}
+		randomSeed = sql.NullString{String: seed, Valid: true}
+		randomSeedCommitment = sql.NullString{String: commitment, Valid: true}
+	}
+	mapVariants, err := marshalMapVariants(req.MapVariants)
+	if err != nil {
+		return nil, err
+	}
+	gameOptions, err := marshalGameOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
 
-	stmt, err := server.db.Prepare("INSERT INTO games (id,created_at,name,min_players,max_players,map_name,owner_user_id,started,finished,invite_only) VALUES (?,?,?,?,?,?,?,0,0,?)")
+	stmt, err := server.db.Prepare("INSERT INTO games (id,created_at,name,min_players,max_players,map_name,owner_user_id,started,finished,invite_only,move_time_limit_hours,random_seed,random_seed_commitment,random_draw_count,map_variants,game_options) VALUES (?,?,?,?,?,?,?,0,0,?,?,?,?,0,?,?)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
@@ -390,7 +491,8 @@ func (server *GameServer) createGame(ctx *RequestContext, req *CreateGameRequest
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate id: %v", err)
 	}
-	_, err = stmt.Exec(id.String(), time.Now().Unix(), req.Name, req.MinPlayers, req.MaxPlayers, req.MapName, ctx.User.Id, boolToInt(req.InviteOnly))
+	_, err = stmt.Exec(id.String(), time.Now().Unix(), req.Name, req.MinPlayers, req.MaxPlayers, req.MapName, ctx.User.Id, boolToInt(req.InviteOnly), req.MoveTimeLimitHours,
+		randomSeed, randomSeedCommitment, mapVariants, gameOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert game row: %v", err)
 	}
@@ -410,21 +512,24 @@ func (server *GameServer) createGame(ctx *RequestContext, req *CreateGameRequest
 
 type JoinGameRequest struct {
 	GameId string `json:"gameId"`
+	// If set, the game owner is adding a bot of this type to the game instead of joining themselves
+	BotType string `json:"botType,omitempty"`
 }
 type JoinGameResponse struct {
 }
 
 func (server *GameServer) joinGame(ctx *RequestContext, req *JoinGameRequest) (resp *JoinGameResponse, err error) {
-	stmt, err := server.db.Prepare("SELECT min_players,max_players,started FROM games WHERE id=?")
+	stmt, err := server.db.Prepare("SELECT owner_user_id,min_players,max_players,started FROM games WHERE id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
 	defer stmt.Close()
 	row := stmt.QueryRow(req.GameId)
+	var ownerUserId string
 	var minPlayers int
 	var maxPlayers int
 	var startedFlag int
-	err = row.Scan(&minPlayers, &maxPlayers, &startedFlag)
+	err = row.Scan(&ownerUserId, &minPlayers, &maxPlayers, &startedFlag)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
@@ -441,21 +546,34 @@ func (server *GameServer) joinGame(ctx *RequestContext, req *JoinGameRequest) (r
 		return nil, err
 	}
 
-	if _, ok := joinedUsers[ctx.User.Id]; ok {
-		return nil, &api.HttpError{"you have already joined this game", http.StatusBadRequest}
-	}
-	if len(joinedUsers) >= maxPlayers {
-		return nil, &api.HttpError{"this game is already full", http.StatusBadRequest}
-	}
+	if req.BotType != "" {
+		if ownerUserId != ctx.User.Id {
+			return nil, &api.HttpError{"only the game owner can add bots", http.StatusBadRequest}
+		}
+		if len(joinedUsers) >= maxPlayers {
+			return nil, &api.HttpError{"this game is already full", http.StatusBadRequest}
+		}
+		err = server.addBotPlayer(req.GameId, req.BotType, len(joinedUsers)+1)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if _, ok := joinedUsers[ctx.User.Id]; ok {
+			return nil, &api.HttpError{"you have already joined this game", http.StatusBadRequest}
+		}
+		if len(joinedUsers) >= maxPlayers {
+			return nil, &api.HttpError{"this game is already full", http.StatusBadRequest}
+		}
 
-	stmt, err = server.db.Prepare("INSERT INTO game_player_map (game_id,player_user_id) VALUES (?,?)")
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare query: %v", err)
-	}
-	defer stmt.Close()
-	_, err = stmt.Exec(req.GameId, ctx.User.Id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %v", err)
+		stmt, err = server.db.Prepare("INSERT INTO game_player_map (game_id,player_user_id) VALUES (?,?)")
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare query: %v", err)
+		}
+		defer stmt.Close()
+		_, err = stmt.Exec(req.GameId, ctx.User.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute query: %v", err)
+		}
 	}
 
 	// If enough players have joined, mark the owner as the "active player" to indicate the game is waiting on their action
@@ -579,6 +697,8 @@ func (server *GameServer) deleteGame(ctx *RequestContext, req *LeaveGameRequest)
 	for _, query := range []string{
 		"DELETE FROM game_chat WHERE game_id=?",
 		"DELETE FROM game_log WHERE game_id=?",
+		"DELETE FROM game_rollback_approvals WHERE game_id=?",
+		"DELETE FROM game_rollbacks WHERE game_id=?",
 		"DELETE FROM game_player_map WHERE game_id=?",
 		"DELETE FROM games WHERE id=?",
 	} {
@@ -607,7 +727,7 @@ type StartGameResponse struct {
 }
 
 func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest) (resp *StartGameResponse, err error) {
-	stmt, err := server.db.Prepare("SELECT owner_user_id,min_players,max_players,map_name,started FROM games WHERE id=?")
+	stmt, err := server.db.Prepare("SELECT owner_user_id,min_players,max_players,map_name,started,map_variants,game_options FROM games WHERE id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
@@ -618,7 +738,9 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 	var maxPlayers int
 	var mapName string
 	var startedFlag int
-	err = row.Scan(&ownerUserId, &minPlayers, &maxPlayers, &mapName, &startedFlag)
+	var mapVariantsStr sql.NullString
+	var gameOptionsStr sql.NullString
+	err = row.Scan(&ownerUserId, &minPlayers, &maxPlayers, &mapName, &startedFlag, &mapVariantsStr, &gameOptionsStr)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
@@ -632,6 +754,14 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 	if ownerUserId != ctx.User.Id {
 		return nil, &api.HttpError{"you are not the owner of this game", http.StatusBadRequest}
 	}
+	mapVariants, err := unmarshalMapVariants(mapVariantsStr)
+	if err != nil {
+		return nil, err
+	}
+	gameOptions, err := unmarshalGameOptions(gameOptionsStr)
+	if err != nil {
+		return nil, err
+	}
 
 	joinedUsers, err := server.getJoinedUsers(req.GameId)
 	if err != nil {
@@ -645,6 +775,17 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 		return nil, &api.HttpError{"game has too many joined players", http.StatusBadRequest}
 	}
 
+	gameMap, err := server.getGameMap(mapName)
+	if err != nil {
+		return nil, err
+	}
+	if gameMap == nil {
+		return nil, fmt.Errorf("failed to lookup map: %s", mapName)
+	}
+	if !slices.Contains(gameMap.GetSupportedPlayerCounts(), len(joinedUsers)) {
+		return nil, &api.HttpError{fmt.Sprintf("this map can't be played with %d players", len(joinedUsers)), http.StatusBadRequest}
+	}
+
 	stmt, err = server.db.Prepare("UPDATE games SET started=1 WHERE id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
@@ -693,27 +834,30 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 		Cubes:           nil,
 		GoodsGrowth:     make([][]common.Color, 20),
 		ProductionCubes: nil,
+		MapVariants:     mapVariants,
+		Options:         gameOptions,
 	}
 	for _, userId := range playerOrder {
-		gameState.PlayerShares[userId] = 2
+		gameState.PlayerShares[userId] = gameOptions.GetStartingShares()
 		gameState.PlayerLoco[userId] = 1
 		gameState.PlayerIncome[userId] = 0
-		gameState.PlayerCash[userId] = 10
+		gameState.PlayerCash[userId] = gameOptions.GetStartingCash()
 	}
 
-	gameMap := server.gameMaps[mapName]
-	if gameMap == nil {
-		return nil, fmt.Errorf("failed to lookup map: %s", mapName)
+	// Keep the setup draws for the log
+	gameRandProvider, err := server.getGameRandProvider(req.GameId)
+	if err != nil {
+		return nil, err
 	}
-
-	err = gameMap.PopulateStartingCubes(gameState, server.randProvider)
+	randProvider := &common.RecordingRandProvider{Provider: gameRandProvider}
+	err = gameMap.PopulateStartingCubes(gameState, randProvider)
 
 	// Populate the goods growth table
 	for i := 0; i < 12; i++ {
 		gameState.GoodsGrowth[i] = make([]common.Color, 3)
 		if gameMap.GetCityHexForGoodsGrowth(i).X >= 0 {
 			for j := 0; j < 3; j++ {
-				cube, err := gameState.DrawCube(server.randProvider)
+				cube, err := gameState.DrawCube(randProvider)
 				if err != nil {
 					return nil, fmt.Errorf("failed to draw cube: %v", err)
 				}
@@ -724,7 +868,7 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 	for i := 12; i < 20; i++ {
 		gameState.GoodsGrowth[i] = make([]common.Color, 2)
 		for j := 0; j < 2; j++ {
-			cube, err := gameState.DrawCube(server.randProvider)
+			cube, err := gameState.DrawCube(randProvider)
 			if err != nil {
 				return nil, fmt.Errorf("failed to draw cube: %v", err)
 			}
@@ -734,7 +878,7 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to populate initial board cubes: %v", err)
 	}
-	err = gameMap.PostSetupHook(gameState, server.randProvider)
+	err = gameMap.PostSetupHook(gameState, randProvider)
 	if err != nil {
 		return nil, fmt.Errorf("failed to populate initial board cubes: %v", err)
 	}
@@ -753,14 +897,27 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 		return nil, fmt.Errorf("failed to execute query: %v", err)
 	}
 
+	err = saveRandDrawCount(server.db, req.GameId, randProvider)
+	if err != nil {
+		return nil, err
+	}
+
 	// Log the start of the game
-	stmt, err = server.db.Prepare("INSERT INTO game_log (game_id,timestamp,user_id,action,description,new_active_player,new_game_state,reversible) VALUES(?, ?, ?, ?, ?, ?, ?, 0)")
+	randomValuesStr, err := marshalRandomValues(randProvider.Values)
+	if err != nil {
+		return nil, err
+	}
+	randomBoundsStr, err := marshalRandomValues(randProvider.Bounds)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err = server.db.Prepare("INSERT INTO game_log (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds) VALUES(?, 1, ?, ?, ?, ?, ?, ?, 0, ?, ?)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
 	defer stmt.Close()
 	_, err = stmt.Exec(req.GameId, time.Now().Unix(), ctx.User.Id, sql.NullString{},
-		"The game has started!", playerOrder[0], string(gameStateStr))
+		"The game has started!", playerOrder[0], string(gameStateStr), randomValuesStr, randomBoundsStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %v", err)
 	}
@@ -771,6 +928,12 @@ func (server *GameServer) startGame(ctx *RequestContext, req *StartGameRequest)
 		return nil, fmt.Errorf("failed to notify user it's their turn: %v", err)
 	}
 
+	// The game has started, so a failing bot shouldn't fail the request; the game will wait on the bot instead
+	err = server.runBots(req.GameId, maxBotMovesPerRequest)
+	if err != nil {
+		slog.Error("failed to run bot move", "error", err, "gameId", req.GameId)
+	}
+
 	return &StartGameResponse{}, nil
 }
 
@@ -790,10 +953,33 @@ type ViewGameResponse struct {
 	JoinedUsers  []*User           `json:"joinedUsers"`
 	GameState    *common.GameState `json:"gameState"`
 	InviteOnly   bool              `json:"inviteOnly"`
+	// Zero if the game has no time limit
+	MoveTimeLimitHours int `json:"moveTimeLimitHours"`
+	// Epoch seconds by which the active player must move, if the game has a time limit
+	MoveDeadline int `json:"moveDeadline,omitempty"`
+	// Set once the game has finished, ordered by place
+	FinalScores []*PlayerScore `json:"finalScores,omitempty"`
+	// For provably fair games, the SHA-256 hash of the random seed, and the seed itself once the game has finished
+	RandomSeedCommitment string `json:"randomSeedCommitment,omitempty"`
+	RandomSeed           string `json:"randomSeed,omitempty"`
+	// A rollback waiting on approval from the players, if there is one
+	PendingRollback *PendingRollback `json:"pendingRollback,omitempty"`
+	// Changes whenever the game state does. Moves should be confirmed with the version they were made against.
+	Version int `json:"version"`
+	// Set if the game is on a custom map
+	CustomMap *CustomMapData `json:"customMap,omitempty"`
+	// The variant rules the game was created with
+	MapVariants []common.MapVariant `json:"mapVariants,omitempty"`
+	// The house rules the game was created with
+	Options *common.GameOptions `json:"options,omitempty"`
 }
 
 func (server *GameServer) viewGame(ctx *RequestContext, req *ViewGameRequest) (resp *ViewGameResponse, err error) {
-	stmt, err := server.db.Prepare("SELECT name,owner_user_id,min_players,max_players,map_name,started,finished,game_state,active_player_id,invite_only FROM games WHERE id=?")
+	err = server.assertCanViewGame(ctx, req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := server.db.Prepare("SELECT name,owner_user_id,min_players,max_players,map_name,started,finished,game_state,active_player_id,invite_only,move_time_limit_hours,final_scores,random_seed,random_seed_commitment,version,map_variants,game_options FROM games WHERE id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
@@ -809,7 +995,15 @@ func (server *GameServer) viewGame(ctx *RequestContext, req *ViewGameRequest) (r
 	var gameStateStr sql.NullString
 	var activePlayerStr sql.NullString
 	var inviteOnlyFlag int
-	err = row.Scan(&name, &ownerUserId, &minPlayers, &maxPlayers, &mapName, &startedFlag, &finishedFlag, &gameStateStr, &activePlayerStr, &inviteOnlyFlag)
+	var moveTimeLimitHours sql.NullInt64
+	var finalScoresStr sql.NullString
+	var randomSeed sql.NullString
+	var randomSeedCommitment sql.NullString
+	var version int
+	var mapVariantsStr sql.NullString
+	var gameOptionsStr sql.NullString
+	err = row.Scan(&name, &ownerUserId, &minPlayers, &maxPlayers, &mapName, &startedFlag, &finishedFlag, &gameStateStr, &activePlayerStr, &inviteOnlyFlag, &moveTimeLimitHours, &finalScoresStr,
+		&randomSeed, &randomSeedCommitment, &version, &mapVariantsStr, &gameOptionsStr)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
@@ -844,6 +1038,22 @@ func (server *GameServer) viewGame(ctx *RequestContext, req *ViewGameRequest) (r
 		}
 	}
 
+	var finalScores []*PlayerScore
+	if finalScoresStr.Valid {
+		err = json.Unmarshal([]byte(finalScoresStr.String), &finalScores)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse final scores: %v", err)
+		}
+	}
+	mapVariants, err := unmarshalMapVariants(mapVariantsStr)
+	if err != nil {
+		return nil, err
+	}
+	gameOptions, err := unmarshalGameOptions(gameOptionsStr)
+	if err != nil {
+		return nil, err
+	}
+
 	res := &ViewGameResponse{
 		Id:           req.GameId,
 		Name:         name,
@@ -857,6 +1067,39 @@ func (server *GameServer) viewGame(ctx *RequestContext, req *ViewGameRequest) (r
 		JoinedUsers:  joinedUsers,
 		GameState:    gameState,
 		InviteOnly:   inviteOnlyFlag != 0,
+
+		MoveTimeLimitHours:   int(moveTimeLimitHours.Int64),
+		FinalScores:          finalScores,
+		RandomSeedCommitment: randomSeedCommitment.String,
+		Version:              version,
+		MapVariants:          mapVariants,
+		Options:              gameOptions,
+	}
+	// Revealing the seed any earlier would let players predict the rest of the game
+	if finishedFlag != 0 {
+		res.RandomSeed = randomSeed.String
+	}
+	hideSealedBids(gameState, ctx.User.Id)
+
+	custom, err := server.getCustomMap(mapName)
+	if err != nil {
+		return nil, err
+	}
+	if custom != nil {
+		res.CustomMap = &CustomMapData{Name: custom.name, Map: json.RawMessage(custom.data)}
+	}
+
+	res.PendingRollback, err = server.getPendingRollback(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+
+	if startedFlag != 0 && finishedFlag == 0 && res.MoveTimeLimitHours > 0 {
+		lastMoveTime, err := server.getLastMoveTime(req.GameId)
+		if err != nil {
+			return nil, err
+		}
+		res.MoveDeadline = lastMoveTime + res.MoveTimeLimitHours*60*60
 	}
 
 	return res, nil
@@ -904,11 +1147,17 @@ func (server *GameServer) listGames(ctx *RequestContext, req *ListGamesRequest)
 }
 
 type GameLogEntry struct {
+	// Orders the entries within a game, starting from 1
+	Seq         int    `json:"seq"`
 	Timestamp   int    `json:"timestamp"`
 	UserId      string `json:"userId"`
 	Action      string `json:"action"`
 	Description string `json:"description"`
 	Reversible  bool   `json:"reversible"`
+	// Every random value drawn during the move, in order. Not set for moves logged before draws were recorded.
+	RandomValues []int `json:"randomValues,omitempty"`
+	// The bound each random value was drawn with, i.e. RandomValues[i] is less than RandomBounds[i]
+	RandomBounds []int `json:"randomBounds,omitempty"`
 }
 
 type GetGameLogsRequest struct {
@@ -918,8 +1167,35 @@ type GetGameLogsResponse struct {
 	Logs []*GameLogEntry `json:"logs"`
 }
 
+// hasSecretBids checks whether a game is still being played with a sealed-bid auction, so the amounts in its logged bids
+// can't be shown to other players
+func (server *GameServer) hasSecretBids(gameId string) (bool, error) {
+	var finishedFlag int
+	var gameOptionsStr sql.NullString
+	err := server.db.QueryRow("SELECT finished,game_options FROM games WHERE id=?", gameId).Scan(&finishedFlag, &gameOptionsStr)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	options, err := unmarshalGameOptions(gameOptionsStr)
+	if err != nil {
+		return false, err
+	}
+	return finishedFlag == 0 && options.GetAuctionType() == common.SEALED_BID_AUCTION_TYPE, nil
+}
+
 func (server *GameServer) getGameLogs(ctx *RequestContext, req *GetGameLogsRequest) (resp *GetGameLogsResponse, err error) {
-	stmt, err := server.db.Prepare("SELECT timestamp,user_id,action,description,reversible FROM game_log WHERE game_id=? ORDER BY timestamp ASC")
+	err = server.assertCanViewGame(ctx, req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	secretBids, err := server.hasSecretBids(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := server.db.Prepare("SELECT seq,timestamp,user_id,action,description,reversible,random_values,random_bounds FROM game_log WHERE game_id=? ORDER BY seq ASC")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare query: %v", err)
 	}
@@ -932,22 +1208,43 @@ func (server *GameServer) getGameLogs(ctx *RequestContext, req *GetGameLogsReque
 
 	var entries []*GameLogEntry
 	for rows.Next() {
+		var seq int
 		var timestamp int
 		var userId string
 		var action sql.NullString
 		var description string
 		var reversibleFlag int
-		err = rows.Scan(&timestamp, &userId, &action, &description, &reversibleFlag)
+		var randomValuesStr sql.NullString
+		var randomBoundsStr sql.NullString
+		err = rows.Scan(&seq, &timestamp, &userId, &action, &description, &reversibleFlag, &randomValuesStr, &randomBoundsStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
 		}
-		entries = append(entries, &GameLogEntry{
-			Timestamp:   timestamp,
-			UserId:      userId,
-			Action:      action.String,
-			Description: description,
-			Reversible:  reversibleFlag != 0,
-		})
+		randomValues, err := unmarshalRandomValues(randomValuesStr)
+		if err != nil {
+			return nil, err
+		}
+		randomBounds, err := unmarshalRandomValues(randomBoundsStr)
+		if err != nil {
+			return nil, err
+		}
+		entry := &GameLogEntry{
+			Seq:          seq,
+			Timestamp:    timestamp,
+			UserId:       userId,
+			Action:       action.String,
+			Description:  description,
+			Reversible:   reversibleFlag != 0,
+			RandomValues: randomValues,
+			RandomBounds: randomBounds,
+		}
+		if secretBids {
+			entry, err = hideSealedBidLogAction(entry, ctx.User.Id)
+			if err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
 	}
 
 	return &GetGameLogsResponse{
@@ -1184,11 +1481,13 @@ func (server *GameServer) setMyProfile(ctx *RequestContext, req *SetMyProfileReq
 
 type GetGameChatRequest struct {
 	GameId string `json:"gameId"`
-	// Get all messages sent strictly after this time, in unix epoch seconds
+	// Get all messages after the one with this sequence number
 	After int `json:"after"`
 }
 
 type GameChatMessage struct {
+	// Orders the messages within a game, starting from 1
+	Seq       int    `json:"seq"`
 	UserId    string `json:"userId"`
 	Timestamp int    `json:"timestamp"`
 	Message   string `json:"message"`
@@ -1202,8 +1501,12 @@ func (server *GameServer) getGameChat(ctx *RequestContext, req *GetGameChatReque
 	if req.GameId == "" {
 		return nil, &api.HttpError{"missing gameId parameter", http.StatusBadRequest}
 	}
+	err = server.assertCanViewGame(ctx, req.GameId)
+	if err != nil {
+		return nil, err
+	}
 
-	stmt, err := server.db.Prepare("SELECT timestamp,user_id,message FROM game_chat WHERE game_id=? AND timestamp > ? ORDER BY timestamp ASC")
+	stmt, err := server.db.Prepare("SELECT seq,timestamp,user_id,message FROM game_chat WHERE game_id=? AND seq > ? ORDER BY seq ASC")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %v", err)
 	}
@@ -1216,14 +1519,16 @@ func (server *GameServer) getGameChat(ctx *RequestContext, req *GetGameChatReque
 
 	var messages []*GameChatMessage
 	for rows.Next() {
+		var seq int
 		var timestamp int
 		var userId string
 		var message string
-		err = rows.Scan(&timestamp, &userId, &message)
+		err = rows.Scan(&seq, &timestamp, &userId, &message)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
 		}
 		messages = append(messages, &GameChatMessage{
+			Seq:       seq,
 			Timestamp: timestamp,
 			UserId:    userId,
 			Message:   message,
@@ -1258,23 +1563,64 @@ func (server *GameServer) sendGameChat(ctx *RequestContext, req *SendGameChatReq
 		return nil, &api.HttpError{"can only send messages in games you are in", http.StatusBadRequest}
 	}
 
-	stmt, err := server.db.Prepare("INSERT INTO game_chat (game_id, timestamp, user_id, message) VALUES(?, ?, ?, ?)")
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %v", err)
+	var chatMessage *GameChatMessage
+	for attempt := 1; ; attempt++ {
+		chatMessage, err = server.insertGameChat(req.GameId, ctx.User.Id, req.Message)
+		if err == nil {
+			break
+		}
+		// Another message was sent at the same time and took this sequence number, so try again with the next one
+		if !isDuplicateKeyError(err) || attempt >= maxChatInsertAttempts {
+			return nil, err
+		}
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(req.GameId, time.Now().Unix(), ctx.User.Id, req.Message)
+
+	server.publishGameEvent(req.GameId, &GameEvent{
+		Type: GAME_EVENT_CHAT,
+		Chat: chatMessage,
+	})
+
+	return &SendGameChatResponse{}, nil
+}
+
+// How many times to try adding a chat message when other messages keep taking its sequence number
+const maxChatInsertAttempts = 5
+
+// insertGameChat adds a message to the game's chat with the next sequence number
+func (server *GameServer) insertGameChat(gameId string, userId string, message string) (*GameChatMessage, error) {
+	tx, err := server.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to add row: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	return &SendGameChatResponse{}, nil
+	seq, err := nextSeq(tx, "game_chat", gameId)
+	if err != nil {
+		return nil, err
+	}
+	chatMessage := &GameChatMessage{
+		Seq:       seq,
+		UserId:    userId,
+		Timestamp: int(time.Now().Unix()),
+		Message:   message,
+	}
+	_, err = tx.Exec("INSERT INTO game_chat (game_id, seq, timestamp, user_id, message) VALUES(?, ?, ?, ?, ?)",
+		gameId, chatMessage.Seq, chatMessage.Timestamp, chatMessage.UserId, chatMessage.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add row: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return chatMessage, nil
 }
 
 type PollGameStatusRequest struct {
 	GameId string `json:"gameId"`
 }
 type PollGameStatusResponse struct {
+	// Sequence numbers of the latest game log entry and chat message
 	LastMove int `json:"lastMove"`
 	LastChat int `json:"lastChat"`
 }
@@ -1284,7 +1630,7 @@ func (server *GameServer) pollGameStatus(ctx *RequestContext, req *PollGameStatu
 		return nil, &api.HttpError{"missing gameId parameter", http.StatusBadRequest}
 	}
 
-	stmt, err := server.db.Prepare("SELECT MAX(timestamp) FROM game_log WHERE game_id=?")
+	stmt, err := server.db.Prepare("SELECT MAX(seq) FROM game_log WHERE game_id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %v", err)
 	}
@@ -1297,7 +1643,7 @@ func (server *GameServer) pollGameStatus(ctx *RequestContext, req *PollGameStatu
 		return nil, fmt.Errorf("failed to execute query: %v", err)
 	}
 
-	stmt, err = server.db.Prepare("SELECT MAX(timestamp) FROM game_chat WHERE game_id=?")
+	stmt, err = server.db.Prepare("SELECT MAX(seq) FROM game_chat WHERE game_id=?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %v", err)
 	}
@@ -1329,15 +1675,15 @@ func (server *GameServer) undoMove(ctx *RequestContext, req *UndoMoveRequest) (r
 	}
 	defer trx.Rollback()
 
-	var lastTimestamp int
+	var lastSeq int
 	var lastPlayer string
 	var lastWasReversible int
-	stmt, err := trx.Prepare("SELECT timestamp,user_id,reversible FROM game_log WHERE game_id=? ORDER BY timestamp DESC LIMIT 1")
+	stmt, err := trx.Prepare("SELECT seq,user_id,reversible FROM game_log WHERE game_id=? ORDER BY seq DESC LIMIT 1")
 	if err != nil {
 		return nil, err
 	}
 	row := stmt.QueryRow(req.GameId)
-	err = row.Scan(&lastTimestamp, &lastPlayer, &lastWasReversible)
+	err = row.Scan(&lastSeq, &lastPlayer, &lastWasReversible)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
@@ -1352,18 +1698,18 @@ func (server *GameServer) undoMove(ctx *RequestContext, req *UndoMoveRequest) (r
 	}
 
 	var priorState string
-	stmt, err = trx.Prepare("SELECT new_game_state FROM game_log WHERE game_id=? AND timestamp < ? ORDER BY TIMESTAMP DESC LIMIT 1")
+	stmt, err = trx.Prepare("SELECT new_game_state FROM game_log WHERE game_id=? AND seq < ? ORDER BY seq DESC LIMIT 1")
 	if err != nil {
 		return nil, err
 	}
-	row = stmt.QueryRow(req.GameId, lastTimestamp)
+	row = stmt.QueryRow(req.GameId, lastSeq)
 	err = row.Scan(&priorState)
 	if err != nil {
 		return nil, err
 	}
 
 	// Apply the reverted game state
-	stmt, err = trx.Prepare("UPDATE games SET active_player_id=?,game_state=? WHERE id=?")
+	stmt, err = trx.Prepare("UPDATE games SET active_player_id=?,game_state=?,version=version+1 WHERE id=?")
 	if err != nil {
 		return nil, err
 	}
@@ -1373,11 +1719,19 @@ func (server *GameServer) undoMove(ctx *RequestContext, req *UndoMoveRequest) (r
 	}
 
 	// Add a log of the undo action
-	stmt, err = trx.Prepare("INSERT INTO game_log (game_id,timestamp,user_id,action,description,new_active_player,new_game_state,reversible) VALUES(?, ?, ?, 'undo', ?, ?, ?, 0)")
+	stmt, err = trx.Prepare("INSERT INTO game_log (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible) VALUES(?, ?, ?, ?, 'undo', ?, ?, ?, 0)")
 	if err != nil {
 		return nil, err
 	}
-	_, err = stmt.Exec(req.GameId, time.Now().Unix(), lastPlayer, fmt.Sprintf("%s undid their previous action", ctx.User.Nickname),
+	logEntry := &GameLogEntry{
+		Seq:         lastSeq + 1,
+		Timestamp:   int(time.Now().Unix()),
+		UserId:      lastPlayer,
+		Action:      "undo",
+		Description: fmt.Sprintf("%s undid their previous action", ctx.User.Nickname),
+		Reversible:  false,
+	}
+	_, err = stmt.Exec(req.GameId, logEntry.Seq, logEntry.Timestamp, logEntry.UserId, logEntry.Description,
 		lastPlayer, priorState)
 	if err != nil {
 		return nil, err
@@ -1387,5 +1741,11 @@ func (server *GameServer) undoMove(ctx *RequestContext, req *UndoMoveRequest) (r
 		return nil, fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
+	server.publishGameEvent(req.GameId, &GameEvent{
+		Type:         GAME_EVENT_MOVE,
+		Log:          logEntry,
+		ActivePlayer: lastPlayer,
+	})
+
 	return &UndoMoveResponse{}, nil
 }
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/bots"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+	"github.com/JackOfMostTrades/eot/backend/tiles"
+)
+
+type GetLegalMovesRequest struct {
+	GameId string `json:"gameId"`
+}
+type GetLegalMovesResponse struct {
+	ActivePlayer string                    `json:"activePlayer"`
+	Moves        []*api.ConfirmMoveRequest `json:"moves"`
+}
+
+func (server *GameServer) getLegalMoves(ctx *RequestContext, req *GetLegalMovesRequest) (resp *GetLegalMovesResponse, err error) {
+	err = server.assertCanViewGame(ctx, req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	handler, err := server.newConfirmMoveHandlerForGame(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+
+	moves, err := handler.LegalMoves()
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetLegalMovesResponse{
+		ActivePlayer: handler.activePlayer,
+		Moves:        moves,
+	}, nil
+}
+
+// LegalMoves returns every move the active player could legally submit in the current game phase. For the
+// build phase this is the empty build plus every legal single-hex build step; multi-step builds are left to the
+// caller to assemble. Candidates in the other phases are checked with the same validation the action handlers run,
+// so the game state is never copied.
+func (handler *confirmMoveHandler) LegalMoves() ([]*api.ConfirmMoveRequest, error) {
+	var candidates []*api.ConfirmMoveRequest
+	var check func(req *api.ConfirmMoveRequest) error
+	switch handler.gameState.GamePhase {
+	case common.SHARES_GAME_PHASE:
+		candidates = handler.sharesMoveCandidates()
+		check = func(req *api.ConfirmMoveRequest) error {
+			return handler.checkSharesAction(req.SharesAction)
+		}
+	case common.AUCTION_GAME_PHASE:
+		candidates = handler.bidMoveCandidates()
+		check = func(req *api.ConfirmMoveRequest) error {
+			return handler.checkBidAction(req.BidAction)
+		}
+	case common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE:
+		candidates = handler.chooseMoveCandidates()
+		check = func(req *api.ConfirmMoveRequest) error {
+			return handler.checkChooseAction(req.ChooseAction)
+		}
+	case common.BUILDING_GAME_PHASE:
+		steps, err := handler.LegalBuildSteps(nil)
+		if err != nil {
+			return nil, err
+		}
+		// Building nothing is always an option. The steps have already been checked, so they are returned directly.
+		moves := []*api.ConfirmMoveRequest{buildMove(nil)}
+		for _, step := range steps {
+			moves = append(moves, buildMove([]*api.BuildStep{step}))
+		}
+		for _, move := range moves {
+			move.GameId = handler.gameId
+		}
+		return moves, nil
+	case common.MOVING_GOODS_GAME_PHASE:
+		deliveryGraph := computeDeliveryGraph(handler.gameState, handler.gameMap)
+		candidates = handler.moveGoodsMoveCandidates(deliveryGraph)
+		check = func(req *api.ConfirmMoveRequest) error {
+			return handler.checkMoveGoodsAction(deliveryGraph, req.MoveGoodsAction)
+		}
+	case common.GOODS_GROWTH_GAME_PHASE:
+		// Production placements are checked directly; trying them out would roll the goods growth dice.
+		var moves []*api.ConfirmMoveRequest
+		for _, destinations := range handler.productionPlacements() {
+			moves = append(moves, &api.ConfirmMoveRequest{
+				GameId:             handler.gameId,
+				ActionName:         api.ProduceGoodsActionName,
+				ProduceGoodsAction: &api.ProduceGoodsAction{Destinations: destinations},
+			})
+		}
+		return moves, nil
+	default:
+		return nil, fmt.Errorf("unhandled game phase: %d", handler.gameState.GamePhase)
+	}
+
+	var moves []*api.ConfirmMoveRequest
+	for _, candidate := range candidates {
+		candidate.GameId = handler.gameId
+		// A move is legal if the rules engine accepts it; anything other than an invalid move error is returned
+		err := check(candidate)
+		if err != nil {
+			if isInvalidMove(err) {
+				continue
+			}
+			return nil, err
+		}
+		moves = append(moves, candidate)
+	}
+	return moves, nil
+}
+
+// isInvalidMove checks whether an error is the rules engine rejecting a move, rather than something going wrong
+func isInvalidMove(err error) bool {
+	var httpError *api.HttpError
+	if errors.As(err, &httpError) {
+		return httpError.Code == http.StatusBadRequest || httpError.Code == http.StatusPreconditionFailed
+	}
+	var invalidMove *invalidMoveError
+	return errors.As(err, &invalidMove)
+}
+
+func (handler *confirmMoveHandler) sharesMoveCandidates() []*api.ConfirmMoveRequest {
+	var candidates []*api.ConfirmMoveRequest
+	remaining := maps.SharesLimit(handler.gameMap, handler.gameState) - handler.gameState.PlayerShares[handler.activePlayer]
+	for amount := 0; amount <= remaining; amount++ {
+		candidates = append(candidates, &api.ConfirmMoveRequest{
+			ActionName:   api.SharesActionName,
+			SharesAction: &api.SharesAction{Amount: amount},
+		})
+	}
+	return candidates
+}
+
+func (handler *confirmMoveHandler) bidMoveCandidates() []*api.ConfirmMoveRequest {
+	var candidates []*api.ConfirmMoveRequest
+	for _, amount := range maps.AuctionPhase(handler.gameMap, handler.gameState).BidAmounts(handler) {
+		candidates = append(candidates, &api.ConfirmMoveRequest{
+			ActionName: api.BidActionName,
+			BidAction:  &api.BidAction{Amount: amount},
+		})
+	}
+	return candidates
+}
+
+func (handler *confirmMoveHandler) chooseMoveCandidates() []*api.ConfirmMoveRequest {
+	var candidates []*api.ConfirmMoveRequest
+	for _, action := range common.ALL_SPECIAL_ACTIONS {
+		candidates = append(candidates, &api.ConfirmMoveRequest{
+			ActionName:   api.ChooseActionName,
+			ChooseAction: &api.ChooseAction{Action: action},
+		})
+	}
+	return candidates
+}
+
+func buildMove(steps []*api.BuildStep) *api.ConfirmMoveRequest {
+	return &api.ConfirmMoveRequest{
+		ActionName:  api.BuildActionName,
+		BuildAction: &api.BuildAction{Steps: steps},
+	}
+}
+
+// LegalBuildSteps returns the single build steps that can be added to the given steps as part of the same build
+// action. With no prior steps, this is every legal single-step build.
+func (handler *confirmMoveHandler) LegalBuildSteps(steps []*api.BuildStep) ([]*api.BuildStep, error) {
+	previews, err := handler.PreviewBuildSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+	legalSteps := make([]*api.BuildStep, len(previews))
+	for i, preview := range previews {
+		legalSteps[i] = preview.Step
+	}
+	return legalSteps, nil
+}
+
+// PreviewBuildSteps implements bots.Rules. Each step is tried out on its own cheap fork, whose result is returned along
+// with the step.
+func (handler *confirmMoveHandler) PreviewBuildSteps(steps []*api.BuildStep) ([]*bots.BuildStepPreview, error) {
+	if handler.gameState.GamePhase != common.BUILDING_GAME_PHASE {
+		return nil, &api.HttpError{fmt.Sprintf("invalid action for current phase %d", handler.gameState.GamePhase), http.StatusPreconditionFailed}
+	}
+
+	// Generate candidates against the state after the prior steps, so that steps extending them are considered
+	base := handler
+	if len(steps) > 0 {
+		base = handler.forkForBuild()
+		err := base.performBuildAction(&api.BuildAction{Steps: steps})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var previews []*bots.BuildStepPreview
+	for _, candidate := range base.buildStepCandidates() {
+		if countsForHexPlacement(candidate) && slices.ContainsFunc(steps, func(step *api.BuildStep) bool {
+			return countsForHexPlacement(step) && step.Hex.Equals(candidate.Hex)
+		}) {
+			continue
+		}
+
+		trial := handler.forkForBuild()
+		// Only the build itself matters here, so skip advancing to the next player. Map-specific post-build checks
+		// report violations as plain errors, so any error means the build is not allowed.
+		err := trial.performBuildAction(&api.BuildAction{Steps: append(slices.Clone(steps), candidate)})
+		if err == nil {
+			previews = append(previews, &bots.BuildStepPreview{
+				Step:      candidate,
+				GameState: trial.gameState,
+				Cost:      trial.buildCost,
+			})
+		}
+	}
+	return previews, nil
+}
+
+// forkForBuild is a cheaper fork for trying out builds. Building only changes the links, urbanizations and cash, so
+// only those are copied and the rest of the game state is shared with this handler.
+func (handler *confirmMoveHandler) forkForBuild() *confirmMoveHandler {
+	gameState := *handler.gameState
+	gameState.PlayerCash = make(map[string]int, len(handler.gameState.PlayerCash))
+	for playerId, cash := range handler.gameState.PlayerCash {
+		gameState.PlayerCash[playerId] = cash
+	}
+	gameState.Urbanizations = slices.Clone(handler.gameState.Urbanizations)
+	gameState.Links = make([]*common.Link, len(handler.gameState.Links))
+	for i, link := range handler.gameState.Links {
+		linkCopy := *link
+		linkCopy.Steps = slices.Clone(link.Steps)
+		gameState.Links[i] = &linkCopy
+	}
+	return &confirmMoveHandler{
+		gameId:         handler.gameId,
+		gameMap:        handler.gameMap,
+		gameState:      &gameState,
+		activePlayer:   handler.activePlayer,
+		playerIdToNick: handler.playerIdToNick,
+		randProvider:   &common.CryptoRandProvider{},
+		reversible:     true,
+	}
+}
+
+func (handler *confirmMoveHandler) buildStepCandidates() []*api.BuildStep {
+	gameState := handler.gameState
+	gameMap := handler.gameMap
+	mapState := newMapState(gameMap, gameState)
+
+	var candidates []*api.BuildStep
+	placements := trackPlacementCandidates()
+
+	for hex, ts := range mapState.GetAllTileState() {
+		hexType := gameMap.GetHexType(hex)
+
+		if hexType == maps.TOWN_HEX_TYPE && !ts.isCity {
+			if gameState.PlayerActions[handler.activePlayer] == common.URBANIZATION_SPECIAL_ACTION {
+				for city := 0; city < 8; city++ {
+					urbanization := city
+					candidates = append(candidates, &api.BuildStep{Hex: hex, Urbanization: &urbanization})
+				}
+			}
+
+			for _, track := range townPlacementCandidates(ts) {
+				candidates = append(candidates, &api.BuildStep{
+					Hex:           hex,
+					TownPlacement: &api.TownPlacement{Track: track},
+				})
+			}
+		}
+
+		if hexType == maps.PLAINS_HEX_TYPE || hexType == maps.RIVER_HEX_TYPE ||
+			hexType == maps.HILLS_HEX_TYPE || hexType == maps.MOUNTAIN_HEX_TYPE {
+			var existingRoutes [][2]common.Direction
+			for _, route := range ts.routes {
+				existingRoutes = append(existingRoutes, [2]common.Direction{route.Left, route.Right})
+			}
+			for _, placement := range placements {
+				// Re-laying the exact same track would cost money without changing anything
+				if len(existingRoutes) > 0 && sameRoutes(existingRoutes, placement.routes) {
+					continue
+				}
+				// New track must extend existing track or start from a city or town, so skip placements that
+				// cannot possibly connect to anything before trying them out.
+				if len(ts.routes) == 0 {
+					connects := false
+					for _, route := range placement.routes {
+						if canConnectInDirection(mapState, hex, route[0]) || canConnectInDirection(mapState, hex, route[1]) {
+							connects = true
+							break
+						}
+					}
+					if !connects {
+						continue
+					}
+				}
+				candidates = append(candidates, &api.BuildStep{
+					Hex: hex,
+					TrackPlacement: &api.TrackPlacement{
+						Tile:     placement.tile,
+						Rotation: placement.rotation,
+					},
+				})
+			}
+		}
+
+		for _, direction := range common.ALL_DIRECTIONS {
+			if gameMap.GetTeleportLinkBuildCost(gameState, handler.activePlayer, hex, direction) > 0 {
+				candidates = append(candidates, &api.BuildStep{
+					Hex:                   hex,
+					TeleportLinkPlacement: &api.TeleportLinkPlacement{Track: direction},
+				})
+			}
+		}
+	}
+
+	return candidates
+}
+
+// canConnectInDirection checks whether track leaving the given hex in the given direction would run into a city, a
+// town, or existing track pointing back at this hex.
+func canConnectInDirection(mapState *MapState, hex common.Coordinate, direction common.Direction) bool {
+	neighbor := mapState.GetTileState(common.ApplyDirection(hex, direction))
+	if neighbor == nil {
+		return false
+	}
+	if neighbor.isCity || neighbor.isTown {
+		return true
+	}
+	for _, route := range neighbor.routes {
+		if route.Left == direction.Opposite() || route.Right == direction.Opposite() {
+			return true
+		}
+	}
+	return false
+}
+
+// townPlacementCandidates returns every set of track directions (up to the limit of four) which keeps all the
+// existing track on the town and adds at least one new track.
+func townPlacementCandidates(ts *TileState) [][]common.Direction {
+	var existing []common.Direction
+	for _, route := range ts.routes {
+		existing = append(existing, route.Right)
+	}
+
+	var candidates [][]common.Direction
+	for mask := 1; mask < (1 << len(common.ALL_DIRECTIONS)); mask++ {
+		var track []common.Direction
+		for _, direction := range common.ALL_DIRECTIONS {
+			if mask&(1<<int(direction)) != 0 {
+				track = append(track, direction)
+			}
+		}
+		if len(track) > 4 || len(track) <= len(existing) {
+			continue
+		}
+		keepsExisting := true
+		for _, direction := range existing {
+			if slices.Index(track, direction) == -1 {
+				keepsExisting = false
+				break
+			}
+		}
+		if keepsExisting {
+			candidates = append(candidates, track)
+		}
+	}
+	return candidates
+}
+
+type trackPlacementCandidate struct {
+	tile     tiles.TrackTile
+	rotation int
+	routes   [][2]common.Direction
+}
+
+// trackPlacementCandidates returns every distinct tile and rotation combination, skipping rotations that result in
+// exactly the same routes as an earlier rotation of the same tile.
+func trackPlacementCandidates() []*trackPlacementCandidate {
+	var placements []*trackPlacementCandidate
+	for _, tile := range tiles.AllTrackTiles {
+		var seen [][][2]common.Direction
+		for rotation := 0; rotation < 6; rotation++ {
+			var rotatedRoutes [][2]common.Direction
+			for _, route := range tiles.GetRoutesForTile(tile) {
+				rotatedRoutes = append(rotatedRoutes, [2]common.Direction{
+					common.Direction((int(route[0]) + rotation) % 6),
+					common.Direction((int(route[1]) + rotation) % 6),
+				})
+			}
+
+			isDuplicate := false
+			for _, seenRoutes := range seen {
+				if sameRoutes(seenRoutes, rotatedRoutes) {
+					isDuplicate = true
+					break
+				}
+			}
+			if isDuplicate {
+				continue
+			}
+			seen = append(seen, rotatedRoutes)
+			placements = append(placements, &trackPlacementCandidate{tile: tile, rotation: rotation, routes: rotatedRoutes})
+		}
+	}
+	return placements
+}
+
+func sameRoutes(a [][2]common.Direction, b [][2]common.Direction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, routeA := range a {
+		found := false
+		for _, routeB := range b {
+			if (routeA[0] == routeB[0] && routeA[1] == routeB[1]) ||
+				(routeA[0] == routeB[1] && routeA[1] == routeB[0]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (handler *confirmMoveHandler) moveGoodsMoveCandidates(deliveryGraph *DeliveryGraph) []*api.ConfirmMoveRequest {
+	gameState := handler.gameState
+
+	// Skipping the delivery and increasing loco are always candidates
+	candidates := []*api.ConfirmMoveRequest{
+		{
+			ActionName:      api.MoveGoodsActionName,
+			MoveGoodsAction: &api.MoveGoodsAction{Color: common.NONE_COLOR},
+		},
+		{
+			ActionName:      api.MoveGoodsActionName,
+			MoveGoodsAction: &api.MoveGoodsAction{Loco: true},
+		},
+	}
+
+	loco := gameState.PlayerLoco[handler.activePlayer]
+
+	var seenCubes []common.BoardCube
+	for _, cube := range gameState.Cubes {
+		if slices.Index(seenCubes, *cube) != -1 {
+			continue
+		}
+		seenCubes = append(seenCubes, *cube)
+
+		var walk func(loc common.Coordinate, path []common.Direction, seenCities []common.Coordinate)
+		walk = func(loc common.Coordinate, path []common.Direction, seenCities []common.Coordinate) {
+			if len(path) >= loco {
+				return
+			}
+			for _, direction := range common.ALL_DIRECTIONS {
+				link, ok := deliveryGraph.hexToDirectionToLink[loc][direction]
+				if !ok || slices.Index(seenCities, link.destination) != -1 {
+					continue
+				}
+				cityColor, ok := handler.deliveryLocationColor(link.destination)
+				if !ok {
+					continue
+				}
+				nextPath := append(slices.Clone(path), direction)
+				if cityColor == cube.Color || handler.gameMap.LocationCanAcceptCube(cube.Color, link.destination) {
+					candidates = append(candidates, &api.ConfirmMoveRequest{
+						ActionName: api.MoveGoodsActionName,
+						MoveGoodsAction: &api.MoveGoodsAction{
+							StartingLocation: cube.Hex,
+							Color:            cube.Color,
+							Path:             nextPath,
+						},
+					})
+				}
+				// Like the delivery itself, the walk can't go on past a city the cube has to stop at
+				if handler.locationBlocksCube(cube.Color, link.destination) {
+					continue
+				}
+				walk(link.destination, nextPath, append(slices.Clone(seenCities), link.destination))
+			}
+		}
+		walk(cube.Hex, nil, []common.Coordinate{cube.Hex})
+	}
+
+	return candidates
+}
+
+// productionPlacements returns every assignment of the drawn production cubes to distinct empty spots on the goods
+// growth chart. Assignments that only swap two cubes of the same color are returned once.
+func (handler *confirmMoveHandler) productionPlacements() [][]common.Coordinate {
+	gameState := handler.gameState
+
+	var emptySpots []common.Coordinate
+	for x, col := range gameState.GoodsGrowth {
+		for y, color := range col {
+			if color == common.NONE_COLOR {
+				emptySpots = append(emptySpots, common.Coordinate{X: x, Y: y})
+			}
+		}
+	}
+
+	var placements [][]common.Coordinate
+	var place func(chosen []int)
+	place = func(chosen []int) {
+		idx := len(chosen)
+		if idx == len(gameState.ProductionCubes) {
+			var destinations []common.Coordinate
+			for _, spot := range chosen {
+				destinations = append(destinations, emptySpots[spot])
+			}
+			placements = append(placements, destinations)
+			return
+		}
+		for spot := range emptySpots {
+			if slices.Index(chosen, spot) != -1 {
+				continue
+			}
+			if idx > 0 && gameState.ProductionCubes[idx] == gameState.ProductionCubes[idx-1] && spot < chosen[idx-1] {
+				continue
+			}
+			place(append(slices.Clone(chosen), spot))
+		}
+	}
+	if len(gameState.ProductionCubes) > 0 {
+		place(nil)
+	}
+	return placements
+}
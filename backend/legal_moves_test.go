@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLegalMovesShares(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+
+	_, err = h.getLegalMoves(t, player1, &GetLegalMovesRequest{GameId: createRes.Id})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	res, err := h.getLegalMoves(t, player1, &GetLegalMovesRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, viewRes.ActivePlayer, res.ActivePlayer)
+
+	activeShares := viewRes.GameState.PlayerShares[viewRes.ActivePlayer]
+	sharesLimit := h.gameServer.gameMaps["rust_belt"].GetSharesLimit()
+	require.Equal(t, sharesLimit-activeShares+1, len(res.Moves))
+	for i, move := range res.Moves {
+		assert.Equal(t, createRes.Id, move.GameId)
+		assert.Equal(t, api.SharesActionName, move.ActionName)
+		assert.Equal(t, i, move.SharesAction.Amount)
+	}
+}
+
+func TestLegalMovesMoveGoods(t *testing.T) {
+	playerId := "player1"
+	playerTwo := "player2"
+	gameMap := &testMap{
+		hexes: [][]maps.HexType{
+			{maps.CITY_HEX_TYPE, maps.CITY_HEX_TYPE},
+			{maps.PLAINS_HEX_TYPE, maps.PLAINS_HEX_TYPE},
+		},
+		cityColor: [][]common.Color{
+			{common.PURPLE, common.BLUE},
+			{common.NONE_COLOR, common.NONE_COLOR},
+		},
+	}
+	gameState := &common.GameState{
+		PlayerOrder: []string{playerId, playerTwo},
+		GamePhase:   common.MOVING_GOODS_GAME_PHASE,
+		CubeBag:     make(map[common.Color]int),
+		Links: []*common.Link{
+			{
+				SourceHex: common.Coordinate{X: 0, Y: 0},
+				Steps:     []common.Direction{common.SOUTH_EAST, common.NORTH_EAST},
+				Complete:  true,
+				Owner:     playerId,
+			},
+		},
+		Cubes: []*common.BoardCube{
+			{
+				Color: common.BLUE,
+				Hex:   common.Coordinate{X: 0, Y: 0},
+			},
+			{
+				Color: common.YELLOW,
+				Hex:   common.Coordinate{X: 0, Y: 0},
+			},
+		},
+		PlayerLoco:        map[string]int{playerId: 1, playerTwo: 1},
+		PlayerIncome:      map[string]int{playerId: 0, playerTwo: 0},
+		PlayerHasDoneLoco: map[string]bool{},
+	}
+
+	handler := &confirmMoveHandler{
+		gameMap:        gameMap,
+		gameState:      gameState,
+		activePlayer:   playerId,
+		playerIdToNick: map[string]string{},
+	}
+	moves, err := handler.LegalMoves()
+	require.NoError(t, err)
+
+	var deliveries []*api.MoveGoodsAction
+	for _, move := range moves {
+		assert.Equal(t, api.MoveGoodsActionName, move.ActionName)
+		if len(move.MoveGoodsAction.Path) > 0 {
+			deliveries = append(deliveries, move.MoveGoodsAction)
+		}
+	}
+	// Only the blue cube can be delivered, and only to the adjacent blue city
+	require.Equal(t, 1, len(deliveries))
+	assert.Equal(t, common.BLUE, deliveries[0].Color)
+	assert.Equal(t, []common.Direction{common.SOUTH_EAST}, deliveries[0].Path)
+
+	// Enumerating moves must not change the actual game state
+	assert.Equal(t, 2, len(gameState.Cubes))
+	assert.Equal(t, 0, gameState.PlayerIncome[playerId])
+}
+
+func TestInviteOnlyGameAccess(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	outsider := h.createUser(t)
+	admin := h.createUser(t)
+	h.gameServer.config.AdminUserIds = []string{admin}
+
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		InviteOnly: true,
+	})
+	require.NoError(t, err)
+	gameId := createRes.Id
+
+	// Anyone with the link can see the game before it starts, so that they can join it
+	_, err = h.viewGame(t, player2, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: gameId})
+	require.NoError(t, err)
+
+	for _, userId := range []string{player1, player2, admin} {
+		_, err = h.viewGame(t, userId, &ViewGameRequest{GameId: gameId})
+		require.NoError(t, err)
+		_, err = h.getLegalMoves(t, userId, &GetLegalMovesRequest{GameId: gameId})
+		require.NoError(t, err)
+	}
+
+	var httpError *api.HttpError
+	_, err = h.viewGame(t, outsider, &ViewGameRequest{GameId: gameId})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusForbidden, httpError.Code)
+	_, err = h.getLegalMoves(t, outsider, &GetLegalMovesRequest{GameId: gameId})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusForbidden, httpError.Code)
+	_, err = h.getGameLogs(t, outsider, &GetGameLogsRequest{GameId: gameId})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusForbidden, httpError.Code)
+	_, err = h.getGameChat(t, outsider, &GetGameChatRequest{GameId: gameId})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusForbidden, httpError.Code)
+}
+
+func TestMoveGoodsCandidatesStopAtBlockingCities(t *testing.T) {
+	playerId := "player1"
+	gameMap := &testMap{
+		hexes: [][]maps.HexType{
+			{maps.CITY_HEX_TYPE},
+			{maps.PLAINS_HEX_TYPE},
+			{maps.CITY_HEX_TYPE},
+			{maps.PLAINS_HEX_TYPE},
+			{maps.CITY_HEX_TYPE},
+		},
+		cityColor: [][]common.Color{
+			{common.BLUE},
+			{common.NONE_COLOR},
+			{common.RED},
+			{common.NONE_COLOR},
+			{common.RED},
+		},
+	}
+	gameState := &common.GameState{
+		PlayerOrder: []string{playerId},
+		GamePhase:   common.MOVING_GOODS_GAME_PHASE,
+		Links: []*common.Link{
+			{SourceHex: common.Coordinate{X: 0, Y: 0}, Steps: []common.Direction{common.SOUTH}, Complete: true, Owner: playerId},
+			{SourceHex: common.Coordinate{X: 0, Y: 2}, Steps: []common.Direction{common.SOUTH}, Complete: true, Owner: playerId},
+		},
+		Cubes:      []*common.BoardCube{{Color: common.RED, Hex: common.Coordinate{X: 0, Y: 0}}},
+		PlayerLoco: map[string]int{playerId: 2},
+	}
+	handler := &confirmMoveHandler{
+		gameMap:      gameMap,
+		gameState:    gameState,
+		activePlayer: playerId,
+	}
+
+	// The red cube has to stop at the first red city, so the walk doesn't go on to the second
+	var paths [][]common.Direction
+	for _, candidate := range handler.moveGoodsMoveCandidates(computeDeliveryGraph(gameState, gameMap)) {
+		if len(candidate.MoveGoodsAction.Path) > 0 {
+			paths = append(paths, candidate.MoveGoodsAction.Path)
+		}
+	}
+	assert.Equal(t, [][]common.Direction{{common.SOUTH}}, paths)
+}
+
+func TestBidMoveCandidates(t *testing.T) {
+	playerId := "player1"
+	otherPlayer := "player2"
+	for _, tc := range []struct {
+		auctionType     common.AuctionType
+		expectedAmounts []int
+	}{
+		{common.STANDARD_AUCTION_TYPE, []int{-1, 0, 4, 5}},
+		{common.PAY_FULL_BID_AUCTION_TYPE, []int{-1, 0, 4, 5}},
+		// Passing and turn order pass are the same sealed bid, so it's only listed once
+		{common.SEALED_BID_AUCTION_TYPE, []int{-1, 1, 2, 3, 4, 5}},
+		{common.FIXED_ORDER_AUCTION_TYPE, nil},
+	} {
+		t.Run(string(tc.auctionType), func(t *testing.T) {
+			handler := &confirmMoveHandler{
+				gameMap: &testMap{},
+				gameState: &common.GameState{
+					PlayerOrder:  []string{playerId, otherPlayer},
+					PlayerCash:   map[string]int{playerId: 5, otherPlayer: 10},
+					AuctionState: map[string]int{otherPlayer: 3},
+					Options:      &common.GameOptions{AuctionType: tc.auctionType},
+				},
+				activePlayer: playerId,
+			}
+			var amounts []int
+			for _, candidate := range handler.bidMoveCandidates() {
+				amounts = append(amounts, candidate.BidAction.Amount)
+			}
+			assert.Equal(t, tc.expectedAmounts, amounts)
+		})
+	}
+}
+
+func TestIsInvalidMove(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"bad request", &api.HttpError{"invalid path", http.StatusBadRequest}, true},
+		{"wrong phase", &api.HttpError{"invalid action for current phase", http.StatusPreconditionFailed}, true},
+		{"invalid build", invalidMoveErr("cannot build here"), true},
+		{"version conflict", &api.HttpError{"game has changed", http.StatusConflict}, false},
+		{"server error", &api.HttpError{"failed", http.StatusInternalServerError}, false},
+		{"plain error", fmt.Errorf("failed to execute query"), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isInvalidMove(tc.err))
+		})
+	}
+}
+
+func TestPreviewBuildSteps(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, player1 := h.startTwoPlayerGame(t, false)
+	for i := 0; i < 20; i++ {
+		viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+		require.NoError(t, err)
+		if viewRes.GameState.GamePhase == common.BUILDING_GAME_PHASE {
+			break
+		}
+		err = h.gameServer.timeoutActivePlayer(gameId)
+		require.NoError(t, err)
+	}
+	handler, err := h.gameServer.newConfirmMoveHandlerForGame(gameId)
+	require.NoError(t, err)
+	require.Equal(t, common.BUILDING_GAME_PHASE, handler.gameState.GamePhase)
+	cashBefore := handler.gameState.PlayerCash[handler.activePlayer]
+	linksBefore := len(handler.gameState.Links)
+
+	previews, err := handler.PreviewBuildSteps(nil)
+	require.NoError(t, err)
+	legalSteps, err := handler.LegalBuildSteps(nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, previews)
+	require.Len(t, previews, len(legalSteps))
+
+	// Each preview matches the full preview of building that step
+	for i, preview := range previews[:min(len(previews), 10)] {
+		assert.Equal(t, legalSteps[i], preview.Step)
+		result, cost, err := handler.PreviewMove(buildMove([]*api.BuildStep{preview.Step}))
+		require.NoError(t, err)
+		assert.Equal(t, cost, preview.Cost)
+		assert.Equal(t, result.PlayerCash, preview.GameState.PlayerCash)
+		assert.Equal(t, result.Links, preview.GameState.Links)
+		assert.Equal(t, result.Urbanizations, preview.GameState.Urbanizations)
+	}
+
+	// Previewing doesn't change the game
+	assert.Equal(t, cashBefore, handler.gameState.PlayerCash[handler.activePlayer])
+	assert.Len(t, handler.gameState.Links, linksBefore)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+type MapInfo struct {
+	// The map name to create a game with
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	// Set for maps uploaded by users
+	Custom bool `json:"custom,omitempty"`
+	// The player counts a game on the map can be created for
+	PlayerCounts []int `json:"playerCounts"`
+	// The variant rules a game on the map can be created with
+	Variants []common.MapVariant `json:"variants,omitempty"`
+	// Keyed by player count
+	TurnLimits           map[int]int `json:"turnLimits"`
+	GoodsGrowthDiceCount map[int]int `json:"goodsGrowthDiceCount"`
+	SharesLimit          int         `json:"sharesLimit"`
+	Width                int         `json:"width"`
+	Height               int         `json:"height"`
+}
+
+type ListMapsRequest struct {
+}
+
+type ListMapsResponse struct {
+	Maps []*MapInfo `json:"maps"`
+}
+
+func newMapInfo(id string, displayName string, gameMap maps.GameMap) *MapInfo {
+	info := &MapInfo{
+		Id:                   id,
+		DisplayName:          displayName,
+		PlayerCounts:         gameMap.GetSupportedPlayerCounts(),
+		Variants:             gameMap.GetSupportedVariants(),
+		TurnLimits:           make(map[int]int),
+		GoodsGrowthDiceCount: make(map[int]int),
+		SharesLimit:          gameMap.GetSharesLimit(),
+		Width:                gameMap.GetWidth(),
+		Height:               gameMap.GetHeight(),
+	}
+	for _, playerCount := range info.PlayerCounts {
+		info.TurnLimits[playerCount] = gameMap.GetTurnLimit(playerCount)
+		info.GoodsGrowthDiceCount[playerCount] = gameMap.GetGoodsGrowthDiceCount(playerCount)
+	}
+	return info
+}
+
+// listMaps returns the built-in maps, followed by the public custom maps and the user's own private ones
+func (server *GameServer) listMaps(ctx *RequestContext, req *ListMapsRequest) (resp *ListMapsResponse, err error) {
+	res := &ListMapsResponse{Maps: make([]*MapInfo, 0)}
+	for _, mapName := range maps.BuiltInMapNames() {
+		gameMap, ok := server.gameMaps[mapName]
+		if !ok {
+			continue
+		}
+		res.Maps = append(res.Maps, newMapInfo(mapName, maps.DisplayName(mapName), gameMap))
+	}
+
+	rows, err := server.db.Query("SELECT id,name,map_data FROM maps WHERE visibility=? OR owner_user_id=? ORDER BY created_at",
+		string(PUBLIC_MAP_VISIBILITY), ctx.User.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maps: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var name string
+		var data string
+		err = rows.Scan(&id, &name, &data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan map row: %v", err)
+		}
+		gameMap, problems, err := maps.ParseCustomMap([]byte(data))
+		if err != nil || len(problems) > 0 {
+			// Uploaded maps are validated first, so this would only happen if validation has since become stricter
+			continue
+		}
+		info := newMapInfo(customMapPrefix+id, name, gameMap)
+		info.Custom = true
+		res.Maps = append(res.Maps, info)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map rows: %v", err)
+	}
+
+	return res, nil
+}
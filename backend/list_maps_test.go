@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMaps(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	owner := h.createUser(t)
+	otherUser := h.createUser(t)
+	mapData, err := os.ReadFile("maps/scotland.json")
+	require.NoError(t, err)
+	publicRes, err := h.uploadMap(t, owner, &UploadMapRequest{Name: "Public", Visibility: PUBLIC_MAP_VISIBILITY, Map: mapData})
+	require.NoError(t, err)
+	privateRes, err := h.uploadMap(t, owner, &UploadMapRequest{Name: "Private", Visibility: PRIVATE_MAP_VISIBILITY, Map: mapData})
+	require.NoError(t, err)
+
+	listIds := func(asUser string) []string {
+		res, err := h.listMaps(t, asUser, &ListMapsRequest{})
+		require.NoError(t, err)
+		var ids []string
+		for _, info := range res.Maps {
+			ids = append(ids, info.Id)
+		}
+		return ids
+	}
+	builtIn := []string{"rust_belt", "southern_us", "germany", "scotland", "australia", "ireland", "korea"}
+	assert.Equal(t, append(builtIn, publicRes.MapName, privateRes.MapName), listIds(owner))
+	assert.Equal(t, append(builtIn, publicRes.MapName), listIds(otherUser))
+
+	res, err := h.listMaps(t, owner, &ListMapsRequest{})
+	require.NoError(t, err)
+	scotland := res.Maps[3]
+	assert.Equal(t, "Scotland", scotland.DisplayName)
+	assert.False(t, scotland.Custom)
+	assert.Equal(t, 8, scotland.TurnLimits[2])
+	assert.Equal(t, 6, scotland.TurnLimits[3])
+	assert.Equal(t, 4, scotland.GoodsGrowthDiceCount[2])
+	assert.Equal(t, 15, scotland.SharesLimit)
+	assert.Equal(t, 4, scotland.Width)
+	assert.Equal(t, 17, scotland.Height)
+
+	custom := res.Maps[len(builtIn)]
+	assert.Equal(t, "Public", custom.DisplayName)
+	assert.True(t, custom.Custom)
+	// Custom maps don't get the special rules of the built-in map they were copied from
+	assert.Equal(t, 10, custom.TurnLimits[2])
+	assert.Equal(t, 4, custom.Width)
+}
+
+func TestCreateGameWithUnknownMap(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player := h.createUser(t)
+	for _, mapName := range []string{"", "atlantis", customMapPrefix + "atlantis"} {
+		_, err := h.createGame(t, player, &CreateGameRequest{
+			Name:       "game-name",
+			MinPlayers: 3,
+			MaxPlayers: 3,
+			MapName:    mapName,
+		})
+		var httpErr *api.HttpError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	}
+}
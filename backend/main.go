@@ -177,18 +177,16 @@ func main() {
 	}
 
 	if config.Database.Bootstrap {
-		bootstrapSql, err := os.ReadFile("bootstrap.sql")
+		err := migrateDatabase(db)
 		if err != nil {
-			panic(fmt.Errorf("failed to load bootstrap.sql: %v", err))
-		}
-		_, err = db.Exec(string(bootstrapSql))
-		if err != nil {
-			panic(fmt.Errorf("failed to run bootstrap.sql: %v", err))
+			panic(fmt.Errorf("failed to migrate database: %v", err))
 		}
 	}
 
+	// The validate-maps task reports the problems with each map itself, so it can still run when they fail to load
+	validatingMaps := len(os.Args) >= 3 && os.Args[1] == "run-task" && os.Args[2] == "validate-maps"
 	gameMaps, err := maps.LoadMaps()
-	if err != nil {
+	if err != nil && !validatingMaps {
 		panic(fmt.Errorf("failed to load maps: %v", err))
 	}
 
@@ -254,6 +252,8 @@ func (server *GameServer) createHttpMux() http.Handler {
 	mux.HandleFunc("/api/startGame", jsonHandler(server, server.startGame))
 	mux.HandleFunc("/api/listGames", jsonHandler(server, server.listGames))
 	mux.HandleFunc("/api/confirmMove", jsonHandler(server, server.confirmMove))
+	mux.HandleFunc("/api/previewMove", jsonHandler(server, server.previewMove))
+	mux.HandleFunc("/api/getLegalMoves", jsonHandler(server, server.getLegalMoves))
 	mux.HandleFunc("/api/viewGame", jsonHandler(server, server.viewGame))
 	mux.HandleFunc("/api/getGameLogs", jsonHandler(server, server.getGameLogs))
 	mux.HandleFunc("/api/getMyGames", jsonHandler(server, server.getMyGames))
@@ -263,6 +263,13 @@ func (server *GameServer) createHttpMux() http.Handler {
 	mux.HandleFunc("/api/sendGameChat", jsonHandler(server, server.sendGameChat))
 	mux.HandleFunc("/api/pollGameStatus", jsonHandler(server, server.pollGameStatus))
 	mux.HandleFunc("/api/undoMove", jsonHandler(server, server.undoMove))
+	mux.HandleFunc("/api/rollbackGame", jsonHandler(server, server.rollbackGame))
+	mux.HandleFunc("/api/voteRollback", jsonHandler(server, server.voteRollback))
+	mux.HandleFunc("/api/getLeaderboard", jsonHandler(server, server.getLeaderboard))
+	mux.HandleFunc("/api/exportGame", jsonHandler(server, server.exportGame))
+	mux.HandleFunc("/api/uploadMap", jsonHandler(server, server.uploadMap))
+	mux.HandleFunc("/api/listMaps", jsonHandler(server, server.listMaps))
+	mux.HandleFunc("/api/gameEvents", server.gameEvents)
 	return mux
 }
 
@@ -272,6 +279,9 @@ func (server *GameServer) runHttpServer() error {
 	if server.httpServer != nil {
 		return fmt.Errorf("http server is already running")
 	}
+	// Live game events are only available when running as a long-lived server
+	broker := newGameEventBroker()
+	server.eventBroker.Store(broker)
 	listenPort := server.config.HttpListenPort
 	if listenPort == 0 {
 		listenPort = 8080
@@ -284,6 +294,8 @@ func (server *GameServer) runHttpServer() error {
 	}
 	server.httpListenPort = listener.Addr().(*net.TCPAddr).Port
 	server.httpServer = &http.Server{Addr: "localhost:8080", Handler: mux}
+	// Shutdown waits for requests to finish, so end any open event streams
+	server.httpServer.RegisterOnShutdown(broker.closeAll)
 
 	go server.httpServer.Serve(listener)
 	return nil
@@ -293,6 +305,7 @@ func (server *GameServer) stopHttpServer() error {
 	if server.httpServer != nil {
 		err := server.httpServer.Shutdown(context.Background())
 		server.httpServer = nil
+		server.eventBroker.Store(nil)
 		return err
 	}
 	return nil
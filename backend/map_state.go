@@ -60,7 +60,7 @@ func newMapState(gameMap maps.GameMap, gameState *common.GameState) *MapState {
 			})
 
 			for i := 1; i < len(link.Steps); i++ {
-				hex = applyDirection(hex, link.Steps[i-1])
+				hex = common.ApplyDirection(hex, link.Steps[i-1])
 				ts[hex.Y][hex.X].routes = append(ts[hex.Y][hex.X].routes, &Route{
 					Left:  link.Steps[i-1].Opposite(),
 					Right: link.Steps[i],
@@ -75,7 +75,7 @@ func newMapState(gameMap maps.GameMap, gameState *common.GameState) *MapState {
 				})
 			}
 
-			hex = applyDirection(hex, link.Steps[len(link.Steps)-1])
+			hex = common.ApplyDirection(hex, link.Steps[len(link.Steps)-1])
 			if ts[hex.Y][hex.X].isTown && link.Complete {
 				dir := link.Steps[len(link.Steps)-1].Opposite()
 				ts[hex.Y][hex.X].routes = append(ts[hex.Y][hex.X].routes, &Route{
@@ -2,42 +2,68 @@ package maps
 
 import (
 	"fmt"
-	"slices"
 
 	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/tiles"
 )
 
 type australiaMap struct {
 	*basicMap
 }
 
-func (b *australiaMap) GetTotalBuildCost(gameState *common.GameState, player string, costs []int) int {
-	baseCost := b.basicMap.GetTotalBuildCost(gameState, player, costs)
+const (
+	// Extra cost of laying new track across the outback
+	outbackTrackSurcharge = 2
+	// The income track doesn't reduce anyone's income below this
+	australiaMinimumReducedIncome = 10
+)
 
-	action := gameState.PlayerActions[player]
-	if action != common.ENGINEER_SPECIAL_ACTION {
-		return baseCost
+// The outback is the desert in the middle of the map, marked in the map file
+func (b *australiaMap) isOutback(hex common.Coordinate) bool {
+	mapData := b.Hexes[hex.Y][hex.X].MapData
+	if mapData != nil {
+		if outback, ok := mapData["outback"].(bool); ok {
+			return outback
+		}
 	}
+	return false
+}
 
-	if len(costs) == 0 {
-		return baseCost
+// Only simple track can be built in the outback, and new track there costs more
+func (b *australiaMap) GetTrackBuildCost(gameState *common.GameState, player string, hexType HexType, hex common.Coordinate, trackType tiles.TrackType, isUpgrade bool) (int, error) {
+	cost, err := b.basicMap.GetTrackBuildCost(gameState, player, hexType, hex, trackType, isUpgrade)
+	if err != nil || !b.isOutback(hex) {
+		return cost, err
+	}
+	if trackType != tiles.SIMPLE_TRACK_TYPE {
+		return 0, fmt.Errorf("only simple track can be built in the outback")
 	}
-	return baseCost - slices.Max(costs)
+	if !isUpgrade {
+		cost += outbackTrackSurcharge
+	}
+	return cost, nil
 }
 
-func (b *australiaMap) GetDeliveryBonus(coordinate common.Coordinate, color common.Color) int {
-	if coordinate.X == 1 && coordinate.Y == 16 {
-		return 3
+// The income track reduces income as usual, but never below $10
+func (b *australiaMap) GetIncomeReduction(gameState *common.GameState, player string) (int, error) {
+	reduction, err := b.basicMap.GetIncomeReduction(gameState, player)
+	if err != nil {
+		return 0, err
 	}
-	return 0
+	return max(min(reduction, gameState.PlayerIncome[player]-australiaMinimumReducedIncome), 0), nil
 }
 
-func (*australiaMap) GetBuildLimit(gameState *common.GameState, player string) (int, error) {
-	action := gameState.PlayerActions[player]
-	if action == common.URBANIZATION_SPECIAL_ACTION {
-		return 2, nil
+func (b *australiaMap) validate() []string {
+	problems := b.basicMap.validate()
+	for y, row := range b.Hexes {
+		for x, hex := range row {
+			coord := common.Coordinate{X: x, Y: y}
+			if hex != nil && hex.HexType != PLAINS_HEX_TYPE && b.isOutback(coord) {
+				problems = append(problems, fmt.Sprintf("%s is in the outback but isn't plains", renderMapHex(coord)))
+			}
+		}
 	}
-	return 3, nil
+	return problems
 }
 
 func (b *australiaMap) PopulateStartingCubes(gameState *common.GameState, randProvider common.RandProvider) error {
@@ -73,11 +99,3 @@ func (b *australiaMap) PopulateStartingCubes(gameState *common.GameState, randPr
 	}
 	return b.basicMap.PopulateStartingCubes(gameState, randProvider)
 }
-
-func loadAustraliaMap() (GameMap, error) {
-	b, err := loadBasicMap("maps/australia.json")
-	if err != nil {
-		return nil, err
-	}
-	return &australiaMap{b}, nil
-}
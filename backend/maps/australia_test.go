@@ -4,93 +4,247 @@ import (
 	"testing"
 
 	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/tiles"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// Tests run from this directory rather than the backend directory that LoadMaps expects
+func loadTestAustraliaMap(t *testing.T) *australiaMap {
+	b, err := loadBasicMap("australia.json")
+	require.NoError(t, err)
+	return &australiaMap{b}
+}
+
+// The declarative special rules for Australia. Each one is covered by a test below, so a rule added to the map file
+// needs a test of its own before this is updated. The outback and income track rules are in australia.go.
+func TestAustraliaRules(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	assert.Equal(t, &mapRules{
+		DeliveryBonuses: []*deliveryBonus{{Hex: &common.Coordinate{X: 1, Y: 16}, Bonus: 3}},
+		BuildLimits: []*buildLimit{
+			{Action: common.URBANIZATION_SPECIAL_ACTION, Limit: 2},
+			{Limit: 3},
+		},
+		EngineerDiscount: MOST_EXPENSIVE_FREE_ENGINEER_DISCOUNT,
+	}, gameMap.Rules)
+	assert.Empty(t, gameMap.TeleportLinks)
+}
+
 func TestGetTotalBuildCost(t *testing.T) {
-	testCase := func(expectedCost int, action common.SpecialAction, costs []int) func(t *testing.T) {
-		return func(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		action       common.SpecialAction
+		costs        []int
+		expectedCost int
+	}{
+		{"regular costs for non-engineer", common.FIRST_BUILD_SPECIAL_ACTION, []int{4, 5, 6}, 15},
+		{"handles empty arrays gracefully", common.ENGINEER_SPECIAL_ACTION, []int{}, 0},
+		{"ignores the most expensive element", common.ENGINEER_SPECIAL_ACTION, []int{4, 1, 3, 5, 9, 6}, 19},
+		{"only one build is free", common.ENGINEER_SPECIAL_ACTION, []int{6, 6}, 6},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
 			playerId := "123"
-			gameMap := &australiaMap{&basicMap{}}
+			gameMap := loadTestAustraliaMap(t)
 			gameState := &common.GameState{
-				PlayerActions: map[string]common.SpecialAction{playerId: action},
+				PlayerActions: map[string]common.SpecialAction{playerId: tc.action},
 			}
 
-			cost := gameMap.GetTotalBuildCost(gameState, playerId, costs)
-			assert.Equal(t, expectedCost, cost)
-		}
+			assert.Equal(t, tc.expectedCost, gameMap.GetTotalBuildCost(gameState, playerId, tc.costs))
+		})
 	}
-
-	t.Run("regular costs for non-engineer",
-		testCase(15, common.FIRST_BUILD_SPECIAL_ACTION, []int{4, 5, 6}))
-
-	t.Run("handles empty arrays gracefully",
-		testCase(0, common.ENGINEER_SPECIAL_ACTION, []int{}))
-
-	t.Run("ignores the most expensive element",
-		testCase(19, common.ENGINEER_SPECIAL_ACTION, []int{4, 1, 3, 5, 9, 6}))
 }
 
 func TestGetBuildLimit(t *testing.T) {
-	testCase := func(expectedResult int, action common.SpecialAction) func(t *testing.T) {
-		return func(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		action        common.SpecialAction
+		expectedLimit int
+	}{
+		{"build limitted to 3 as normal", common.FIRST_BUILD_SPECIAL_ACTION, 3},
+		{"build limitted to 2 with urbanization", common.URBANIZATION_SPECIAL_ACTION, 2},
+		{"engineer does not increase build limits", common.ENGINEER_SPECIAL_ACTION, 3},
+		{"loco does not affect build limits", common.LOCO_SPECIAL_ACTION, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
 			playerId := "123"
-			gameMap := &australiaMap{&basicMap{}}
+			gameMap := loadTestAustraliaMap(t)
 			gameState := &common.GameState{
-				PlayerActions: map[string]common.SpecialAction{playerId: action},
+				PlayerActions: map[string]common.SpecialAction{playerId: tc.action},
 			}
 
 			result, err := gameMap.GetBuildLimit(gameState, playerId)
-			assert.Equal(t, expectedResult, result)
-			assert.Equal(t, nil, err)
-		}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedLimit, result)
+		})
 	}
+}
 
-	t.Run("build limitted to 3 as normal", testCase(3, common.FIRST_BUILD_SPECIAL_ACTION))
-
-	t.Run("build limitted to 2 with urbanization", testCase(2, common.URBANIZATION_SPECIAL_ACTION))
-
-	t.Run("engineer does not increase build limits", testCase(3, common.ENGINEER_SPECIAL_ACTION))
+func TestAustraliaDeliveryBonus(t *testing.T) {
+	perth := common.Coordinate{X: 1, Y: 16}
+	sydney := common.Coordinate{X: 8, Y: 18}
+	for _, tc := range []struct {
+		name          string
+		hex           common.Coordinate
+		color         common.Color
+		expectedBonus int
+	}{
+		{"bonus for delivering to Perth", perth, common.BLUE, 3},
+		{"bonus for any color delivered to Perth", perth, common.RED, 3},
+		{"no bonus elsewhere", sydney, common.RED, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gameMap := loadTestAustraliaMap(t)
+			assert.Equal(t, "Perth", gameMap.Hexes[perth.Y][perth.X].Name)
+			assert.Equal(t, tc.expectedBonus, gameMap.GetDeliveryBonus(tc.hex, tc.color))
+			assert.True(t, gameMap.ShouldPutDeliveryInBag(tc.color))
+		})
+	}
 }
 
 func TestPopulateStartingCubes(t *testing.T) {
-	gameMap := &australiaMap{&basicMap{}}
+	gameMap := loadTestAustraliaMap(t)
 	gameState := &common.GameState{
 		CubeBag: map[common.Color]int{
-			common.BLUE:   12,
-			common.RED:    12,
-			common.PURPLE: 12,
-			common.YELLOW: 12,
-			common.BLACK:  20,
+			common.BLACK:  16,
+			common.RED:    20,
+			common.YELLOW: 20,
+			common.BLUE:   20,
+			common.PURPLE: 20,
 		},
 	}
 
 	err := gameMap.PopulateStartingCubes(gameState, &common.CryptoRandProvider{})
-	assert.Equal(t, nil, err)
+	require.NoError(t, err)
 
+	darkCities := 0
 	for y := 0; y < len(gameMap.Hexes); y++ {
 		for x := 0; x < len(gameMap.Hexes[y]); x++ {
 			hex := gameMap.Hexes[y][x]
 			if hex.HexType != CITY_HEX_TYPE {
 				continue
 			}
-			if len(hex.GoodsGrowth) < 1 || hex.GoodsGrowth[0] < 6 {
-				continue
-			}
 
-			var cubes []*common.BoardCube
+			var cubes []common.Color
 			for _, cube := range gameState.Cubes {
 				if cube.Hex.X == x && cube.Hex.Y == y {
-					cubes = append(cubes, cube)
+					cubes = append(cubes, cube.Color)
 				}
 			}
-			if len(hex.GoodsGrowth) >= 1 && hex.GoodsGrowth[0] >= 6 {
-				assert.Equal(t, common.BLUE, cubes[0])
-				assert.Equal(t, common.BLUE, cubes[1])
-				assert.Equal(t, 4, len(cubes))
+			// Dark cities get two blue cubes on top of the usual two drawn from the bag
+			if hex.GoodsGrowth[0] >= 6 {
+				darkCities += 1
+				require.Len(t, cubes, 4, hex.Name)
+				assert.Equal(t, common.BLUE, cubes[0], hex.Name)
+				assert.Equal(t, common.BLUE, cubes[1], hex.Name)
 			} else {
-				assert.Equal(t, 3, len(cubes))
+				assert.Len(t, cubes, 3, hex.Name)
 			}
 		}
 	}
+	assert.Equal(t, 6, darkCities)
+}
+
+func TestAustraliaPlayerCounts(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	assert.Equal(t, []int{4, 5, 6}, gameMap.GetSupportedPlayerCounts())
+
+	for _, tc := range []struct {
+		playerCount       int
+		expectedTurnLimit int
+	}{
+		{4, 8},
+		{5, 7},
+		{6, 6},
+	} {
+		assert.Equal(t, tc.expectedTurnLimit, gameMap.GetTurnLimit(tc.playerCount))
+	}
+}
+
+func TestAustraliaOutback(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	for _, tc := range []struct {
+		name            string
+		hex             common.Coordinate
+		expectedOutback bool
+	}{
+		{"plains near the coast", common.Coordinate{X: 3, Y: 5}, false},
+		{"plains north of Alice Springs", common.Coordinate{X: 4, Y: 7}, true},
+		{"plains next to Uluru", common.Coordinate{X: 3, Y: 10}, true},
+		{"plains south of the outback", common.Coordinate{X: 3, Y: 13}, false},
+		{"Alice Springs", common.Coordinate{X: 5, Y: 8}, false},
+		{"mountains", common.Coordinate{X: 1, Y: 10}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutback, gameMap.isOutback(tc.hex))
+		})
+	}
+}
+
+func TestAustraliaTrackBuildCost(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	gameState := &common.GameState{}
+	coast := common.Coordinate{X: 3, Y: 5}
+	outback := common.Coordinate{X: 4, Y: 7}
+	mountain := common.Coordinate{X: 1, Y: 10}
+
+	for _, tc := range []struct {
+		name         string
+		hex          common.Coordinate
+		trackType    tiles.TrackType
+		isUpgrade    bool
+		expectedCost int
+		expectedErr  bool
+	}{
+		{"simple track on plains", coast, tiles.SIMPLE_TRACK_TYPE, false, 2, false},
+		{"complex track on plains", coast, tiles.COMPLEX_CROSSING_TRACK_TYPE, false, 4, false},
+		{"simple track in the outback", outback, tiles.SIMPLE_TRACK_TYPE, false, 4, false},
+		{"redirecting track in the outback", outback, tiles.SIMPLE_TRACK_TYPE, true, 2, false},
+		{"complex coexisting track in the outback", outback, tiles.COMPLEX_COEXISTING_TRACK_TYPE, false, 0, true},
+		{"complex crossing track in the outback", outback, tiles.COMPLEX_CROSSING_TRACK_TYPE, false, 0, true},
+		{"upgrading to complex track in the outback", outback, tiles.COMPLEX_CROSSING_TRACK_TYPE, true, 0, true},
+		{"simple track on mountains", mountain, tiles.SIMPLE_TRACK_TYPE, false, 4, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := gameMap.GetTrackBuildCost(gameState, "player", gameMap.GetHexType(tc.hex), tc.hex, tc.trackType, tc.isUpgrade)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedCost, cost)
+			}
+		})
+	}
+}
+
+func TestAustraliaIncomeReduction(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	for _, tc := range []struct {
+		name              string
+		income            int
+		expectedReduction int
+	}{
+		{"no reduction at 10 or less", 10, 0},
+		{"only reduced to 10", 11, 1},
+		{"usual reduction when it stays above 10", 15, 2},
+		{"usual reduction at the top of the track", 50, 10},
+		{"no reduction for negative income", -3, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			playerId := "123"
+			gameState := &common.GameState{
+				PlayerIncome: map[string]int{playerId: tc.income},
+			}
+			reduction, err := gameMap.GetIncomeReduction(gameState, playerId)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedReduction, reduction)
+		})
+	}
+}
+
+func TestAustraliaValidate(t *testing.T) {
+	gameMap := loadTestAustraliaMap(t)
+	assert.Empty(t, gameMap.validate())
+	gameMap.Hexes[7][2] = &basicMapHex{HexType: MOUNTAIN_HEX_TYPE, MapData: map[string]interface{}{"outback": true}}
+	assert.Equal(t, []string{"H6 is in the outback but isn't plains"}, gameMap.validate())
 }
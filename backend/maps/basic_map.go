@@ -1,6 +1,7 @@
 package maps
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/tiles"
@@ -24,13 +25,15 @@ type teleportLink struct {
 }
 
 type basicMapHex struct {
-	HexType           HexType                `json:"type"`
-	Name              string                 `json:"name,omitempty"`
-	CityColor         common.Color           `json:"cityColor,omitempty"`
-	GoodsGrowth       []int                  `json:"goodsGrowth,omitempty"`
-	StartingCubeCount int                    `json:"startingCubeCount,omitempty"`
-	Cost              int                    `json:"cost,omitempty"`
-	MapData           map[string]interface{} `json:"mapData,omitempty"`
+	HexType           HexType      `json:"type"`
+	Name              string       `json:"name,omitempty"`
+	CityColor         common.Color `json:"cityColor,omitempty"`
+	GoodsGrowth       []int        `json:"goodsGrowth,omitempty"`
+	StartingCubeCount int          `json:"startingCubeCount,omitempty"`
+	// The cubes the city starts with in games with fixed starting cubes
+	FixedStartingCubes []common.Color         `json:"fixedStartingCubes,omitempty"`
+	Cost               int                    `json:"cost,omitempty"`
+	MapData            map[string]interface{} `json:"mapData,omitempty"`
 }
 
 type basicMap struct {
@@ -39,9 +42,16 @@ type basicMap struct {
 	// Rectangular array height*width in size (y dimension is first)
 	Hexes         [][]*basicMapHex `json:"hexes"`
 	TeleportLinks []teleportLink   `json:"teleportLinks"`
+	Rules         *mapRules        `json:"rules,omitempty"`
+	// Defaults to 3-6 players
+	PlayerCounts []int               `json:"playerCounts,omitempty"`
+	Variants     []common.MapVariant `json:"variants,omitempty"`
 }
 
 func (b *basicMap) PopulateStartingCubes(gameState *common.GameState, randProvider common.RandProvider) error {
+	if gameState.HasMapVariant(common.FIXED_STARTING_CUBES_VARIANT) {
+		return b.populateFixedStartingCubes(gameState)
+	}
 	for y := 0; y < len(b.Hexes); y++ {
 		for x := 0; x < len(b.Hexes[y]); x++ {
 			for i := 0; i < b.Hexes[y][x].StartingCubeCount; i++ {
@@ -59,6 +69,40 @@ func (b *basicMap) PopulateStartingCubes(gameState *common.GameState, randProvid
 	return nil
 }
 
+// populateFixedStartingCubes takes the cubes listed for each city in the map file out of the bag and puts them on the
+// city
+func (b *basicMap) populateFixedStartingCubes(gameState *common.GameState) error {
+	for y := 0; y < len(b.Hexes); y++ {
+		for x := 0; x < len(b.Hexes[y]); x++ {
+			for _, color := range b.Hexes[y][x].FixedStartingCubes {
+				count, err := gameState.PullCube(color, 1)
+				if err != nil {
+					return err
+				}
+				if count != 1 {
+					return fmt.Errorf("no %v cubes left in the bag for the fixed starting cubes", color)
+				}
+				gameState.Cubes = append(gameState.Cubes, &common.BoardCube{
+					Color: color,
+					Hex:   common.Coordinate{X: x, Y: y},
+				})
+			}
+		}
+	}
+	return nil
+}
+
+func (b *basicMap) GetSupportedPlayerCounts() []int {
+	if len(b.PlayerCounts) > 0 {
+		return b.PlayerCounts
+	}
+	return b.AbstractGameMapImpl.GetSupportedPlayerCounts()
+}
+
+func (b *basicMap) GetSupportedVariants() []common.MapVariant {
+	return b.Variants
+}
+
 func (b *basicMap) GetCityColorForHex(gameState *common.GameState, hex common.Coordinate) common.Color {
 	return b.Hexes[hex.Y][hex.X].CityColor
 }
@@ -139,3 +183,26 @@ func loadBasicMap(filename string) (*basicMap, error) {
 	}
 	return m, nil
 }
+
+// Custom maps can't be larger than this many hexes in either dimension
+const maxCustomMapSize = 50
+
+// ParseCustomMap parses a map uploaded by a user, which is in the same format as the map files of the built-in maps. It
+// returns the structural problems found in the map, if there are any. Custom maps can use the rules in the map file, but
+// not the special rules of built-in maps that are implemented in code.
+func ParseCustomMap(data []byte) (GameMap, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	m := new(basicMap)
+	err := decoder.Decode(m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range m.Hexes {
+		if len(m.Hexes) > maxCustomMapSize || len(row) > maxCustomMapSize {
+			return nil, []string{fmt.Sprintf("the map is larger than %d by %d hexes", maxCustomMapSize, maxCustomMapSize)}, nil
+		}
+	}
+	return m, m.validate(), nil
+}
@@ -10,6 +10,11 @@ type germanyMap struct {
 }
 
 func (m *germanyMap) PostSetupHook(gameState *common.GameState, randProvider common.RandProvider) error {
+	err := m.basicMap.PostSetupHook(gameState, randProvider)
+	if err != nil {
+		return err
+	}
+
 	portColors := make([]common.Color, 6)
 	for i := 0; i < len(portColors); i++ {
 		cube, err := gameState.DrawCube(randProvider)
@@ -57,33 +62,11 @@ func (m *germanyMap) GetCityColorForHex(gameState *common.GameState, hex common.
 	return common.NONE_COLOR
 }
 
-func (m *germanyMap) LocationBlocksCubePassage(cube common.Color, hex common.Coordinate) bool {
-	port := m.getPortNumber(hex)
-	if port != 0 {
-		return true
-	}
-	return false
-}
-
-func (m *germanyMap) GetBuildLimit(gameState *common.GameState, player string) (int, error) {
-	return 3, nil
-}
-
-func (m *germanyMap) GetTotalBuildCost(gameState *common.GameState, player string, costs []int) int {
-	if gameState.PlayerActions[player] == common.ENGINEER_SPECIAL_ACTION {
-		maxCost := 0
-		totalCost := 0
-		for _, cost := range costs {
-			totalCost += cost
-			if cost > maxCost {
-				maxCost = cost
-			}
-		}
-		totalCost -= maxCost / 2
-		return totalCost
-	} else {
-		return m.basicMap.GetTotalBuildCost(gameState, player, costs)
-	}
+// Ports have no city color in the map file, since their colors are drawn during setup
+func (m *germanyMap) validate() []string {
+	return m.basicMap.validateMap(func(hex common.Coordinate) bool {
+		return m.getPortNumber(hex) != 0
+	})
 }
 
 func (b *germanyMap) PostBuildActionHook(gameState *common.GameState, player string) error {
@@ -94,34 +77,3 @@ func (b *germanyMap) PostBuildActionHook(gameState *common.GameState, player str
 	}
 	return nil
 }
-
-func (b *germanyMap) PostGoodsGrowthHook(gameState *common.GameState, randProvider common.RandProvider, log LogFun) error {
-	err := b.basicMap.PostGoodsGrowthHook(gameState, randProvider, log)
-	if err != nil {
-		return err
-	}
-
-	color, err := gameState.DrawCube(randProvider)
-	if err != nil {
-		return err
-	}
-	if color == common.NONE_COLOR {
-		log("No cube was drawn to add to Berlin because the bag is empty.", color.String())
-	} else {
-		gameState.Cubes = append(gameState.Cubes, &common.BoardCube{
-			Color: color,
-			Hex:   common.Coordinate{X: 4, Y: 9},
-		})
-		log("A %s cube was drawn and added to Berlin.", color.String())
-	}
-
-	return nil
-}
-
-func loadGermanyMap() (GameMap, error) {
-	b, err := loadBasicMap("maps/germany.json")
-	if err != nil {
-		return nil, err
-	}
-	return &germanyMap{b}, nil
-}
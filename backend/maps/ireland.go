@@ -0,0 +1,18 @@
+package maps
+
+type irelandMap struct {
+	*basicMap
+}
+
+// Ireland is a short game: goods are removed from the game once delivered (see the map file), so the board runs dry
+// after a few turns
+func (*irelandMap) GetTurnLimit(playerCount int) int {
+	if playerCount >= 4 {
+		return 7
+	}
+	return 8
+}
+
+func (*irelandMap) GetGoodsGrowthDiceCount(playerCount int) int {
+	return 3
+}
@@ -0,0 +1,55 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestIrelandMap(t *testing.T) *irelandMap {
+	b, err := loadBasicMap("ireland.json")
+	require.NoError(t, err)
+	return &irelandMap{b}
+}
+
+func TestIrelandTurnLimit(t *testing.T) {
+	gameMap := loadTestIrelandMap(t)
+	assert.Equal(t, []int{3, 4}, gameMap.GetSupportedPlayerCounts())
+
+	for _, tc := range []struct {
+		playerCount       int
+		expectedTurnLimit int
+	}{
+		{3, 8},
+		{4, 7},
+	} {
+		assert.Equal(t, tc.expectedTurnLimit, gameMap.GetTurnLimit(tc.playerCount))
+	}
+}
+
+func TestIrelandGoodsGrowthDiceCount(t *testing.T) {
+	gameMap := loadTestIrelandMap(t)
+	for _, playerCount := range gameMap.GetSupportedPlayerCounts() {
+		assert.Equal(t, 3, gameMap.GetGoodsGrowthDiceCount(playerCount))
+	}
+}
+
+func TestIrelandDeliveredGoodsAreRemoved(t *testing.T) {
+	gameMap := loadTestIrelandMap(t)
+	for _, color := range []common.Color{common.BLACK, common.RED, common.YELLOW, common.BLUE, common.PURPLE} {
+		t.Run(color.String(), func(t *testing.T) {
+			assert.False(t, gameMap.ShouldPutDeliveryInBag(color))
+		})
+	}
+}
+
+// Ireland's special rules, besides the turn limit and goods growth dice above
+func TestIrelandRules(t *testing.T) {
+	gameMap := loadTestIrelandMap(t)
+	assert.Equal(t, &mapRules{
+		RemovedOnDelivery: []common.Color{common.BLACK, common.RED, common.YELLOW, common.BLUE, common.PURPLE},
+	}, gameMap.Rules)
+	assert.Empty(t, gameMap.TeleportLinks)
+}
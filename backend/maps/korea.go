@@ -0,0 +1,50 @@
+package maps
+
+import (
+	"fmt"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/tiles"
+)
+
+type koreaMap struct {
+	*basicMap
+}
+
+func (m *koreaMap) isDmz(hex common.Coordinate) bool {
+	mapData := m.Hexes[hex.Y][hex.X].MapData
+	if mapData != nil {
+		if dmz, ok := mapData["dmz"].(bool); ok {
+			return dmz
+		}
+	}
+	return false
+}
+
+// Nothing can be built in the DMZ, so the only way across is the link between Kaesong and Seoul
+func (m *koreaMap) CheckBuildableHex(gameState *common.GameState, hex common.Coordinate) error {
+	if m.isDmz(hex) {
+		return fmt.Errorf("cannot build in the DMZ at %s", common.RenderHexCoordinate(hex))
+	}
+	return m.basicMap.CheckBuildableHex(gameState, hex)
+}
+
+func (m *koreaMap) GetTrackBuildCost(gameState *common.GameState, player string, hexType HexType, hex common.Coordinate, trackType tiles.TrackType, isUpgrade bool) (int, error) {
+	if m.isDmz(hex) {
+		return 0, fmt.Errorf("cannot build track in the DMZ")
+	}
+	return m.basicMap.GetTrackBuildCost(gameState, player, hexType, hex, trackType, isUpgrade)
+}
+
+func (m *koreaMap) validate() []string {
+	problems := m.basicMap.validate()
+	for y, row := range m.Hexes {
+		for x, hex := range row {
+			coord := common.Coordinate{X: x, Y: y}
+			if hex != nil && (hex.HexType == CITY_HEX_TYPE || hex.HexType == TOWN_HEX_TYPE) && m.isDmz(coord) {
+				problems = append(problems, fmt.Sprintf("%s at %s can't be in the DMZ", hex.Name, renderMapHex(coord)))
+			}
+		}
+	}
+	return problems
+}
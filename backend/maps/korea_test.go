@@ -0,0 +1,103 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/tiles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestKoreaMap(t *testing.T) *koreaMap {
+	b, err := loadBasicMap("korea.json")
+	require.NoError(t, err)
+	return &koreaMap{b}
+}
+
+func TestKoreaTrackBuildCost(t *testing.T) {
+	gameMap := loadTestKoreaMap(t)
+	gameState := &common.GameState{}
+
+	for _, tc := range []struct {
+		name         string
+		hex          common.Coordinate
+		expectedCost int
+		expectedErr  bool
+	}{
+		{"plains north of the DMZ", common.Coordinate{X: 1, Y: 7}, 2, false},
+		{"plains in the DMZ", common.Coordinate{X: 1, Y: 8}, 0, true},
+		{"mountain in the DMZ", common.Coordinate{X: 4, Y: 9}, 0, true},
+		{"plains south of the DMZ", common.Coordinate{X: 1, Y: 10}, 2, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := gameMap.GetTrackBuildCost(gameState, "player", gameMap.GetHexType(tc.hex), tc.hex, tiles.SIMPLE_TRACK_TYPE, false)
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedCost, cost)
+			}
+		})
+	}
+}
+
+// Korea has no special rules in its map file; the DMZ is the only rule and it's implemented in korea.go
+func TestKoreaRules(t *testing.T) {
+	gameMap := loadTestKoreaMap(t)
+	assert.Nil(t, gameMap.Rules)
+	assert.Equal(t, []int{3, 4, 5}, gameMap.GetSupportedPlayerCounts())
+	assert.Len(t, gameMap.TeleportLinks, 1)
+}
+
+func TestKoreaCheckBuildableHex(t *testing.T) {
+	gameMap := loadTestKoreaMap(t)
+	gameState := &common.GameState{}
+
+	for _, tc := range []struct {
+		name        string
+		hex         common.Coordinate
+		expectedErr bool
+	}{
+		{"plains north of the DMZ", common.Coordinate{X: 1, Y: 7}, false},
+		{"plains in the DMZ", common.Coordinate{X: 1, Y: 8}, true},
+		{"mountain in the DMZ", common.Coordinate{X: 4, Y: 9}, true},
+		{"plains south of the DMZ", common.Coordinate{X: 1, Y: 10}, false},
+		{"Kaesong end of the link across the DMZ", common.Coordinate{X: 2, Y: 7}, false},
+		{"Seoul end of the link across the DMZ", common.Coordinate{X: 2, Y: 10}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := gameMap.CheckBuildableHex(gameState, tc.hex)
+			if tc.expectedErr {
+				assert.EqualError(t, err, "cannot build in the DMZ at "+common.RenderHexCoordinate(tc.hex))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKoreaDmzCrossesTheBoard(t *testing.T) {
+	gameMap := loadTestKoreaMap(t)
+	for _, y := range []int{8, 9} {
+		for x := 0; x < gameMap.GetWidth(); x++ {
+			hex := common.Coordinate{X: x, Y: y}
+			if gameMap.GetHexType(hex) != WATER_HEX_TYPE {
+				assert.True(t, gameMap.isDmz(hex), renderMapHex(hex))
+			}
+		}
+	}
+
+	// The link between Kaesong and Seoul is the only way across
+	dest, direction := gameMap.GetTeleportLink(&common.GameState{}, common.Coordinate{X: 2, Y: 7}, common.SOUTH)
+	require.NotNil(t, dest)
+	assert.Equal(t, common.Coordinate{X: 2, Y: 10}, *dest)
+	assert.Equal(t, common.NORTH, direction)
+	assert.Equal(t, 6, gameMap.GetTeleportLinkBuildCost(&common.GameState{}, "player", common.Coordinate{X: 2, Y: 7}, common.SOUTH))
+}
+
+func TestKoreaValidate(t *testing.T) {
+	gameMap := loadTestKoreaMap(t)
+	gameMap.Hexes[8][2] = &basicMapHex{HexType: TOWN_HEX_TYPE, Name: "Panmunjom", MapData: map[string]interface{}{"dmz": true}}
+	assert.Equal(t, []string{"Panmunjom at I5 can't be in the DMZ"}, gameMap.validate())
+}
@@ -16,17 +16,24 @@ type GameMap interface {
 	GetCityColorForHex(gameState *common.GameState, hex common.Coordinate) common.Color
 	GetCityHexForGoodsGrowth(goodsGrowth int) common.Coordinate
 	PopulateStartingCubes(gameState *common.GameState, randProvider common.RandProvider) error
+	// The player counts a game on this map can be played with, in increasing order
+	GetSupportedPlayerCounts() []int
+	// The variant rules a game on this map can be created with
+	GetSupportedVariants() []common.MapVariant
 	GetTurnLimit(playerCount int) int
 	GetSharesLimit() int
 	GetGoodsGrowthDiceCount(playerCount int) int
 	GetTeleportLink(gameState *common.GameState, src common.Coordinate, direction common.Direction) (*common.Coordinate, common.Direction)
 	GetAuctionPhase() auction.AuctionPhase
 
+	IsSpecialActionAvailable(gameState *common.GameState, action common.SpecialAction) bool
 	GetBuildLimit(gameState *common.GameState, player string) (int, error)
 	GetTownBuildCost(gameState *common.GameState, player string, hex common.Coordinate, routeCount int, isUpgrade bool) int
 	GetTrackBuildCost(gameState *common.GameState, player string, hexType HexType, hex common.Coordinate, trackType tiles.TrackType, isUpgrade bool) (int, error)
 	GetTotalBuildCost(gameState *common.GameState, player string, costs []int) int
 	GetTeleportLinkBuildCost(gameState *common.GameState, player string, hex common.Coordinate, direction common.Direction) int
+	// Returns an error if the map doesn't allow building anything on the hex, whether track, towns, urbanizations or teleport links
+	CheckBuildableHex(gameState *common.GameState, hex common.Coordinate) error
 	GetIncomeReduction(gameState *common.GameState, player string) (int, error)
 	PostSetupHook(gameState *common.GameState, randProvider common.RandProvider) error
 	PostBuildActionHook(gameState *common.GameState, player string) error
@@ -65,6 +72,14 @@ func (*AbstractGameMapImpl) PopulateStartingCubes(gameState *common.GameState, r
 	return nil
 }
 
+func (*AbstractGameMapImpl) GetSupportedPlayerCounts() []int {
+	return []int{3, 4, 5, 6}
+}
+
+func (*AbstractGameMapImpl) GetSupportedVariants() []common.MapVariant {
+	return nil
+}
+
 func (*AbstractGameMapImpl) GetTurnLimit(playerCount int) int {
 	if playerCount == 6 {
 		return 6
@@ -88,6 +103,17 @@ func (*AbstractGameMapImpl) GetTeleportLink(gameState *common.GameState, src com
 	return nil, 0
 }
 
+func (*AbstractGameMapImpl) IsSpecialActionAvailable(gameState *common.GameState, action common.SpecialAction) bool {
+	if action == common.URBANIZATION_SPECIAL_ACTION && gameState.TurnNumber == 1 &&
+		gameState.HasMapVariant(common.NO_FIRST_TURN_URBANIZATION_VARIANT) {
+		return false
+	}
+	if action == common.TURN_ORDER_PASS_SPECIAL_ACTION && !gameState.Options.IsTurnOrderPassAvailable() {
+		return false
+	}
+	return true
+}
+
 func (*AbstractGameMapImpl) GetBuildLimit(gameState *common.GameState, player string) (int, error) {
 	action := gameState.PlayerActions[player]
 	if action == common.ENGINEER_SPECIAL_ACTION {
@@ -178,7 +204,17 @@ func (*AbstractGameMapImpl) GetTeleportLinkBuildCost(gameState *common.GameState
 	return 0
 }
 
+func (*AbstractGameMapImpl) CheckBuildableHex(gameState *common.GameState, hex common.Coordinate) error {
+	return nil
+}
+
 func (*AbstractGameMapImpl) PostSetupHook(gameState *common.GameState, randProvider common.RandProvider) error {
+	if gameState.HasMapVariant(common.FIRST_TURN_EXTRA_SHARE_VARIANT) {
+		for _, player := range gameState.PlayerOrder {
+			gameState.PlayerShares[player] += 1
+			gameState.PlayerCash[player] += 5
+		}
+	}
 	return nil
 }
 
@@ -210,38 +246,98 @@ func (*AbstractGameMapImpl) LocationCanAcceptCube(cube common.Color, hex common.
 	return false
 }
 
-func LoadMaps() (map[string]GameMap, error) {
-	maps := make(map[string]GameMap)
+// SharesLimit returns how many shares a player can take in the game, which the game's options can override
+func SharesLimit(gameMap GameMap, gameState *common.GameState) int {
+	return gameState.Options.GetSharesLimit(gameMap.GetSharesLimit())
+}
 
-	rustBelt, err := loadBasicMap("maps/rust_belt.json")
-	if err != nil {
-		return nil, err
+// TurnLimit returns how many turns the game lasts, which the game's options can override
+func TurnLimit(gameMap GameMap, gameState *common.GameState) int {
+	return gameState.Options.GetTurnLimit(gameMap.GetTurnLimit(len(gameState.PlayerOrder)))
+}
+
+// AuctionPhase returns how the turn order is decided in the game, which is the map's own auction unless the game's
+// options replace it
+func AuctionPhase(gameMap GameMap, gameState *common.GameState) auction.AuctionPhase {
+	switch gameState.Options.GetAuctionType() {
+	case common.SEALED_BID_AUCTION_TYPE:
+		return &auction.SealedBidAuctionPhase{}
+	case common.PAY_FULL_BID_AUCTION_TYPE:
+		return auction.NewPayFullBidAuctionPhase()
+	case common.FIXED_ORDER_AUCTION_TYPE:
+		return &auction.FixedOrderAuctionPhase{}
 	}
-	maps["rust_belt"] = rustBelt
+	return gameMap.GetAuctionPhase()
+}
 
-	southernUs, err := loadSouthernUsMap()
-	if err != nil {
-		return nil, err
+// mapFiles are the maps that ship with the game, in the order they're listed
+var mapFiles = []struct {
+	name        string
+	displayName string
+	filename    string
+	wrap        func(b *basicMap) GameMap
+}{
+	{"rust_belt", "Rust Belt", "maps/rust_belt.json", func(b *basicMap) GameMap { return b }},
+	{"southern_us", "Southern U.S.", "maps/southern_us.json", func(b *basicMap) GameMap { return &southernUsMap{b} }},
+	{"germany", "Germany", "maps/germany.json", func(b *basicMap) GameMap { return &germanyMap{b} }},
+	{"scotland", "Scotland", "maps/scotland.json", func(b *basicMap) GameMap { return &scotlandMap{b} }},
+	{"australia", "Australia", "maps/australia.json", func(b *basicMap) GameMap { return &australiaMap{b} }},
+	{"ireland", "Ireland", "maps/ireland.json", func(b *basicMap) GameMap { return &irelandMap{b} }},
+	{"korea", "Korea", "maps/korea.json", func(b *basicMap) GameMap { return &koreaMap{b} }},
+}
+
+// BuiltInMapNames returns the names of the maps that ship with the game, in the order they should be listed
+func BuiltInMapNames() []string {
+	names := make([]string, 0, len(mapFiles))
+	for _, mapFile := range mapFiles {
+		names = append(names, mapFile.name)
 	}
-	maps["southern_us"] = southernUs
+	return names
+}
 
-	germany, err := loadGermanyMap()
-	if err != nil {
-		return nil, err
+// DisplayName returns the name of a built-in map as shown to players, or the empty string for an unknown map
+func DisplayName(mapName string) string {
+	for _, mapFile := range mapFiles {
+		if mapFile.name == mapName {
+			return mapFile.displayName
+		}
 	}
-	maps["germany"] = germany
+	return ""
+}
 
-	scotland, err := loadScotlandMap()
-	if err != nil {
-		return nil, err
+// loadMapFiles loads every map, along with the structural problems found in each map file keyed by file name
+func loadMapFiles() (map[string]GameMap, map[string][]string, error) {
+	maps := make(map[string]GameMap)
+	problems := make(map[string][]string)
+	for _, mapFile := range mapFiles {
+		b, err := loadBasicMap(mapFile.filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load %s: %v", mapFile.filename, err)
+		}
+		gameMap := mapFile.wrap(b)
+		if validated, ok := gameMap.(validatedMap); ok {
+			if mapProblems := validated.validate(); len(mapProblems) > 0 {
+				problems[mapFile.filename] = mapProblems
+			}
+		}
+		maps[mapFile.name] = gameMap
 	}
-	maps["scotland"] = scotland
+	return maps, problems, nil
+}
 
-	australia, err := loadAustraliaMap()
+// ValidateMaps returns the structural problems found in each map file, keyed by file name
+func ValidateMaps() (map[string][]string, error) {
+	_, problems, err := loadMapFiles()
+	return problems, err
+}
+
+func LoadMaps() (map[string]GameMap, error) {
+	maps, problems, err := loadMapFiles()
 	if err != nil {
 		return nil, err
 	}
-	maps["australia"] = australia
-
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid maps:\n%s", FormatMapProblems(problems))
+	}
 	return maps, nil
 }
@@ -0,0 +1,204 @@
+package maps
+
+import (
+	"slices"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+)
+
+// EngineerDiscount is how the engineer special action changes the cost of a build, on maps where it doesn't just raise
+// the build limit
+type EngineerDiscount string
+
+const (
+	NO_ENGINEER_DISCOUNT                  EngineerDiscount = ""
+	HALF_MOST_EXPENSIVE_ENGINEER_DISCOUNT EngineerDiscount = "half_most_expensive"
+	MOST_EXPENSIVE_FREE_ENGINEER_DISCOUNT EngineerDiscount = "most_expensive_free"
+)
+
+// turnRange limits a rule to some turns of the game. Either end can be left out (i.e. zero) to leave it open.
+type turnRange struct {
+	FromTurn int `json:"fromTurn,omitempty"`
+	ToTurn   int `json:"toTurn,omitempty"`
+}
+
+func (r *turnRange) includes(turn int) bool {
+	return (r.FromTurn == 0 || turn >= r.FromTurn) && (r.ToTurn == 0 || turn <= r.ToTurn)
+}
+
+// deliveryBonus is extra income for delivering goods. Leaving out the hex or the color applies the bonus to any.
+type deliveryBonus struct {
+	Hex   *common.Coordinate `json:"hex,omitempty"`
+	Color common.Color       `json:"color,omitempty"`
+	Bonus int                `json:"bonus"`
+}
+
+// extraGoodsGrowth adds cubes drawn from the bag to a city at the end of each goods growth phase in the turn range
+type extraGoodsGrowth struct {
+	turnRange
+	Hex common.Coordinate `json:"hex"`
+	// Defaults to 1
+	Count int `json:"count,omitempty"`
+}
+
+// cubeRestriction changes how goods of some colors (any color, if left out) move through a set of hexes
+type cubeRestriction struct {
+	Hexes  []common.Coordinate `json:"hexes"`
+	Colors []common.Color      `json:"colors,omitempty"`
+	// Goods must end their movement at these hexes
+	BlocksPassage bool `json:"blocksPassage,omitempty"`
+	// Goods can be delivered to these hexes, whatever the color of the city
+	AcceptsCube bool `json:"acceptsCube,omitempty"`
+}
+
+func (r *cubeRestriction) applies(cube common.Color, hex common.Coordinate) bool {
+	return slices.Contains(r.Hexes, hex) && (len(r.Colors) == 0 || slices.Contains(r.Colors, cube))
+}
+
+// buildLimit overrides the number of builds a player can make in a turn. A limit without an action applies to players
+// who took any action without a limit of its own.
+type buildLimit struct {
+	Action common.SpecialAction `json:"action,omitempty"`
+	Limit  int                  `json:"limit"`
+}
+
+// incomeReductionMultiplier multiplies the income reduction during the turn range
+type incomeReductionMultiplier struct {
+	turnRange
+	Multiplier int `json:"multiplier"`
+}
+
+// mapRules are the declarative special rules of a map, so that most maps can be described entirely by their JSON
+type mapRules struct {
+	DeliveryBonuses []*deliveryBonus `json:"deliveryBonuses,omitempty"`
+	// Goods of these colors are removed from the game when delivered, instead of going back in the bag
+	RemovedOnDelivery          []common.Color               `json:"removedOnDelivery,omitempty"`
+	ExtraGoodsGrowth           []*extraGoodsGrowth          `json:"extraGoodsGrowth,omitempty"`
+	CubeRestrictions           []*cubeRestriction           `json:"cubeRestrictions,omitempty"`
+	BuildLimits                []*buildLimit                `json:"buildLimits,omitempty"`
+	IncomeReductionMultipliers []*incomeReductionMultiplier `json:"incomeReductionMultipliers,omitempty"`
+	EngineerDiscount           EngineerDiscount             `json:"engineerDiscount,omitempty"`
+}
+
+func (b *basicMap) GetDeliveryBonus(coordinate common.Coordinate, color common.Color) int {
+	if b.Rules == nil {
+		return b.AbstractGameMapImpl.GetDeliveryBonus(coordinate, color)
+	}
+	bonus := 0
+	for _, deliveryBonus := range b.Rules.DeliveryBonuses {
+		if (deliveryBonus.Hex == nil || *deliveryBonus.Hex == coordinate) &&
+			(deliveryBonus.Color == common.NONE_COLOR || deliveryBonus.Color == color) {
+			bonus += deliveryBonus.Bonus
+		}
+	}
+	return bonus
+}
+
+func (b *basicMap) ShouldPutDeliveryInBag(color common.Color) bool {
+	if b.Rules != nil && slices.Contains(b.Rules.RemovedOnDelivery, color) {
+		return false
+	}
+	return b.AbstractGameMapImpl.ShouldPutDeliveryInBag(color)
+}
+
+func (b *basicMap) PostGoodsGrowthHook(gameState *common.GameState, randProvider common.RandProvider, log LogFun) error {
+	err := b.AbstractGameMapImpl.PostGoodsGrowthHook(gameState, randProvider, log)
+	if err != nil || b.Rules == nil {
+		return err
+	}
+
+	for _, extra := range b.Rules.ExtraGoodsGrowth {
+		if !extra.includes(gameState.TurnNumber) {
+			continue
+		}
+		name := b.Hexes[extra.Hex.Y][extra.Hex.X].Name
+		count := extra.Count
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			color, err := gameState.DrawCube(randProvider)
+			if err != nil {
+				return err
+			}
+			if color == common.NONE_COLOR {
+				log("No cube was drawn to add to %s because the bag is empty.", name)
+				break
+			}
+			gameState.Cubes = append(gameState.Cubes, &common.BoardCube{
+				Color: color,
+				Hex:   extra.Hex,
+			})
+			log("A %s cube was drawn and added to %s.", color.String(), name)
+		}
+	}
+	return nil
+}
+
+func (b *basicMap) LocationBlocksCubePassage(cube common.Color, hex common.Coordinate) bool {
+	if b.Rules != nil {
+		for _, restriction := range b.Rules.CubeRestrictions {
+			if restriction.BlocksPassage && restriction.applies(cube, hex) {
+				return true
+			}
+		}
+	}
+	return b.AbstractGameMapImpl.LocationBlocksCubePassage(cube, hex)
+}
+
+func (b *basicMap) LocationCanAcceptCube(cube common.Color, hex common.Coordinate) bool {
+	if b.Rules != nil {
+		for _, restriction := range b.Rules.CubeRestrictions {
+			if restriction.AcceptsCube && restriction.applies(cube, hex) {
+				return true
+			}
+		}
+	}
+	return b.AbstractGameMapImpl.LocationCanAcceptCube(cube, hex)
+}
+
+func (b *basicMap) GetBuildLimit(gameState *common.GameState, player string) (int, error) {
+	if b.Rules != nil {
+		action := gameState.PlayerActions[player]
+		var defaultLimit *buildLimit
+		for _, limit := range b.Rules.BuildLimits {
+			if limit.Action == "" {
+				defaultLimit = limit
+			} else if limit.Action == action {
+				return limit.Limit, nil
+			}
+		}
+		if defaultLimit != nil {
+			return defaultLimit.Limit, nil
+		}
+	}
+	return b.AbstractGameMapImpl.GetBuildLimit(gameState, player)
+}
+
+func (b *basicMap) GetIncomeReduction(gameState *common.GameState, player string) (int, error) {
+	reduction, err := b.AbstractGameMapImpl.GetIncomeReduction(gameState, player)
+	if err != nil || b.Rules == nil {
+		return reduction, err
+	}
+	for _, multiplier := range b.Rules.IncomeReductionMultipliers {
+		if multiplier.includes(gameState.TurnNumber) {
+			reduction *= multiplier.Multiplier
+		}
+	}
+	return reduction, nil
+}
+
+func (b *basicMap) GetTotalBuildCost(gameState *common.GameState, player string, costs []int) int {
+	totalCost := b.AbstractGameMapImpl.GetTotalBuildCost(gameState, player, costs)
+	if b.Rules == nil || gameState.PlayerActions[player] != common.ENGINEER_SPECIAL_ACTION || len(costs) == 0 {
+		return totalCost
+	}
+
+	switch b.Rules.EngineerDiscount {
+	case HALF_MOST_EXPENSIVE_ENGINEER_DISCOUNT:
+		return totalCost - slices.Max(costs)/2
+	case MOST_EXPENSIVE_FREE_ENGINEER_DISCOUNT:
+		return totalCost - slices.Max(costs)
+	}
+	return totalCost
+}
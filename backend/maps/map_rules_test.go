@@ -0,0 +1,97 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeliveryBonus(t *testing.T) {
+	perth := common.Coordinate{X: 1, Y: 16}
+	elsewhere := common.Coordinate{X: 2, Y: 3}
+	gameMap := &basicMap{Rules: &mapRules{
+		DeliveryBonuses: []*deliveryBonus{
+			{Hex: &perth, Bonus: 3},
+			{Color: common.WHITE, Bonus: 1},
+		},
+	}}
+
+	assert.Equal(t, 3, gameMap.GetDeliveryBonus(perth, common.RED))
+	assert.Equal(t, 4, gameMap.GetDeliveryBonus(perth, common.WHITE))
+	assert.Equal(t, 1, gameMap.GetDeliveryBonus(elsewhere, common.WHITE))
+	assert.Equal(t, 0, gameMap.GetDeliveryBonus(elsewhere, common.RED))
+	assert.Equal(t, 0, (&basicMap{}).GetDeliveryBonus(perth, common.RED))
+}
+
+func TestCubeRestrictions(t *testing.T) {
+	port := common.Coordinate{X: 3, Y: 1}
+	city := common.Coordinate{X: 2, Y: 20}
+	gameMap := &basicMap{Rules: &mapRules{
+		CubeRestrictions: []*cubeRestriction{
+			{Hexes: []common.Coordinate{port}, BlocksPassage: true},
+			{Hexes: []common.Coordinate{city}, Colors: []common.Color{common.WHITE}, BlocksPassage: true, AcceptsCube: true},
+		},
+	}}
+
+	assert.True(t, gameMap.LocationBlocksCubePassage(common.RED, port))
+	assert.False(t, gameMap.LocationCanAcceptCube(common.RED, port))
+	assert.True(t, gameMap.LocationBlocksCubePassage(common.WHITE, city))
+	assert.True(t, gameMap.LocationCanAcceptCube(common.WHITE, city))
+	assert.False(t, gameMap.LocationBlocksCubePassage(common.RED, city))
+	assert.False(t, gameMap.LocationCanAcceptCube(common.RED, city))
+}
+
+func TestGetIncomeReduction(t *testing.T) {
+	testCase := func(expectedReduction int, turn int, income int) func(t *testing.T) {
+		return func(t *testing.T) {
+			playerId := "123"
+			gameMap := &basicMap{Rules: &mapRules{
+				IncomeReductionMultipliers: []*incomeReductionMultiplier{
+					{turnRange: turnRange{FromTurn: 4, ToTurn: 4}, Multiplier: 2},
+				},
+			}}
+			gameState := &common.GameState{
+				TurnNumber:   turn,
+				PlayerIncome: map[string]int{playerId: income},
+			}
+
+			reduction, err := gameMap.GetIncomeReduction(gameState, playerId)
+			require.NoError(t, err)
+			assert.Equal(t, expectedReduction, reduction)
+		}
+	}
+
+	t.Run("regular reduction before the turn range",
+		testCase(2, 3, 15))
+
+	t.Run("doubled reduction during the turn range",
+		testCase(4, 4, 15))
+
+	t.Run("regular reduction after the turn range",
+		testCase(2, 5, 15))
+}
+
+func TestEngineerDiscount(t *testing.T) {
+	testCase := func(expectedCost int, discount EngineerDiscount, costs []int) func(t *testing.T) {
+		return func(t *testing.T) {
+			playerId := "123"
+			gameMap := &basicMap{Rules: &mapRules{EngineerDiscount: discount}}
+			gameState := &common.GameState{
+				PlayerActions: map[string]common.SpecialAction{playerId: common.ENGINEER_SPECIAL_ACTION},
+			}
+
+			assert.Equal(t, expectedCost, gameMap.GetTotalBuildCost(gameState, playerId, costs))
+		}
+	}
+
+	t.Run("no discount",
+		testCase(15, NO_ENGINEER_DISCOUNT, []int{4, 5, 6}))
+
+	t.Run("half of the most expensive build",
+		testCase(12, HALF_MOST_EXPENSIVE_ENGINEER_DISCOUNT, []int{4, 5, 6}))
+
+	t.Run("most expensive build is free",
+		testCase(9, MOST_EXPENSIVE_FREE_ENGINEER_DISCOUNT, []int{4, 5, 6}))
+}
@@ -0,0 +1,68 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intOption(value int) *int {
+	return &value
+}
+
+func TestStartingCashAndShares(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		options                *common.GameOptions
+		expectedStartingCash   int
+		expectedStartingShares int
+	}{
+		{"defaults without options", nil, common.DEFAULT_STARTING_CASH, common.DEFAULT_STARTING_SHARES},
+		{"defaults with unset options", &common.GameOptions{TurnLimit: 4}, common.DEFAULT_STARTING_CASH, common.DEFAULT_STARTING_SHARES},
+		{"overridden", &common.GameOptions{StartingCash: intOption(20), StartingShares: intOption(3)}, 20, 3},
+		{"overridden with zero", &common.GameOptions{StartingCash: intOption(0), StartingShares: intOption(0)}, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedStartingCash, tc.options.GetStartingCash())
+			assert.Equal(t, tc.expectedStartingShares, tc.options.GetStartingShares())
+		})
+	}
+}
+
+func TestGameOptionLimits(t *testing.T) {
+	gameMap, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name                string
+		options             *common.GameOptions
+		expectedSharesLimit int
+		expectedTurnLimit   int
+	}{
+		{"map limits without options", nil, 15, 10},
+		{"map limits with unset options", &common.GameOptions{StartingCash: intOption(20)}, 15, 10},
+		{"overridden shares limit", &common.GameOptions{SharesLimit: 8}, 8, 10},
+		{"overridden turn limit", &common.GameOptions{TurnLimit: 4}, 15, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gameState := newVariantTestGameState()
+			gameState.Options = tc.options
+			assert.Equal(t, tc.expectedSharesLimit, SharesLimit(gameMap, gameState))
+			assert.Equal(t, tc.expectedTurnLimit, TurnLimit(gameMap, gameState))
+		})
+	}
+}
+
+func TestNoTurnOrderPass(t *testing.T) {
+	gameMap, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+
+	gameState := newVariantTestGameState()
+	assert.True(t, gameMap.IsSpecialActionAvailable(gameState, common.TURN_ORDER_PASS_SPECIAL_ACTION))
+
+	gameState.Options = &common.GameOptions{NoTurnOrderPass: true}
+	assert.False(t, gameMap.IsSpecialActionAvailable(gameState, common.TURN_ORDER_PASS_SPECIAL_ACTION))
+	assert.True(t, gameMap.IsSpecialActionAvailable(gameState, common.ENGINEER_SPECIAL_ACTION))
+}
@@ -112,11 +112,3 @@ func (m *scotlandMap) GetTeleportLinkBuildCost(gameState *common.GameState, play
 	}
 	return m.basicMap.GetTeleportLinkBuildCost(gameState, player, hex, direction)
 }
-
-func loadScotlandMap() (GameMap, error) {
-	b, err := loadBasicMap("maps/scotland.json")
-	if err != nil {
-		return nil, err
-	}
-	return &scotlandMap{b}, nil
-}
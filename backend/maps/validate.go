@@ -0,0 +1,194 @@
+package maps
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+)
+
+// Goods growth columns 0-11 belong to cities on the map; the rest belong to new cities
+const mapGoodsGrowthColumns = 12
+
+// The most players any map can support
+const maxPlayerCount = 6
+
+// validatedMap is implemented by maps that can check their map file for structural problems
+type validatedMap interface {
+	validate() []string
+}
+
+// renderMapHex renders a coordinate for a problem report. Coordinates off the top or left of the board have no label.
+func renderMapHex(hex common.Coordinate) string {
+	if hex.X < 0 || hex.Y < 0 {
+		return fmt.Sprintf("(%d, %d)", hex.X, hex.Y)
+	}
+	return common.RenderHexCoordinate(hex)
+}
+
+func (b *basicMap) isOnBoard(hex common.Coordinate) bool {
+	return hex.Y >= 0 && hex.Y < len(b.Hexes) && hex.X >= 0 && hex.X < len(b.Hexes[hex.Y])
+}
+
+func (b *basicMap) validate() []string {
+	return b.validateMap(func(hex common.Coordinate) bool { return false })
+}
+
+// validateMap returns every structural problem found in the map. Cities normally need a color, unless
+// colorDrawnAtSetup returns true for them.
+func (b *basicMap) validateMap(colorDrawnAtSetup func(hex common.Coordinate) bool) []string {
+	var problems []string
+	problem := func(format string, a ...any) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if len(b.Hexes) == 0 || len(b.Hexes[0]) == 0 {
+		return []string{"the map has no hexes"}
+	}
+
+	width := len(b.Hexes[0])
+	fixedStartingCubes := slices.Contains(b.Variants, common.FIXED_STARTING_CUBES_VARIANT)
+	goodsGrowthHexes := make(map[int]common.Coordinate)
+	for y, row := range b.Hexes {
+		if len(row) != width {
+			problem("the row starting at %s has %d hexes instead of %d", renderMapHex(common.Coordinate{X: 0, Y: y}),
+				len(row), width)
+		}
+		for x, hex := range row {
+			coord := common.Coordinate{X: x, Y: y}
+			if hex == nil {
+				problem("hex %s is missing", renderMapHex(coord))
+				continue
+			}
+			if hex.HexType < OFFBOARD_HEX_TYPE || hex.HexType > HILLS_HEX_TYPE {
+				problem("hex %s has unknown type %d", renderMapHex(coord), hex.HexType)
+			}
+			if !slices.Contains(common.ALL_COLORS, hex.CityColor) {
+				problem("hex %s has unknown city color %d", renderMapHex(coord), hex.CityColor)
+			} else if hex.HexType == CITY_HEX_TYPE && hex.CityColor == common.NONE_COLOR && !colorDrawnAtSetup(coord) {
+				problem("city %s at %s has no color", hex.Name, renderMapHex(coord))
+			} else if hex.HexType != CITY_HEX_TYPE && hex.CityColor != common.NONE_COLOR {
+				problem("hex %s has a city color but isn't a city", renderMapHex(coord))
+			}
+
+			for _, goodsGrowth := range hex.GoodsGrowth {
+				if hex.HexType != CITY_HEX_TYPE {
+					problem("hex %s has goods growth %d but isn't a city", renderMapHex(coord), goodsGrowth)
+				}
+				if goodsGrowth < 0 || goodsGrowth >= mapGoodsGrowthColumns {
+					problem("hex %s has goods growth %d, which is out of range", renderMapHex(coord), goodsGrowth)
+				} else if other, ok := goodsGrowthHexes[goodsGrowth]; ok {
+					problem("goods growth %d is on both %s and %s", goodsGrowth, renderMapHex(other), renderMapHex(coord))
+				} else {
+					goodsGrowthHexes[goodsGrowth] = coord
+				}
+			}
+
+			if (fixedStartingCubes || len(hex.FixedStartingCubes) > 0) && len(hex.FixedStartingCubes) != hex.StartingCubeCount {
+				problem("hex %s has %d fixed starting cubes instead of %d", renderMapHex(coord), len(hex.FixedStartingCubes),
+					hex.StartingCubeCount)
+			}
+			for _, color := range hex.FixedStartingCubes {
+				if color == common.NONE_COLOR || !slices.Contains(common.ALL_COLORS, color) {
+					problem("hex %s has unknown fixed starting cube color %d", renderMapHex(coord), color)
+				}
+			}
+		}
+	}
+
+	// Maps can leave out columns at the end of the goods growth chart (e.g. Scotland only uses six), but not in between
+	lastColumn := -1
+	for goodsGrowth := range goodsGrowthHexes {
+		lastColumn = max(lastColumn, goodsGrowth)
+	}
+	for goodsGrowth := 0; goodsGrowth < lastColumn; goodsGrowth++ {
+		if _, ok := goodsGrowthHexes[goodsGrowth]; !ok {
+			problem("no city has goods growth %d", goodsGrowth)
+		}
+	}
+
+	for i, link := range b.TeleportLinks {
+		for _, side := range []struct {
+			name string
+			edge *teleportLinkEdge
+		}{{"left", link.Left}, {"right", link.Right}} {
+			if side.edge == nil {
+				problem("teleport link %d is missing its %s side", i, side.name)
+				continue
+			}
+			if !b.isOnBoard(side.edge.Hex) {
+				problem("the %s side of teleport link %d is off the board at %s", side.name, i, renderMapHex(side.edge.Hex))
+			}
+			if !slices.Contains(common.ALL_DIRECTIONS, side.edge.Direction) {
+				problem("the %s side of teleport link %d has unknown direction %d", side.name, i, side.edge.Direction)
+			}
+		}
+		if !b.isOnBoard(link.CostLocation) {
+			problem("the cost of teleport link %d is off the board at %s", i, renderMapHex(link.CostLocation))
+		}
+	}
+
+	for i, playerCount := range b.PlayerCounts {
+		if playerCount < 2 || playerCount > maxPlayerCount {
+			problem("player count %d is out of range", playerCount)
+		} else if i > 0 && playerCount <= b.PlayerCounts[i-1] {
+			problem("player counts must be in increasing order")
+		}
+	}
+
+	for _, variant := range b.Variants {
+		if !slices.Contains(common.ALL_MAP_VARIANTS, variant) {
+			problem("unknown variant %q", variant)
+		}
+	}
+
+	if b.Rules != nil {
+		problems = append(problems, b.Rules.validate(b)...)
+	}
+
+	return problems
+}
+
+// validate checks that the hexes the rules refer to are on the board
+func (r *mapRules) validate(b *basicMap) []string {
+	var problems []string
+	checkHex := func(rule string, hex common.Coordinate) {
+		if !b.isOnBoard(hex) {
+			problems = append(problems, fmt.Sprintf("%s rule refers to %s, which is off the board", rule, renderMapHex(hex)))
+		}
+	}
+
+	for _, bonus := range r.DeliveryBonuses {
+		if bonus.Hex != nil {
+			checkHex("deliveryBonuses", *bonus.Hex)
+		}
+	}
+	for _, extra := range r.ExtraGoodsGrowth {
+		checkHex("extraGoodsGrowth", extra.Hex)
+	}
+	for _, restriction := range r.CubeRestrictions {
+		for _, hex := range restriction.Hexes {
+			checkHex("cubeRestrictions", hex)
+		}
+	}
+	return problems
+}
+
+// FormatMapProblems renders the problems found in each map file as one line per problem, ordered by file name
+func FormatMapProblems(problems map[string][]string) string {
+	filenames := make([]string, 0, len(problems))
+	for filename := range problems {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var lines []string
+	for _, filename := range filenames {
+		for _, problem := range problems[filename] {
+			lines = append(lines, fmt.Sprintf("%s: %s", filename, problem))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,73 @@
+package maps
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShippedMapsAreValid(t *testing.T) {
+	for _, mapFile := range mapFiles {
+		t.Run(mapFile.name, func(t *testing.T) {
+			b, err := loadBasicMap(filepath.Base(mapFile.filename))
+			require.NoError(t, err)
+			gameMap := mapFile.wrap(b).(validatedMap)
+			assert.Empty(t, gameMap.validate())
+		})
+	}
+}
+
+func TestValidateMap(t *testing.T) {
+	city := func(color common.Color, goodsGrowth ...int) *basicMapHex {
+		return &basicMapHex{HexType: CITY_HEX_TYPE, Name: "Springfield", CityColor: color, GoodsGrowth: goodsGrowth}
+	}
+	plains := &basicMapHex{HexType: PLAINS_HEX_TYPE}
+	startingCubes := city(common.BLUE, 0)
+	startingCubes.StartingCubeCount = 2
+	startingCubes.FixedStartingCubes = []common.Color{common.RED}
+	gameMap := &basicMap{
+		Hexes: [][]*basicMapHex{
+			{city(common.RED, 0), plains, startingCubes},
+			{plains, city(common.NONE_COLOR, 3)},
+			{plains, {HexType: PLAINS_HEX_TYPE, GoodsGrowth: []int{1}}, nil},
+		},
+		TeleportLinks: []teleportLink{{
+			Left:         &teleportLinkEdge{Hex: common.Coordinate{X: 0, Y: 0}, Direction: common.SOUTH},
+			Right:        &teleportLinkEdge{Hex: common.Coordinate{X: 5, Y: 1}, Direction: common.NORTH},
+			CostLocation: common.Coordinate{X: 0, Y: 0},
+		}},
+		Rules: &mapRules{
+			ExtraGoodsGrowth: []*extraGoodsGrowth{{Hex: common.Coordinate{X: 0, Y: -1}}},
+		},
+		PlayerCounts: []int{3, 3, 7},
+		Variants:     []common.MapVariant{common.FIXED_STARTING_CUBES_VARIANT, "aliens"},
+	}
+
+	assert.Equal(t, []string{
+		"goods growth 0 is on both A1 and A5",
+		"hex A5 has 1 fixed starting cubes instead of 2",
+		"the row starting at B2 has 2 hexes instead of 3",
+		"city Springfield at B4 has no color",
+		"hex C3 has goods growth 1 but isn't a city",
+		"hex C5 is missing",
+		"no city has goods growth 2",
+		"the right side of teleport link 0 is off the board at B12",
+		"player counts must be in increasing order",
+		"player count 7 is out of range",
+		`unknown variant "aliens"`,
+		"extraGoodsGrowth rule refers to (0, -1), which is off the board",
+	}, gameMap.validate())
+}
+
+func TestFormatMapProblems(t *testing.T) {
+	problems := map[string][]string{
+		"maps/b.json": {"hex A1 is missing"},
+		"maps/a.json": {"no city has goods growth 2", "hex C5 is missing"},
+	}
+	assert.Equal(t, "maps/a.json: no city has goods growth 2\n"+
+		"maps/a.json: hex C5 is missing\n"+
+		"maps/b.json: hex A1 is missing", FormatMapProblems(problems))
+}
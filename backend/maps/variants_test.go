@@ -0,0 +1,106 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVariantTestGameState(variants ...common.MapVariant) *common.GameState {
+	return &common.GameState{
+		PlayerOrder:  []string{"p1", "p2"},
+		PlayerShares: map[string]int{"p1": 2, "p2": 2},
+		PlayerCash:   map[string]int{"p1": 10, "p2": 10},
+		TurnNumber:   1,
+		CubeBag: map[common.Color]int{
+			common.BLACK:  16,
+			common.RED:    20,
+			common.YELLOW: 20,
+			common.BLUE:   20,
+			common.PURPLE: 20,
+		},
+		MapVariants: variants,
+	}
+}
+
+func TestNoFirstTurnUrbanization(t *testing.T) {
+	gameMap, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name              string
+		variants          []common.MapVariant
+		turnNumber        int
+		action            common.SpecialAction
+		expectedAvailable bool
+	}{
+		{"urbanization is available without the variant", nil, 1, common.URBANIZATION_SPECIAL_ACTION, true},
+		{"urbanization isn't available on the first turn", []common.MapVariant{common.NO_FIRST_TURN_URBANIZATION_VARIANT}, 1, common.URBANIZATION_SPECIAL_ACTION, false},
+		{"urbanization is available after the first turn", []common.MapVariant{common.NO_FIRST_TURN_URBANIZATION_VARIANT}, 2, common.URBANIZATION_SPECIAL_ACTION, true},
+		{"other actions are available on the first turn", []common.MapVariant{common.NO_FIRST_TURN_URBANIZATION_VARIANT}, 1, common.ENGINEER_SPECIAL_ACTION, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gameState := newVariantTestGameState(tc.variants...)
+			gameState.TurnNumber = tc.turnNumber
+			assert.Equal(t, tc.expectedAvailable, gameMap.IsSpecialActionAvailable(gameState, tc.action))
+		})
+	}
+}
+
+func TestFixedStartingCubes(t *testing.T) {
+	gameMap, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+	gameState := newVariantTestGameState(common.FIXED_STARTING_CUBES_VARIANT)
+
+	// No random draws should be made
+	err = gameMap.PopulateStartingCubes(gameState, nil)
+	require.NoError(t, err)
+
+	total := 0
+	for y, row := range gameMap.Hexes {
+		for x, hex := range row {
+			var cubes []common.Color
+			for _, cube := range gameState.Cubes {
+				if cube.Hex == (common.Coordinate{X: x, Y: y}) {
+					cubes = append(cubes, cube.Color)
+				}
+			}
+			assert.Equal(t, hex.FixedStartingCubes, cubes, hex.Name)
+			total += len(cubes)
+		}
+	}
+	assert.Equal(t, 26, total)
+
+	bagTotal := 0
+	for _, count := range gameState.CubeBag {
+		bagTotal += count
+	}
+	assert.Equal(t, 96-26, bagTotal)
+}
+
+func TestFirstTurnExtraShare(t *testing.T) {
+	gameMap, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+
+	gameState := newVariantTestGameState()
+	require.NoError(t, gameMap.PostSetupHook(gameState, nil))
+	assert.Equal(t, map[string]int{"p1": 2, "p2": 2}, gameState.PlayerShares)
+	assert.Equal(t, map[string]int{"p1": 10, "p2": 10}, gameState.PlayerCash)
+
+	gameState = newVariantTestGameState(common.FIRST_TURN_EXTRA_SHARE_VARIANT)
+	require.NoError(t, gameMap.PostSetupHook(gameState, nil))
+	assert.Equal(t, map[string]int{"p1": 3, "p2": 3}, gameState.PlayerShares)
+	assert.Equal(t, map[string]int{"p1": 15, "p2": 15}, gameState.PlayerCash)
+}
+
+func TestSupportedVariants(t *testing.T) {
+	rustBelt, err := loadBasicMap("rust_belt.json")
+	require.NoError(t, err)
+	assert.Equal(t, common.ALL_MAP_VARIANTS, rustBelt.GetSupportedVariants())
+
+	scotland, err := loadBasicMap("scotland.json")
+	require.NoError(t, err)
+	assert.Empty(t, (&scotlandMap{scotland}).GetSupportedVariants())
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// A migration moves the database schema from the previous version to this one. Statements are run one at a time, since
+// the MySQL driver does not accept multiple statements in a single query, and must be valid for both SQLite and MySQL.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// Migrations must never be edited once released; add a new migration instead. Versions must start at 1 and increase by
+// one with each migration.
+var migrations = []*migration{
+	{
+		version:     1,
+		description: "initial schema",
+		// These use IF NOT EXISTS so that databases created before migrations existed can be brought under version control
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				id varchar(255) PRIMARY KEY,
+				nickname text,
+				email text,
+				google_user_id text,
+				discord_user_id text,
+				color_preferences text,
+				custom_colors text,
+				email_notifications_enabled int,
+				discord_turn_alerts_enabled int,
+				webhooks text
+			)`,
+			`CREATE TABLE IF NOT EXISTS games (
+				id varchar(255) PRIMARY KEY,
+				created_at int,
+				name text,
+				min_players int,
+				max_players int,
+				map_name text,
+				owner_user_id text,
+				started int,
+				finished int,
+				game_state longtext,
+				active_player_id text,
+				invite_only int
+			)`,
+			`CREATE TABLE IF NOT EXISTS game_player_map (
+				game_id text,
+				player_user_id text
+			)`,
+			`CREATE TABLE IF NOT EXISTS game_log (
+				game_id varchar(255),
+				timestamp int,
+				user_id text,
+				action text,
+				description text,
+				new_active_player text,
+				new_game_state longtext,
+				reversible int,
+				PRIMARY KEY (game_id, timestamp)
+			)`,
+			`CREATE TABLE IF NOT EXISTS game_chat (
+				game_id varchar(255),
+				timestamp int,
+				user_id text,
+				message text,
+				PRIMARY KEY (game_id, timestamp)
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "add bot players",
+		statements: []string{
+			"ALTER TABLE users ADD COLUMN bot_type text",
+		},
+	},
+	{
+		version:     3,
+		description: "add move time limits",
+		statements: []string{
+			// Zero or null for no limit
+			"ALTER TABLE games ADD COLUMN move_time_limit_hours int",
+		},
+	},
+	{
+		version:     4,
+		description: "add player ratings",
+		statements: []string{
+			// An empty map name or zero player count holds the rating across all maps or player counts
+			`CREATE TABLE player_ratings (
+				user_id varchar(255),
+				map_name varchar(255),
+				player_count int,
+				rating double,
+				games_played int,
+				PRIMARY KEY (user_id, map_name, player_count)
+			)`,
+		},
+	},
+	{
+		version:     5,
+		description: "add final scores",
+		statements: []string{
+			"ALTER TABLE games ADD COLUMN final_scores text",
+		},
+	},
+	{
+		version:     6,
+		description: "record random draws",
+		statements: []string{
+			// JSON array of every random value drawn during the move; null for moves logged before this
+			"ALTER TABLE game_log ADD COLUMN random_values text",
+		},
+	},
+	{
+		version:     7,
+		description: "add provably fair randomness",
+		statements: []string{
+			// The seed is null for games using the server's regular source of randomness
+			"ALTER TABLE games ADD COLUMN random_seed text",
+			"ALTER TABLE games ADD COLUMN random_seed_commitment text",
+			"ALTER TABLE games ADD COLUMN random_draw_count int",
+			// JSON array of the bound each random value was drawn with
+			"ALTER TABLE game_log ADD COLUMN random_bounds text",
+		},
+	},
+	{
+		version:     8,
+		description: "add game rollbacks",
+		statements: []string{
+			// At most one rollback can be pending for a game at a time
+			`CREATE TABLE game_rollbacks (
+				game_id varchar(255) PRIMARY KEY,
+				requested_by text,
+				target_timestamp int,
+				requested_at int
+			)`,
+			`CREATE TABLE game_rollback_approvals (
+				game_id varchar(255),
+				user_id varchar(255),
+				PRIMARY KEY (game_id, user_id)
+			)`,
+		},
+	},
+	{
+		version:     9,
+		description: "order game logs and chats by sequence number",
+		// Timestamps are only to the second, so rows added in the same second collided. The tables are rebuilt, since
+		// SQLite can't change a primary key, numbering the existing rows of each game from 1 in timestamp order.
+		statements: []string{
+			`CREATE TABLE game_log_v9 (
+				game_id varchar(255),
+				seq int,
+				timestamp int,
+				user_id text,
+				action text,
+				description text,
+				new_active_player text,
+				new_game_state longtext,
+				reversible int,
+				random_values text,
+				random_bounds text,
+				PRIMARY KEY (game_id, seq)
+			)`,
+			`INSERT INTO game_log_v9 (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds)
+				SELECT game_id,
+					(SELECT COUNT(*) FROM game_log AS earlier WHERE earlier.game_id=game_log.game_id AND earlier.timestamp<=game_log.timestamp),
+					timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds
+				FROM game_log`,
+			"DROP TABLE game_log",
+			"ALTER TABLE game_log_v9 RENAME TO game_log",
+			`CREATE TABLE game_chat_v9 (
+				game_id varchar(255),
+				seq int,
+				timestamp int,
+				user_id text,
+				message text,
+				PRIMARY KEY (game_id, seq)
+			)`,
+			`INSERT INTO game_chat_v9 (game_id,seq,timestamp,user_id,message)
+				SELECT game_id,
+					(SELECT COUNT(*) FROM game_chat AS earlier WHERE earlier.game_id=game_chat.game_id AND earlier.timestamp<=game_chat.timestamp),
+					timestamp,user_id,message
+				FROM game_chat`,
+			"DROP TABLE game_chat",
+			"ALTER TABLE game_chat_v9 RENAME TO game_chat",
+			// Pending rollbacks point at their log entry by sequence number too
+			"ALTER TABLE game_rollbacks ADD COLUMN target_seq int",
+			`UPDATE game_rollbacks SET target_seq=(SELECT seq FROM game_log
+				WHERE game_log.game_id=game_rollbacks.game_id AND game_log.timestamp=game_rollbacks.target_timestamp)`,
+			"ALTER TABLE game_rollbacks DROP COLUMN target_timestamp",
+		},
+	},
+	{
+		version:     10,
+		description: "add game versions",
+		statements: []string{
+			// Incremented whenever the game's state changes, so that concurrent moves can be detected
+			"ALTER TABLE games ADD COLUMN version int NOT NULL DEFAULT 0",
+		},
+	},
+	{
+		version:     11,
+		description: "add custom maps",
+		statements: []string{
+			// Games on a custom map have a map_name of "custom:" followed by the map's id
+			`CREATE TABLE maps (
+				id varchar(255) PRIMARY KEY,
+				name text,
+				owner_user_id text,
+				visibility text,
+				map_data longtext,
+				created_at int
+			)`,
+		},
+	},
+	{
+		version:     12,
+		description: "add map variants",
+		statements: []string{
+			// A JSON array of the variant rules the game was created with
+			"ALTER TABLE games ADD COLUMN map_variants text",
+		},
+	},
+	{
+		version:     13,
+		description: "add game options",
+		statements: []string{
+			// A JSON object of the house rules the game was created with
+			"ALTER TABLE games ADD COLUMN game_options text",
+		},
+	},
+}
+
+// getSchemaVersion returns the version of the latest migration applied to the database, or zero if none have been
+func getSchemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_version (version int PRIMARY KEY, applied_at int)")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema_version table: %v", err)
+	}
+
+	var version sql.NullInt64
+	err = db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query schema version: %v", err)
+	}
+	return int(version.Int64), nil
+}
+
+// migrateDatabase applies any migrations that haven't yet been applied to the database
+func migrateDatabase(db *sql.DB) error {
+	version, err := getSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		// MySQL commits implicitly after schema changes, so this only makes a failed migration atomic on SQLite
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		for _, statement := range m.statements {
+			_, err = tx.Exec(statement)
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to apply migration %d (%s): %v", m.version, m.description, err)
+			}
+		}
+		_, err = tx.Exec("INSERT INTO schema_version (version,applied_at) VALUES (?,?)", m.version, time.Now().Unix())
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %v", m.version, err)
+		}
+		err = tx.Commit()
+		if err != nil {
+			return fmt.Errorf("failed to commit migration %d: %v", m.version, err)
+		}
+		slog.Info("Applied database migration", "version", m.version, "description", m.description)
+	}
+
+	return nil
+}
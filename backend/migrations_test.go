@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationVersions(t *testing.T) {
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.version, "migration %q is out of sequence", m.description)
+		assert.NotEmpty(t, m.statements)
+	}
+}
+
+func TestMigrateDatabase(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	version, err := getSchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+
+	err = migrateDatabase(db)
+	require.NoError(t, err)
+	version, err = getSchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	// Running again is a no-op
+	err = migrateDatabase(db)
+	require.NoError(t, err)
+	version, err = getSchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	_, err = db.Exec("INSERT INTO users (id,nickname,bot_type) VALUES ('bot','Bot 1','heuristic')")
+	require.NoError(t, err)
+}
+
+func TestMigrateUnversionedDatabase(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// A database created before migrations existed already has the initial tables, with data in them
+	for _, statement := range migrations[0].statements {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec("INSERT INTO games (id,name,started,finished) VALUES ('game1','game-name',1,1)")
+	require.NoError(t, err)
+
+	err = migrateDatabase(db)
+	require.NoError(t, err)
+	version, err := getSchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), version)
+
+	var name string
+	var finalScores sql.NullString
+	err = db.QueryRow("SELECT name,final_scores FROM games WHERE id='game1'").Scan(&name, &finalScores)
+	require.NoError(t, err)
+	assert.Equal(t, "game-name", name)
+	assert.False(t, finalScores.Valid)
+}
+
+func TestMigrateSequenceNumbers(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Bring the database up to the version before sequence numbers, with rows keyed by timestamp
+	_, err = getSchemaVersion(db)
+	require.NoError(t, err)
+	for _, m := range migrations[:8] {
+		for _, statement := range m.statements {
+			_, err = db.Exec(statement)
+			require.NoError(t, err)
+		}
+	}
+	_, err = db.Exec("INSERT INTO schema_version (version,applied_at) VALUES (8,0)")
+	require.NoError(t, err)
+	for _, statement := range []string{
+		"INSERT INTO game_log (game_id,timestamp,description) VALUES ('game1',300,'third')",
+		"INSERT INTO game_log (game_id,timestamp,description) VALUES ('game1',100,'first')",
+		"INSERT INTO game_log (game_id,timestamp,description) VALUES ('game2',50,'other')",
+		"INSERT INTO game_log (game_id,timestamp,description) VALUES ('game1',200,'second')",
+		"INSERT INTO game_chat (game_id,timestamp,message) VALUES ('game1',20,'bye')",
+		"INSERT INTO game_chat (game_id,timestamp,message) VALUES ('game1',10,'hi')",
+		"INSERT INTO game_rollbacks (game_id,requested_by,target_timestamp,requested_at) VALUES ('game1','user1',200,0)",
+	} {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	err = migrateDatabase(db)
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT seq,description FROM game_log WHERE game_id='game1' ORDER BY seq")
+	require.NoError(t, err)
+	var descriptions []string
+	for seq := 1; rows.Next(); seq++ {
+		var rowSeq int
+		var description string
+		require.NoError(t, rows.Scan(&rowSeq, &description))
+		assert.Equal(t, seq, rowSeq)
+		descriptions = append(descriptions, description)
+	}
+	rows.Close()
+	assert.Equal(t, []string{"first", "second", "third"}, descriptions)
+
+	var seq int
+	err = db.QueryRow("SELECT seq FROM game_log WHERE game_id='game2'").Scan(&seq)
+	require.NoError(t, err)
+	assert.Equal(t, 1, seq)
+	err = db.QueryRow("SELECT seq FROM game_chat WHERE game_id='game1' AND message='bye'").Scan(&seq)
+	require.NoError(t, err)
+	assert.Equal(t, 2, seq)
+	err = db.QueryRow("SELECT target_seq FROM game_rollbacks WHERE game_id='game1'").Scan(&seq)
+	require.NoError(t, err)
+	assert.Equal(t, 2, seq)
+
+	// Rows logged in the same second no longer collide
+	_, err = db.Exec("INSERT INTO game_log (game_id,seq,timestamp,description) VALUES ('game1',4,300,'fourth')")
+	require.NoError(t, err)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+)
+
+// getLastMoveTime returns when the last move (or the start) of the game was logged, in epoch seconds
+func (server *GameServer) getLastMoveTime(gameId string) (int, error) {
+	stmt, err := server.db.Prepare("SELECT MAX(timestamp) FROM game_log WHERE game_id=?")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	var lastTimestamp sql.NullInt64
+	err = stmt.QueryRow(gameId).Scan(&lastTimestamp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %v", err)
+	}
+	return int(lastTimestamp.Int64), nil
+}
+
+// defaultMove returns the move made on behalf of a player who runs out of time. It is the most passive move available
+// in each phase: take no shares, pass the auction, pick the first available action, build nothing, skip moving goods,
+// and place production cubes in the first available spots.
+func (handler *confirmMoveHandler) defaultMove() (*api.ConfirmMoveRequest, error) {
+	move := &api.ConfirmMoveRequest{GameId: handler.gameId}
+	switch handler.gameState.GamePhase {
+	case common.SHARES_GAME_PHASE:
+		move.ActionName = api.SharesActionName
+		move.SharesAction = &api.SharesAction{Amount: 0}
+	case common.AUCTION_GAME_PHASE:
+		move.ActionName = api.BidActionName
+		move.BidAction = &api.BidAction{Amount: -1}
+	case common.BUILDING_GAME_PHASE:
+		move.ActionName = api.BuildActionName
+		move.BuildAction = &api.BuildAction{}
+	case common.MOVING_GOODS_GAME_PHASE:
+		move.ActionName = api.MoveGoodsActionName
+		move.MoveGoodsAction = &api.MoveGoodsAction{Color: common.NONE_COLOR}
+	case common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE, common.GOODS_GROWTH_GAME_PHASE:
+		moves, err := handler.LegalMoves()
+		if err != nil {
+			return nil, err
+		}
+		if len(moves) == 0 {
+			return nil, fmt.Errorf("no legal moves for player %s", handler.activePlayer)
+		}
+		move = moves[0]
+	default:
+		return nil, fmt.Errorf("unhandled game phase: %d", handler.gameState.GamePhase)
+	}
+	return move, nil
+}
+
+// runMoveTimeouts makes the default move for the active player in every game where they have run out of time
+func (server *GameServer) runMoveTimeouts() error {
+	stmt, err := server.db.Prepare("SELECT id,move_time_limit_hours FROM games WHERE started <> 0 AND finished=0 AND move_time_limit_hours > 0")
+	if err != nil {
+		return fmt.Errorf("failed to get games: %v", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query()
+	if err != nil {
+		return fmt.Errorf("failed to query timed games: %v", err)
+	}
+	defer rows.Close()
+
+	gameIdToTimeLimit := make(map[string]int)
+	for rows.Next() {
+		var gameId string
+		var moveTimeLimitHours int
+		err = rows.Scan(&gameId, &moveTimeLimitHours)
+		if err != nil {
+			return fmt.Errorf("failed to scan row: %v", err)
+		}
+		gameIdToTimeLimit[gameId] = moveTimeLimitHours
+	}
+	rows.Close()
+
+	now := int(time.Now().Unix())
+	for gameId, moveTimeLimitHours := range gameIdToTimeLimit {
+		lastMoveTime, err := server.getLastMoveTime(gameId)
+		if err != nil {
+			return err
+		}
+		if now < lastMoveTime+moveTimeLimitHours*60*60 {
+			continue
+		}
+
+		// One game getting stuck shouldn't hold up all the others
+		err = server.timeoutActivePlayer(gameId)
+		if err != nil {
+			slog.Error("failed to make move for timed out player", "error", err, "gameId", gameId)
+			continue
+		}
+		err = server.runBots(gameId, maxConsecutiveBotMoves)
+		if err != nil {
+			slog.Error("failed to run bot move", "error", err, "gameId", gameId)
+		}
+	}
+
+	return nil
+}
+
+// timeoutActivePlayer makes the default move on behalf of the game's active player
+func (server *GameServer) timeoutActivePlayer(gameId string) error {
+	handler, err := server.newConfirmMoveHandlerForGame(gameId)
+	if err != nil {
+		return err
+	}
+	move, err := handler.defaultMove()
+	if err != nil {
+		return err
+	}
+
+	activePlayer := handler.activePlayer
+	handler.Log("%s ran out of time, so a move was made for them.", handler.ActivePlayerNick())
+	err = handler.handleAction(move)
+	if err != nil {
+		return fmt.Errorf("default move was rejected: %v", err)
+	}
+	// The player didn't choose this move, so don't let it be undone as if they had
+	handler.reversible = false
+
+	return server.saveMove(handler, activePlayer, move)
+}
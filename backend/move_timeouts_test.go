@@ -0,0 +1,101 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveTimeouts(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:               "game-name",
+		MinPlayers:         2,
+		MaxPlayers:         2,
+		MapName:            "rust_belt",
+		MoveTimeLimitHours: 24,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 24, viewRes.MoveTimeLimitHours)
+	assert.NotZero(t, viewRes.MoveDeadline)
+	timedOutPlayer := viewRes.ActivePlayer
+
+	// Nothing happens while the active player still has time left
+	err = h.gameServer.runMoveTimeouts()
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, timedOutPlayer, viewRes.ActivePlayer)
+
+	// Pretend the game started two days ago
+	_, err = h.gameServer.db.Exec("UPDATE game_log SET timestamp=timestamp-172800 WHERE game_id=?", createRes.Id)
+	require.NoError(t, err)
+	err = h.gameServer.runMoveTimeouts()
+	require.NoError(t, err)
+
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.NotEqual(t, timedOutPlayer, viewRes.ActivePlayer)
+	assert.Equal(t, common.SHARES_GAME_PHASE, viewRes.GameState.GamePhase)
+	assert.Equal(t, 2, viewRes.GameState.PlayerShares[timedOutPlayer])
+
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	lastLog := logsRes.Logs[len(logsRes.Logs)-1]
+	assert.Equal(t, timedOutPlayer, lastLog.UserId)
+	assert.Contains(t, lastLog.Description, "ran out of time")
+	assert.False(t, lastLog.Reversible)
+
+	// The next player just got their turn, so they still have time left
+	nextPlayer := viewRes.ActivePlayer
+	err = h.gameServer.runMoveTimeouts()
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, nextPlayer, viewRes.ActivePlayer)
+}
+
+func TestDefaultMoves(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	// Time out every move through the first turn; each default move must be accepted by the rules engine
+	var viewRes *ViewGameResponse
+	for i := 0; i < 20; i++ {
+		err = h.gameServer.timeoutActivePlayer(createRes.Id)
+		require.NoError(t, err)
+		viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+		require.NoError(t, err)
+		if viewRes.GameState.TurnNumber > 1 {
+			break
+		}
+	}
+	assert.Equal(t, 2, viewRes.GameState.TurnNumber)
+	assert.Equal(t, common.SHARES_GAME_PHASE, viewRes.GameState.GamePhase)
+	assert.Empty(t, viewRes.GameState.Links)
+}
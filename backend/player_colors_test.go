@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"os"
 	"testing"
 )
 
@@ -12,9 +11,7 @@ func TestAssignPlayerColors(t *testing.T) {
 	db, err := sql.Open("sqlite", ":memory:")
 	require.NoError(t, err)
 	defer db.Close()
-	bootstrapSql, err := os.ReadFile("bootstrap.sql")
-	require.NoError(t, err)
-	_, err = db.Exec(string(bootstrapSql))
+	err = migrateDatabase(db)
 	require.NoError(t, err)
 
 	_, err = db.Exec("INSERT INTO users (id, color_preferences) VALUES ('player1','[4,3,2]'), ('player2','[4,2,3]'), ('player3','[5]'), ('player4',NULL), ('player5',NULL)")
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewMove(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	logCount := len(logsRes.Logs)
+	activePlayer := viewRes.ActivePlayer
+	otherPlayer := player1
+	if activePlayer == player1 {
+		otherPlayer = player2
+	}
+
+	req := &api.ConfirmMoveRequest{
+		GameId:       createRes.Id,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 2},
+	}
+
+	_, err = h.previewMove(t, otherPlayer, req)
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusPreconditionFailed, httpError.Code)
+
+	previewRes, err := h.previewMove(t, activePlayer, req)
+	require.NoError(t, err)
+	assert.Equal(t, otherPlayer, previewRes.ActivePlayer)
+	assert.Equal(t, viewRes.GameState.PlayerShares[activePlayer]+2, previewRes.GameState.PlayerShares[activePlayer])
+	assert.Equal(t, viewRes.GameState.PlayerCash[activePlayer]+10, previewRes.GameState.PlayerCash[activePlayer])
+	assert.NotEmpty(t, previewRes.Logs)
+	assert.Equal(t, true, previewRes.Reversible)
+	assert.Equal(t, false, previewRes.GameFinished)
+
+	// Nothing about the actual game should have changed
+	afterRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, activePlayer, afterRes.ActivePlayer)
+	assert.Equal(t, viewRes.GameState, afterRes.GameState)
+
+	logsRes, err = h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, logCount, len(logsRes.Logs))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+)
+
+// newRandomSeed generates the secret seed for a provably fair game, along with the commitment to it that is shown to
+// players until the game finishes and the seed is revealed
+func newRandomSeed() (seed string, commitment string, err error) {
+	seedBytes := make([]byte, 32)
+	_, err = rand.Read(seedBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate random seed: %v", err)
+	}
+	return hex.EncodeToString(seedBytes), randomSeedCommitment(seedBytes), nil
+}
+
+// randomSeedCommitment is the hex-encoded SHA-256 hash of the seed
+func randomSeedCommitment(seed []byte) string {
+	hash := sha256.Sum256(seed)
+	return hex.EncodeToString(hash[:])
+}
+
+// getGameRandProvider returns the source of randomness for the game's next move: the game's seeded provider, picking
+// up after the last value it drew, if the game is provably fair, or the server's provider otherwise
+func (server *GameServer) getGameRandProvider(gameId string) (common.RandProvider, error) {
+	stmt, err := server.db.Prepare("SELECT random_seed,random_draw_count FROM games WHERE id=?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	var seedStr sql.NullString
+	var drawCount sql.NullInt64
+	err = stmt.QueryRow(gameId).Scan(&seedStr, &drawCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if !seedStr.Valid {
+		return server.randProvider, nil
+	}
+
+	seed, err := hex.DecodeString(seedStr.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode random seed: %v", err)
+	}
+	return &common.SeededRandProvider{Seed: seed, Count: int(drawCount.Int64)}, nil
+}
+
+// saveRandDrawCount records how many values have been drawn from a provably fair game's seed, so that the next move
+// continues from there
+func saveRandDrawCount(db dbOrTx, gameId string, randProvider common.RandProvider) error {
+	if recorder, ok := randProvider.(*common.RecordingRandProvider); ok {
+		randProvider = recorder.Provider
+	}
+	seeded, ok := randProvider.(*common.SeededRandProvider)
+	if !ok {
+		return nil
+	}
+
+	_, err := db.Exec("UPDATE games SET random_draw_count=? WHERE id=?", seeded.Count, gameId)
+	if err != nil {
+		return fmt.Errorf("failed to save random draw count: %v", err)
+	}
+	return nil
+}
+
+// verifyRandomDraws checks a revealed seed against its commitment and against every random value recorded in the log
+func verifyRandomDraws(seedStr string, commitment string, steps []*ExportedGameStep) error {
+	seed, err := hex.DecodeString(seedStr)
+	if err != nil {
+		return fmt.Errorf("failed to decode random seed: %v", err)
+	}
+	if randomSeedCommitment(seed) != commitment {
+		return fmt.Errorf("random seed does not match its commitment")
+	}
+
+	randProvider := &common.SeededRandProvider{Seed: seed}
+	for idx, step := range steps {
+		if len(step.RandomValues) != len(step.RandomBounds) {
+			return fmt.Errorf("step %d is missing its random draws", idx)
+		}
+		for i, value := range step.RandomValues {
+			expected, err := randProvider.RandN(step.RandomBounds[i])
+			if err != nil {
+				return err
+			}
+			if value != expected {
+				return fmt.Errorf("step %d drew %d (out of %d), but the seed gives %d", idx, value,
+					step.RandomBounds[i], expected)
+			}
+		}
+	}
+	return nil
+}
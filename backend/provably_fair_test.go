@@ -0,0 +1,137 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeededRandProvider(t *testing.T) {
+	draw := func(seed string, bounds []int) []int {
+		randProvider := &common.SeededRandProvider{Seed: []byte(seed)}
+		var values []int
+		for _, n := range bounds {
+			val, err := randProvider.RandN(n)
+			require.NoError(t, err)
+			require.True(t, 0 <= val && val < n)
+			values = append(values, val)
+		}
+		assert.Equal(t, len(bounds), randProvider.Count)
+		return values
+	}
+
+	bounds := make([]int, 0)
+	for i := 0; i < 50; i++ {
+		bounds = append(bounds, 6, 100)
+	}
+	values := draw("seed", bounds)
+	assert.Equal(t, values, draw("seed", bounds))
+	assert.NotEqual(t, values, draw("other seed", bounds))
+
+	// Picking up from a count continues the same sequence
+	randProvider := &common.SeededRandProvider{Seed: []byte("seed"), Count: 10}
+	val, err := randProvider.RandN(bounds[10])
+	require.NoError(t, err)
+	assert.Equal(t, values[10], val)
+}
+
+func TestProvablyFairGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, player1 := h.startTwoPlayerGame(t, true)
+	h.playFirstTurn(t, gameId, player1)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.NotEmpty(t, viewRes.RandomSeedCommitment)
+	assert.Empty(t, viewRes.RandomSeed)
+	exportRes, err := h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Empty(t, exportRes.Game.RandomSeed)
+
+	// The seed is revealed once the game is over
+	_, err = h.gameServer.db.Exec("UPDATE games SET finished=1 WHERE id=?", gameId)
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	seed, err := hex.DecodeString(viewRes.RandomSeed)
+	require.NoError(t, err)
+	commitment := sha256.Sum256(seed)
+	assert.Equal(t, hex.EncodeToString(commitment[:]), viewRes.RandomSeedCommitment)
+
+	// Every draw in the log, including setting up the board, follows from the seed
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: gameId})
+	require.NoError(t, err)
+	randProvider := &common.SeededRandProvider{Seed: seed}
+	drawCount := 0
+	for _, entry := range logsRes.Logs {
+		require.Len(t, entry.RandomBounds, len(entry.RandomValues))
+		for i, value := range entry.RandomValues {
+			expected, err := randProvider.RandN(entry.RandomBounds[i])
+			require.NoError(t, err)
+			assert.Equal(t, expected, value)
+			drawCount += 1
+		}
+	}
+	assert.NotZero(t, drawCount)
+
+	err = runTask(h.gameServer, []string{"verify-game", gameId})
+	require.NoError(t, err)
+
+	exportRes, err = h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	err = verifyRandomDraws(hex.EncodeToString([]byte("some other seed")), exportRes.Game.RandomSeedCommitment, exportRes.Steps)
+	assert.Error(t, err)
+}
+
+func TestImportProvablyFairGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, player1 := h.startTwoPlayerGame(t, true)
+	h.playFirstTurn(t, gameId, player1)
+
+	reimport := func(export *ExportedGame) {
+		for _, table := range []string{"games WHERE id=?", "game_player_map WHERE game_id=?", "game_log WHERE game_id=?"} {
+			_, err := h.gameServer.db.Exec("DELETE FROM "+table, gameId)
+			require.NoError(t, err)
+		}
+		err := h.gameServer.importGame(export)
+		require.NoError(t, err)
+	}
+
+	// Without its seed, an unfinished game is no longer provably fair, but it can still be played and verified
+	export, err := h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	require.NotEmpty(t, export.Game.RandomSeedCommitment)
+	reimport(export)
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Empty(t, viewRes.RandomSeedCommitment)
+	assert.Empty(t, viewRes.RandomSeed)
+	err = h.gameServer.timeoutActivePlayer(gameId)
+	require.NoError(t, err)
+	err = runTask(h.gameServer, []string{"verify-game", gameId})
+	require.NoError(t, err)
+
+	// A finished game comes with its seed, so it stays provably fair
+	gameId, player1 = h.startTwoPlayerGame(t, true)
+	h.playFirstTurn(t, gameId, player1)
+	_, err = h.gameServer.db.Exec("UPDATE games SET finished=1 WHERE id=?", gameId)
+	require.NoError(t, err)
+	export, err = h.exportGame(t, player1, &ExportGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	require.NotEmpty(t, export.Game.RandomSeed)
+	reimport(export)
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	assert.Equal(t, export.Game.RandomSeedCommitment, viewRes.RandomSeedCommitment)
+	assert.Equal(t, export.Game.RandomSeed, viewRes.RandomSeed)
+	err = runTask(h.gameServer, []string{"verify-game", gameId})
+	require.NoError(t, err)
+}
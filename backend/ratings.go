@@ -0,0 +1,184 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+)
+
+// Rating given to a player before they have finished any games
+const initialRating = 1500.0
+
+// Maximum amount a rating can change as the result of a single game
+const ratingKFactor = 32.0
+
+// Number of players shown on the leaderboard
+const leaderboardSize = 100
+
+// ratingChanges computes the multiplayer Elo adjustment for each player given their place in the game, treating the
+// game as a head-to-head match between every pair of players
+func ratingChanges(ratings map[string]float64, places map[string]int) map[string]float64 {
+	changes := make(map[string]float64)
+	if len(ratings) < 2 {
+		return changes
+	}
+	for player, rating := range ratings {
+		change := 0.0
+		for opponent, opponentRating := range ratings {
+			if opponent == player {
+				continue
+			}
+			expected := 1.0 / (1.0 + math.Pow(10, (opponentRating-rating)/400.0))
+
+			actual := 0.5
+			if places[player] < places[opponent] {
+				actual = 1.0
+			} else if places[player] > places[opponent] {
+				actual = 0.0
+			}
+			change += actual - expected
+		}
+		changes[player] = ratingKFactor * change / float64(len(ratings)-1)
+	}
+	return changes
+}
+
+// updateRatings adjusts the ratings of everyone who played in a game that just finished. Ratings are tracked overall
+// and also per map and per player count, with an empty map name or zero player count meaning "any". Bots are not
+// rated, so only the human players are ranked against each other. This is run in the same transaction that saves the
+// final move, so that a game can't finish without its ratings being updated.
+func updateRatings(db dbOrTx, gameId string, scores []*PlayerScore) error {
+	var mapName string
+	err := db.QueryRow("SELECT map_name FROM games WHERE id=?", gameId).Scan(&mapName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch game row: %v", err)
+	}
+
+	places := make(map[string]int)
+	for _, score := range scores {
+		var botType sql.NullString
+		err = db.QueryRow("SELECT bot_type FROM users WHERE id=?", score.PlayerId).Scan(&botType)
+		if err != nil {
+			return fmt.Errorf("failed to fetch user row: %v", err)
+		}
+		if botType.String == "" {
+			places[score.PlayerId] = score.Place
+		}
+	}
+	playerCount := len(scores)
+
+	for _, category := range []struct {
+		mapName     string
+		playerCount int
+	}{
+		{"", 0},
+		{mapName, 0},
+		{"", playerCount},
+		{mapName, playerCount},
+	} {
+		ratings := make(map[string]float64)
+		gamesPlayed := make(map[string]int)
+		for userId := range places {
+			rating, played, err := getRating(db, userId, category.mapName, category.playerCount)
+			if err != nil {
+				return err
+			}
+			ratings[userId] = rating
+			gamesPlayed[userId] = played
+		}
+
+		for userId, change := range ratingChanges(ratings, places) {
+			err = setRating(db, userId, category.mapName, category.playerCount, ratings[userId]+change, gamesPlayed[userId]+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getRating returns the user's rating and number of rated games in the given category
+func getRating(db dbOrTx, userId string, mapName string, playerCount int) (float64, int, error) {
+	var rating float64
+	var gamesPlayed int
+	err := db.QueryRow("SELECT rating,games_played FROM player_ratings WHERE user_id=? AND map_name=? AND player_count=?",
+		userId, mapName, playerCount).Scan(&rating, &gamesPlayed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return initialRating, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to fetch rating: %v", err)
+	}
+	return rating, gamesPlayed, nil
+}
+
+func setRating(db dbOrTx, userId string, mapName string, playerCount int, rating float64, gamesPlayed int) error {
+	var query string
+	if gamesPlayed == 1 {
+		query = "INSERT INTO player_ratings (rating,games_played,user_id,map_name,player_count) VALUES (?,?,?,?,?)"
+	} else {
+		query = "UPDATE player_ratings SET rating=?,games_played=? WHERE user_id=? AND map_name=? AND player_count=?"
+	}
+	_, err := db.Exec(query, rating, gamesPlayed, userId, mapName, playerCount)
+	if err != nil {
+		return fmt.Errorf("failed to save rating: %v", err)
+	}
+	return nil
+}
+
+type GetLeaderboardRequest struct {
+	// If set, only rank games played on this map
+	MapName string `json:"mapName"`
+	// If set, only rank games with this many players
+	PlayerCount int `json:"playerCount"`
+}
+
+type LeaderboardEntry struct {
+	User        *User `json:"user"`
+	Rating      int   `json:"rating"`
+	GamesPlayed int   `json:"gamesPlayed"`
+}
+
+type GetLeaderboardResponse struct {
+	Entries []*LeaderboardEntry `json:"entries"`
+}
+
+func (server *GameServer) getLeaderboard(ctx *RequestContext, req *GetLeaderboardRequest) (resp *GetLeaderboardResponse, err error) {
+	if req.PlayerCount < 0 {
+		return nil, &api.HttpError{"invalid playerCount parameter", http.StatusBadRequest}
+	}
+
+	// Bots are never rated, but exclude them here too in case they were before that rule was added
+	stmt, err := server.db.Prepare("SELECT user_id,nickname,rating,games_played FROM player_ratings INNER JOIN users ON users.id=player_ratings.user_id WHERE map_name=? AND player_count=? AND (bot_type IS NULL OR bot_type='') ORDER BY rating DESC LIMIT ?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(req.MapName, req.PlayerCount, leaderboardSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*LeaderboardEntry, 0)
+	for rows.Next() {
+		user := new(User)
+		var rating float64
+		var gamesPlayed int
+		err = rows.Scan(&user.Id, &user.Nickname, &rating, &gamesPlayed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		entries = append(entries, &LeaderboardEntry{
+			User:        user,
+			Rating:      int(math.Round(rating)),
+			GamesPlayed: gamesPlayed,
+		})
+	}
+
+	return &GetLeaderboardResponse{Entries: entries}, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/bots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingChanges(t *testing.T) {
+	ratings := map[string]float64{"player1": 1500, "player2": 1500, "player3": 1500}
+	changes := ratingChanges(ratings, map[string]int{"player1": 1, "player2": 2, "player3": 3})
+
+	assert.InDelta(t, 16.0, changes["player1"], 0.001)
+	assert.InDelta(t, 0.0, changes["player2"], 0.001)
+
+	// Players sharing a place draw with each other
+	changes = ratingChanges(ratings, map[string]int{"player1": 1, "player2": 1, "player3": 3})
+	assert.InDelta(t, 8.0, changes["player1"], 0.001)
+	assert.InDelta(t, 8.0, changes["player2"], 0.001)
+	assert.InDelta(t, -16.0, changes["player3"], 0.001)
+
+	// An upset moves ratings more than an expected result
+	upset := ratingChanges(map[string]float64{"player1": 1300, "player2": 1700}, map[string]int{"player1": 1, "player2": 2})
+	expected := ratingChanges(map[string]float64{"player1": 1700, "player2": 1300}, map[string]int{"player1": 1, "player2": 2})
+	assert.Greater(t, upset["player1"], expected["player1"])
+	assert.InDelta(t, 0.0, upset["player1"]+upset["player2"], 0.001)
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	err = updateRatings(h.gameServer.db, createRes.Id, []*PlayerScore{
+		{PlayerId: player1, Total: 24, Place: 1},
+		{PlayerId: player2, Total: 3, Place: 2},
+	})
+	require.NoError(t, err)
+
+	for _, req := range []*GetLeaderboardRequest{
+		{},
+		{MapName: "rust_belt"},
+		{PlayerCount: 2},
+		{MapName: "rust_belt", PlayerCount: 2},
+	} {
+		res, err := h.getLeaderboard(t, player1, req)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(res.Entries))
+		assert.Equal(t, player1, res.Entries[0].User.Id)
+		assert.Equal(t, 1516, res.Entries[0].Rating)
+		assert.Equal(t, 1, res.Entries[0].GamesPlayed)
+		assert.Equal(t, player2, res.Entries[1].User.Id)
+		assert.Equal(t, 1484, res.Entries[1].Rating)
+	}
+
+	res, err := h.getLeaderboard(t, player1, &GetLeaderboardRequest{MapName: "germany"})
+	require.NoError(t, err)
+	assert.Empty(t, res.Entries)
+	res, err = h.getLeaderboard(t, player1, &GetLeaderboardRequest{PlayerCount: 3})
+	require.NoError(t, err)
+	assert.Empty(t, res.Entries)
+}
+
+func TestBotsAreNotRated(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 3,
+		MaxPlayers: 3,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player1, &JoinGameRequest{GameId: createRes.Id, BotType: bots.HEURISTIC_BOT_TYPE})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	var botId string
+	for _, user := range viewRes.JoinedUsers {
+		if user.Id != player1 && user.Id != player2 {
+			botId = user.Id
+		}
+	}
+	require.NotEmpty(t, botId)
+
+	// The bot winning doesn't count, so the humans are only ranked against each other
+	err = updateRatings(h.gameServer.db, createRes.Id, []*PlayerScore{
+		{PlayerId: botId, Total: 30, Place: 1},
+		{PlayerId: player1, Total: 24, Place: 2},
+		{PlayerId: player2, Total: 3, Place: 3},
+	})
+	require.NoError(t, err)
+	_, gamesPlayed, err := getRating(h.gameServer.db, botId, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, gamesPlayed)
+
+	// Ratings left over from before bots were excluded don't show up either
+	err = setRating(h.gameServer.db, botId, "", 0, 2000, 1)
+	require.NoError(t, err)
+
+	res, err := h.getLeaderboard(t, player1, &GetLeaderboardRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(res.Entries))
+	assert.Equal(t, player1, res.Entries[0].User.Id)
+	assert.Equal(t, 1516, res.Entries[0].Rating)
+	assert.Equal(t, player2, res.Entries[1].User.Id)
+	assert.Equal(t, 1484, res.Entries[1].Rating)
+}
+
+func TestRatingsSavedWithFinalMove(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	startGame := func() string {
+		createRes, err := h.createGame(t, player1, &CreateGameRequest{
+			Name:       "game-name",
+			MinPlayers: 2,
+			MaxPlayers: 2,
+			MapName:    "rust_belt",
+		})
+		require.NoError(t, err)
+		_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+		require.NoError(t, err)
+		_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+		require.NoError(t, err)
+		return createRes.Id
+	}
+	finishGame := func(gameId string) error {
+		handler, err := h.gameServer.newConfirmMoveHandlerForGame(gameId)
+		require.NoError(t, err)
+		handler.gameFinished = true
+		handler.finalScores = []*PlayerScore{
+			{PlayerId: player1, Total: 24, Place: 1},
+			{PlayerId: player2, Total: 3, Place: 2},
+		}
+		return h.gameServer.saveMove(handler, handler.activePlayer, &api.ConfirmMoveRequest{GameId: gameId})
+	}
+	isFinished := func(gameId string) bool {
+		var finishedFlag int
+		err := h.gameServer.db.QueryRow("SELECT finished FROM games WHERE id=?", gameId).Scan(&finishedFlag)
+		require.NoError(t, err)
+		return finishedFlag != 0
+	}
+
+	gameId := startGame()
+	require.NoError(t, finishGame(gameId))
+	assert.True(t, isFinished(gameId))
+	rating, gamesPlayed, err := getRating(h.gameServer.db, player1, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, gamesPlayed)
+	assert.InDelta(t, 1516.0, rating, 0.001)
+
+	// If the ratings can't be updated, the final move isn't saved either
+	gameId = startGame()
+	_, err = h.gameServer.db.Exec("DROP TABLE player_ratings")
+	require.NoError(t, err)
+	require.Error(t, finishGame(gameId))
+	assert.False(t, isFinished(gameId))
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+)
+
+// Logged rollbacks aren't confirmed moves, so they are exported as a ConfirmMoveRequest with this action name
+const rollbackActionName = "rollback"
+
+// PendingRollback is a request to rewind a game to an earlier point in its log, which is applied once every player has
+// approved it
+type PendingRollback struct {
+	RequestedBy string `json:"requestedBy"`
+	// Sequence number and timestamp of the game log entry whose state the game will be rewound to
+	Seq         int `json:"seq"`
+	Timestamp   int `json:"timestamp"`
+	RequestedAt int `json:"requestedAt"`
+	// Players who have approved so far. Bots always approve.
+	Approvals []string `json:"approvals"`
+}
+
+type RollbackGameRequest struct {
+	GameId string `json:"gameId"`
+	// Sequence number of the game log entry to rewind to
+	Seq int `json:"seq"`
+}
+
+type RollbackGameResponse struct {
+	// Nil if the rollback has already been applied
+	PendingRollback *PendingRollback `json:"pendingRollback"`
+}
+
+// rollbackGame requests that a game be rewound to an earlier point in its log. Only the game's owner or an admin can
+// request a rollback, which replaces any other pending rollback for the game.
+func (server *GameServer) rollbackGame(ctx *RequestContext, req *RollbackGameRequest) (resp *RollbackGameResponse, err error) {
+	stmt, err := server.db.Prepare("SELECT owner_user_id,started,finished FROM games WHERE id=?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	var ownerUserId string
+	var startedFlag int
+	var finishedFlag int
+	err = stmt.QueryRow(req.GameId).Scan(&ownerUserId, &startedFlag, &finishedFlag)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &api.HttpError{fmt.Sprintf("invalid game id: %s", req.GameId), http.StatusBadRequest}
+		}
+		return nil, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if ownerUserId != ctx.User.Id && !slices.Contains(server.config.AdminUserIds, ctx.User.Id) {
+		return nil, &api.HttpError{"only the game owner can roll back the game", http.StatusBadRequest}
+	}
+	if startedFlag == 0 {
+		return nil, &api.HttpError{"game has not started yet", http.StatusBadRequest}
+	}
+	if finishedFlag != 0 {
+		return nil, &api.HttpError{"cannot roll back a game that has finished", http.StatusBadRequest}
+	}
+
+	seq, err := nextSeq(server.db, "game_log", req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	if req.Seq < 1 || req.Seq >= seq {
+		return nil, &api.HttpError{"invalid seq parameter", http.StatusBadRequest}
+	}
+	if req.Seq == seq-1 {
+		return nil, &api.HttpError{"cannot roll back to the current state of the game", http.StatusBadRequest}
+	}
+
+	tx, err := server.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	err = clearPendingRollback(tx, req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("INSERT INTO game_rollbacks (game_id,requested_by,target_seq,requested_at) VALUES (?,?,?,?)",
+		req.GameId, ctx.User.Id, req.Seq, time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert rollback: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	// Requesting a rollback counts as approving it, for an owner or admin who is also playing
+	joinedUsers, err := server.getJoinedUsers(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	if joinedUsers[ctx.User.Id] {
+		err = server.approveRollback(req.GameId, ctx.User.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pendingRollback, err := server.checkRollbackApprovals(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	return &RollbackGameResponse{PendingRollback: pendingRollback}, nil
+}
+
+type VoteRollbackRequest struct {
+	GameId  string `json:"gameId"`
+	Approve bool   `json:"approve"`
+}
+
+type VoteRollbackResponse struct {
+	// Nil if the rollback was rejected or has been applied
+	PendingRollback *PendingRollback `json:"pendingRollback"`
+}
+
+// voteRollback approves or rejects the game's pending rollback. A single rejection cancels the rollback.
+func (server *GameServer) voteRollback(ctx *RequestContext, req *VoteRollbackRequest) (resp *VoteRollbackResponse, err error) {
+	joinedUsers, err := server.getJoinedUsers(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	if !joinedUsers[ctx.User.Id] {
+		return nil, &api.HttpError{"only players in the game can vote on a rollback", http.StatusBadRequest}
+	}
+	var startedFlag int
+	var finishedFlag int
+	err = server.db.QueryRow("SELECT started,finished FROM games WHERE id=?", req.GameId).Scan(&startedFlag, &finishedFlag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if startedFlag == 0 {
+		return nil, &api.HttpError{"game has not started yet", http.StatusBadRequest}
+	}
+	if finishedFlag != 0 {
+		return nil, &api.HttpError{"cannot roll back a game that has finished", http.StatusBadRequest}
+	}
+	pendingRollback, err := server.getPendingRollback(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	if pendingRollback == nil {
+		return nil, &api.HttpError{"game has no pending rollback", http.StatusBadRequest}
+	}
+
+	if !req.Approve {
+		tx, err := server.db.Begin()
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %v", err)
+		}
+		defer tx.Rollback()
+		err = clearPendingRollback(tx, req.GameId)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %v", err)
+		}
+		server.publishGameEvent(req.GameId, &GameEvent{Type: GAME_EVENT_ROLLBACK})
+		return &VoteRollbackResponse{}, nil
+	}
+
+	if !slices.Contains(pendingRollback.Approvals, ctx.User.Id) {
+		err = server.approveRollback(req.GameId, ctx.User.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pendingRollback, err = server.checkRollbackApprovals(req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	return &VoteRollbackResponse{PendingRollback: pendingRollback}, nil
+}
+
+// getPendingRollback returns the game's pending rollback, or nil if there isn't one
+func (server *GameServer) getPendingRollback(gameId string) (*PendingRollback, error) {
+	stmt, err := server.db.Prepare("SELECT r.requested_by,r.target_seq,l.timestamp,r.requested_at FROM game_rollbacks r JOIN game_log l ON l.game_id=r.game_id AND l.seq=r.target_seq WHERE r.game_id=?")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	pendingRollback := &PendingRollback{Approvals: make([]string, 0)}
+	err = stmt.QueryRow(gameId).Scan(&pendingRollback.RequestedBy, &pendingRollback.Seq, &pendingRollback.Timestamp,
+		&pendingRollback.RequestedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch rollback: %v", err)
+	}
+
+	stmt, err = server.db.Prepare("SELECT user_id FROM game_rollback_approvals WHERE game_id=? ORDER BY user_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	rows, err := stmt.Query(gameId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userId string
+		err = rows.Scan(&userId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		pendingRollback.Approvals = append(pendingRollback.Approvals, userId)
+	}
+	return pendingRollback, nil
+}
+
+func (server *GameServer) approveRollback(gameId string, userId string) error {
+	stmt, err := server.db.Prepare("INSERT INTO game_rollback_approvals (game_id,user_id) VALUES (?,?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare query: %v", err)
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(gameId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to save rollback approval: %v", err)
+	}
+	return nil
+}
+
+func clearPendingRollback(tx *sql.Tx, gameId string) error {
+	_, err := tx.Exec("DELETE FROM game_rollback_approvals WHERE game_id=?", gameId)
+	if err != nil {
+		return fmt.Errorf("failed to delete rollback approvals: %v", err)
+	}
+	_, err = tx.Exec("DELETE FROM game_rollbacks WHERE game_id=?", gameId)
+	if err != nil {
+		return fmt.Errorf("failed to delete rollback: %v", err)
+	}
+	return nil
+}
+
+// checkRollbackApprovals applies the game's pending rollback if every player has approved it. Otherwise, it returns the
+// still pending rollback and lets everyone watching the game know about it.
+func (server *GameServer) checkRollbackApprovals(gameId string) (*PendingRollback, error) {
+	pendingRollback, err := server.getPendingRollback(gameId)
+	if err != nil || pendingRollback == nil {
+		return nil, err
+	}
+
+	joinedUsers, err := server.getJoinedUsers(gameId)
+	if err != nil {
+		return nil, err
+	}
+	approved := true
+	for userId := range joinedUsers {
+		botType, err := server.getBotType(userId)
+		if err != nil {
+			return nil, err
+		}
+		if botType == "" && !slices.Contains(pendingRollback.Approvals, userId) {
+			approved = false
+			break
+		}
+	}
+	if !approved {
+		server.publishGameEvent(gameId, &GameEvent{Type: GAME_EVENT_ROLLBACK, Rollback: pendingRollback})
+		return pendingRollback, nil
+	}
+
+	err = server.applyRollback(gameId, pendingRollback)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// applyRollback rewinds the game to the state in the rollback's target log entry. The moves after that stay in the log,
+// followed by an entry for the rollback itself.
+func (server *GameServer) applyRollback(gameId string, pendingRollback *PendingRollback) error {
+	requestedBy, err := server.getUserById(pendingRollback.RequestedBy)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %v", pendingRollback.RequestedBy, err)
+	}
+	// Nothing random happens in a rollback
+	noRandomValues, err := marshalRandomValues(nil)
+	if err != nil {
+		return err
+	}
+
+	tx, err := server.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// The game may have finished since the rollback was requested, and then it's too late to roll it back
+	var finishedFlag int
+	var version int
+	err = tx.QueryRow("SELECT finished,version FROM games WHERE id=?", gameId).Scan(&finishedFlag, &version)
+	if err != nil {
+		return fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if finishedFlag != 0 {
+		return &api.HttpError{"cannot roll back a game that has finished", http.StatusBadRequest}
+	}
+
+	var gameState string
+	var activePlayer string
+	err = tx.QueryRow("SELECT new_game_state,new_active_player FROM game_log WHERE game_id=? AND seq=?",
+		gameId, pendingRollback.Seq).Scan(&gameState, &activePlayer)
+	if err != nil {
+		return fmt.Errorf("failed to fetch game log entry to roll back to: %v", err)
+	}
+	// A move confirmed in the meantime could have finished the game, so only roll back the version checked above
+	result, err := tx.Exec("UPDATE games SET active_player_id=?,game_state=?,version=version+1 WHERE id=? AND version=? AND finished=0",
+		activePlayer, gameState, gameId, version)
+	if err != nil {
+		return fmt.Errorf("failed to update game: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get number of rows affected: %v", err)
+	}
+	if rowsAffected == 0 {
+		return &api.HttpError{"the game has changed since the rollback was approved", http.StatusConflict}
+	}
+
+	seq, err := nextSeq(tx, "game_log", gameId)
+	if err != nil {
+		return err
+	}
+	logEntry := &GameLogEntry{
+		Seq:       seq,
+		Timestamp: int(time.Now().Unix()),
+		UserId:    pendingRollback.RequestedBy,
+		Action:    rollbackActionName,
+		Description: fmt.Sprintf("All players approved %s's request to roll the game back to %s.", requestedBy.Nickname,
+			time.Unix(int64(pendingRollback.Timestamp), 0).UTC().Format(time.RFC1123)),
+		Reversible:   false,
+		RandomValues: make([]int, 0),
+		RandomBounds: make([]int, 0),
+	}
+	_, err = tx.Exec("INSERT INTO game_log (game_id,seq,timestamp,user_id,action,description,new_active_player,new_game_state,reversible,random_values,random_bounds) VALUES (?,?,?,?,?,?,?,?,0,?,?)",
+		gameId, logEntry.Seq, logEntry.Timestamp, logEntry.UserId, logEntry.Action, logEntry.Description, activePlayer, gameState,
+		noRandomValues, noRandomValues)
+	if err != nil {
+		return fmt.Errorf("failed to insert game log entry: %v", err)
+	}
+	err = clearPendingRollback(tx, gameId)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	server.publishGameEvent(gameId, &GameEvent{
+		Type:         GAME_EVENT_MOVE,
+		Log:          logEntry,
+		ActivePlayer: activePlayer,
+	})
+
+	err = server.notifyPlayer(gameId, activePlayer)
+	if err != nil {
+		return fmt.Errorf("failed to notify user it's their turn: %v", err)
+	}
+
+	// The rollback has been saved, so a failing bot shouldn't fail it; the game will wait on the bot instead
+	err = server.runBots(gameId, maxBotMovesPerRequest)
+	if err != nil {
+		slog.Error("failed to run bot move", "error", err, "gameId", gameId)
+	}
+	return nil
+}
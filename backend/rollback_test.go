@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	startView, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = h.gameServer.timeoutActivePlayer(createRes.Id)
+		require.NoError(t, err)
+	}
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.Len(t, logsRes.Logs, 4)
+	target := logsRes.Logs[0].Seq
+
+	// Only the owner can ask for a rollback, and only to an earlier point in the game
+	_, err = h.rollbackGame(t, player2, &RollbackGameRequest{GameId: createRes.Id, Seq: target})
+	assert.Error(t, err)
+	_, err = h.rollbackGame(t, player1, &RollbackGameRequest{GameId: createRes.Id, Seq: logsRes.Logs[3].Seq})
+	assert.Error(t, err)
+	_, err = h.rollbackGame(t, player1, &RollbackGameRequest{GameId: createRes.Id, Seq: target + 1000})
+	assert.Error(t, err)
+
+	// A rejection cancels the rollback
+	rollbackRes, err := h.rollbackGame(t, player1, &RollbackGameRequest{GameId: createRes.Id, Seq: target})
+	require.NoError(t, err)
+	require.NotNil(t, rollbackRes.PendingRollback)
+	assert.Equal(t, player1, rollbackRes.PendingRollback.RequestedBy)
+	assert.Equal(t, []string{player1}, rollbackRes.PendingRollback.Approvals)
+	voteRes, err := h.voteRollback(t, player2, &VoteRollbackRequest{GameId: createRes.Id, Approve: false})
+	require.NoError(t, err)
+	assert.Nil(t, voteRes.PendingRollback)
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Nil(t, viewRes.PendingRollback)
+	_, err = h.voteRollback(t, player2, &VoteRollbackRequest{GameId: createRes.Id, Approve: true})
+	assert.Error(t, err)
+
+	// Once everyone approves, the game goes back to the earlier state
+	_, err = h.rollbackGame(t, player1, &RollbackGameRequest{GameId: createRes.Id, Seq: target})
+	require.NoError(t, err)
+	viewRes, err = h.viewGame(t, player2, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.NotNil(t, viewRes.PendingRollback)
+	assert.Equal(t, target, viewRes.PendingRollback.Seq)
+	voteRes, err = h.voteRollback(t, player2, &VoteRollbackRequest{GameId: createRes.Id, Approve: true})
+	require.NoError(t, err)
+	assert.Nil(t, voteRes.PendingRollback)
+
+	viewRes, err = h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Nil(t, viewRes.PendingRollback)
+	assert.Equal(t, startView.ActivePlayer, viewRes.ActivePlayer)
+	assert.Equal(t, startView.GameState, viewRes.GameState)
+
+	logsRes, err = h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	require.Len(t, logsRes.Logs, 5)
+	assert.Equal(t, rollbackActionName, logsRes.Logs[4].Action)
+	assert.Equal(t, player1, logsRes.Logs[4].UserId)
+	assert.False(t, logsRes.Logs[4].Reversible)
+
+	// The log still replays with the rollback in it
+	err = h.gameServer.timeoutActivePlayer(createRes.Id)
+	require.NoError(t, err)
+	err = runTask(h.gameServer, []string{"verify-game", createRes.Id})
+	require.NoError(t, err)
+}
+
+func TestRollbackFinishedGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = h.gameServer.timeoutActivePlayer(createRes.Id)
+		require.NoError(t, err)
+	}
+	logsRes, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	rollbackRes, err := h.rollbackGame(t, player1, &RollbackGameRequest{GameId: createRes.Id, Seq: logsRes.Logs[0].Seq})
+	require.NoError(t, err)
+	require.NotNil(t, rollbackRes.PendingRollback)
+
+	// The game finishes while the rollback is still pending
+	_, err = h.gameServer.db.Exec("UPDATE games SET finished=1 WHERE id=?", createRes.Id)
+	require.NoError(t, err)
+	finishedView, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	var httpErr *api.HttpError
+	_, err = h.voteRollback(t, player2, &VoteRollbackRequest{GameId: createRes.Id, Approve: true})
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+
+	// Even a rollback that was already approved isn't applied
+	err = h.gameServer.applyRollback(createRes.Id, rollbackRes.PendingRollback)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, finishedView.Version, viewRes.Version)
+	assert.Equal(t, finishedView.GameState, viewRes.GameState)
+	logsAfter, err := h.getGameLogs(t, player1, &GetGameLogsRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Len(t, logsAfter.Logs, len(logsRes.Logs))
+}
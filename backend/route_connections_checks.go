@@ -87,7 +87,7 @@ func (handler *confirmMoveHandler) checkLoopingConnections() error {
 			if teleportDest, _ := handler.gameMap.GetTeleportLink(handler.gameState, endHex, step); teleportDest != nil {
 				endHex = *teleportDest
 			} else {
-				endHex = applyDirection(endHex, step)
+				endHex = common.ApplyDirection(endHex, step)
 			}
 		}
 
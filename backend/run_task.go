@@ -1,6 +1,11 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
 
 func runTask(server *GameServer, args []string) error {
 	if len(args) == 0 {
@@ -12,6 +17,47 @@ func runTask(server *GameServer, args []string) error {
 		if err != nil {
 			return err
 		}
+	} else if task == "migrate" {
+		err := migrateDatabase(server.db)
+		if err != nil {
+			return err
+		}
+	} else if task == "move-timeouts" {
+		err := server.runMoveTimeouts()
+		if err != nil {
+			return err
+		}
+	} else if task == "run-bots" {
+		err := server.runPendingBots()
+		if err != nil {
+			return err
+		}
+	} else if task == "import-game" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: import-game <path to exported game>")
+		}
+		err := server.importGameFile(args[1])
+		if err != nil {
+			return err
+		}
+	} else if task == "verify-game" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: verify-game <game id>")
+		}
+		err := server.verifyGame(args[1])
+		if err != nil {
+			return err
+		}
+	} else if task == "validate-maps" {
+		problems, err := maps.ValidateMaps()
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			fmt.Println(maps.FormatMapProblems(problems))
+			return fmt.Errorf("found problems in %d map files", len(problems))
+		}
+		slog.Info("All maps are valid")
 	} else {
 		return fmt.Errorf("invalid task: %s", task)
 	}
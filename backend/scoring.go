@@ -0,0 +1,127 @@
+package main
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+// PlayerScore is the breakdown of a player's victory points at the end of the game
+type PlayerScore struct {
+	PlayerId     string `json:"playerId"`
+	IncomePoints int    `json:"incomePoints"`
+	SharesPoints int    `json:"sharesPoints"`
+	TrackPoints  int    `json:"trackPoints"`
+	Total        int    `json:"total"`
+	// Bankrupt players don't score and place behind everyone else
+	Bankrupt bool `json:"bankrupt"`
+	// 1 for the winner. Players who are still tied after the tie-breaks share a place.
+	Place int `json:"place"`
+}
+
+// countTrackPoints returns the number of track segments (hexes other than cities) in the player's completed links.
+// Teleport links always count as one extra segment.
+func countTrackPoints(gameMap maps.GameMap, gameState *common.GameState, player string) int {
+	isCity := func(hex common.Coordinate) bool {
+		if gameMap.GetHexType(hex) == maps.CITY_HEX_TYPE {
+			return true
+		}
+		for _, urbanization := range gameState.Urbanizations {
+			if urbanization.Hex.Equals(hex) {
+				return true
+			}
+		}
+		return false
+	}
+
+	trackCount := 0
+	for _, link := range gameState.Links {
+		if !link.Complete || link.Owner != player {
+			continue
+		}
+		hex := link.SourceHex
+		for _, step := range link.Steps {
+			if !isCity(hex) {
+				trackCount += 1
+			}
+			if teleportHex, _ := gameMap.GetTeleportLink(gameState, hex, step); teleportHex != nil {
+				trackCount += 1
+				hex = *teleportHex
+			} else {
+				hex = common.ApplyDirection(hex, step)
+			}
+		}
+		if !isCity(hex) {
+			trackCount += 1
+		}
+	}
+	return trackCount
+}
+
+// computeFinalScores scores every player who took part in the game: 3 points per income, -3 points per share issued
+// and 1 point per track segment in completed links. The result is ordered by place. Ties on points are broken by the
+// higher income, then by fewer shares issued, then by more cash on hand.
+func computeFinalScores(gameMap maps.GameMap, gameState *common.GameState) []*PlayerScore {
+	// Bankrupt players are dropped from the player order, but keep their other player state
+	var players []string
+	for player := range gameState.PlayerShares {
+		players = append(players, player)
+	}
+	sort.Strings(players)
+
+	var scores []*PlayerScore
+	for _, player := range players {
+		score := &PlayerScore{PlayerId: player}
+		if !slices.Contains(gameState.PlayerOrder, player) {
+			score.Bankrupt = true
+		} else {
+			score.IncomePoints = 3 * gameState.PlayerIncome[player]
+			score.SharesPoints = -3 * gameState.PlayerShares[player]
+			score.TrackPoints = countTrackPoints(gameMap, gameState, player)
+			score.Total = score.IncomePoints + score.SharesPoints + score.TrackPoints
+		}
+		scores = append(scores, score)
+	}
+
+	compare := func(a *PlayerScore, b *PlayerScore) int {
+		if a.Bankrupt || b.Bankrupt {
+			return boolToInt(a.Bankrupt) - boolToInt(b.Bankrupt)
+		}
+		if a.Total != b.Total {
+			return b.Total - a.Total
+		}
+		if a.IncomePoints != b.IncomePoints {
+			return b.IncomePoints - a.IncomePoints
+		}
+		if a.SharesPoints != b.SharesPoints {
+			return b.SharesPoints - a.SharesPoints
+		}
+		return gameState.PlayerCash[b.PlayerId] - gameState.PlayerCash[a.PlayerId]
+	}
+	slices.SortStableFunc(scores, compare)
+	for i, score := range scores {
+		if i > 0 && compare(scores[i-1], score) == 0 {
+			score.Place = scores[i-1].Place
+		} else {
+			score.Place = i + 1
+		}
+	}
+	return scores
+}
+
+// scoreGame computes the final scores once the game has finished and adds them to the log
+func (handler *confirmMoveHandler) scoreGame() {
+	handler.finalScores = computeFinalScores(handler.gameMap, handler.gameState)
+
+	handler.Log("Final scores:")
+	for _, score := range handler.finalScores {
+		if score.Bankrupt {
+			handler.Log("%d. %s went bankrupt.", score.Place, handler.PlayerNick(score.PlayerId))
+		} else {
+			handler.Log("%d. %s scores %d points (%d for income, %d for shares, %d for track).", score.Place,
+				handler.PlayerNick(score.PlayerId), score.Total, score.IncomePoints, score.SharesPoints, score.TrackPoints)
+		}
+	}
+}
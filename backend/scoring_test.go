@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFinalScores(t *testing.T) {
+	gameMap := &testMap{
+		hexes: [][]maps.HexType{
+			{maps.CITY_HEX_TYPE, maps.CITY_HEX_TYPE},
+			{maps.PLAINS_HEX_TYPE, maps.PLAINS_HEX_TYPE},
+		},
+	}
+	gameState := &common.GameState{
+		PlayerOrder:  []string{"player1", "player2", "player3", "player5", "player6"},
+		PlayerIncome: map[string]int{"player1": 5, "player2": 6, "player3": 2, "player4": -1, "player5": 2, "player6": 2},
+		PlayerShares: map[string]int{"player1": 4, "player2": 5, "player3": 2, "player4": 10, "player5": 2, "player6": 2},
+		PlayerCash:   map[string]int{"player1": 0, "player2": 0, "player3": 0, "player4": 0, "player5": 3, "player6": 3},
+		Links: []*common.Link{
+			{
+				SourceHex: common.Coordinate{X: 0, Y: 0},
+				Steps:     []common.Direction{common.SOUTH_EAST, common.NORTH_EAST},
+				Owner:     "player3",
+				Complete:  true,
+			},
+			{
+				SourceHex: common.Coordinate{X: 1, Y: 0},
+				Steps:     []common.Direction{common.SOUTH_EAST},
+				Owner:     "player5",
+				Complete:  false,
+			},
+		},
+	}
+
+	scores := computeFinalScores(gameMap, gameState)
+	require.Equal(t, 6, len(scores))
+	// player1 and player2 are tied on points, but player2 wins the tie-break on income
+	assert.Equal(t, &PlayerScore{PlayerId: "player2", IncomePoints: 18, SharesPoints: -15, TrackPoints: 0, Total: 3, Place: 1}, scores[0])
+	assert.Equal(t, &PlayerScore{PlayerId: "player1", IncomePoints: 15, SharesPoints: -12, TrackPoints: 0, Total: 3, Place: 2}, scores[1])
+	assert.Equal(t, &PlayerScore{PlayerId: "player3", IncomePoints: 6, SharesPoints: -6, TrackPoints: 1, Total: 1, Place: 3}, scores[2])
+	// player5 and player6 are still tied after the tie-breaks, so share a place. Incomplete links don't score.
+	assert.Equal(t, &PlayerScore{PlayerId: "player5", IncomePoints: 6, SharesPoints: -6, TrackPoints: 0, Total: 0, Place: 4}, scores[3])
+	assert.Equal(t, &PlayerScore{PlayerId: "player6", IncomePoints: 6, SharesPoints: -6, TrackPoints: 0, Total: 0, Place: 4}, scores[4])
+	// Players dropped from the player order went bankrupt
+	assert.Equal(t, &PlayerScore{PlayerId: "player4", Bankrupt: true, Place: 6}, scores[5])
+}
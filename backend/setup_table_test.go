@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 
@@ -104,6 +107,220 @@ func TestCreateGamePlayerCountsRequired(t *testing.T) {
 	assert.Equal(t, 0, len(res.Games))
 }
 
+func TestCreateGameUnsupportedPlayerCounts(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	for _, req := range []*CreateGameRequest{
+		{Name: "game-name", MinPlayers: 2, MaxPlayers: 4, MapName: "germany"},
+		{Name: "game-name", MinPlayers: 3, MaxPlayers: 4, MapName: "australia"},
+		{Name: "game-name", MinPlayers: 2, MaxPlayers: 4, MapName: "scotland"},
+	} {
+		_, err := h.createGame(t, player1, req)
+		var httpError *api.HttpError
+		require.ErrorAs(t, err, &httpError)
+		assert.Equal(t, http.StatusBadRequest, httpError.Code)
+	}
+
+	_, err := h.createGame(t, player1, &CreateGameRequest{Name: "game-name", MinPlayers: 2, MaxPlayers: 3, MapName: "scotland"})
+	require.NoError(t, err)
+	_, err = h.createGame(t, player1, &CreateGameRequest{Name: "game-name", MinPlayers: 4, MaxPlayers: 6, MapName: "australia"})
+	require.NoError(t, err)
+}
+
+func TestStartGameUnsupportedPlayerCount(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	// e.g. a game created before the map's player counts were enforced
+	_, err = h.gameServer.db.Exec("UPDATE games SET map_name='germany' WHERE id=?", createRes.Id)
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.False(t, viewRes.Started)
+}
+
+func TestCreateGameWithMapVariants(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	for _, req := range []*CreateGameRequest{
+		{Name: "game-name", MinPlayers: 3, MaxPlayers: 3, MapName: "germany", MapVariants: []common.MapVariant{common.FIXED_STARTING_CUBES_VARIANT}},
+		{Name: "game-name", MinPlayers: 2, MaxPlayers: 2, MapName: "rust_belt", MapVariants: []common.MapVariant{"aliens"}},
+		{Name: "game-name", MinPlayers: 2, MaxPlayers: 2, MapName: "rust_belt",
+			MapVariants: []common.MapVariant{common.FIXED_STARTING_CUBES_VARIANT, common.FIXED_STARTING_CUBES_VARIANT}},
+	} {
+		_, err := h.createGame(t, player1, req)
+		var httpError *api.HttpError
+		require.ErrorAs(t, err, &httpError)
+		assert.Equal(t, http.StatusBadRequest, httpError.Code)
+	}
+
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:        "game-name",
+		MinPlayers:  2,
+		MaxPlayers:  2,
+		MapName:     "rust_belt",
+		MapVariants: common.ALL_MAP_VARIANTS,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, common.ALL_MAP_VARIANTS, viewRes.MapVariants)
+	assert.Equal(t, common.ALL_MAP_VARIANTS, viewRes.GameState.MapVariants)
+	assert.Equal(t, map[string]int{player1: 3, player2: 3}, viewRes.GameState.PlayerShares)
+	assert.Equal(t, map[string]int{player1: 15, player2: 15}, viewRes.GameState.PlayerCash)
+	// Duluth starts with the cubes listed in the map file
+	var duluthCubes []common.Color
+	for _, cube := range viewRes.GameState.Cubes {
+		if cube.Hex == (common.Coordinate{X: 1, Y: 0}) {
+			duluthCubes = append(duluthCubes, cube.Color)
+		}
+	}
+	assert.Equal(t, []common.Color{common.BLACK, common.RED}, duluthCubes)
+
+	// Urbanization can't be chosen on the first turn
+	handler, err := h.gameServer.newConfirmMoveHandlerForGame(createRes.Id)
+	require.NoError(t, err)
+	handler.gameState.GamePhase = common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE
+	err = handler.handleAction(&api.ConfirmMoveRequest{
+		ActionName:   api.ChooseActionName,
+		ChooseAction: &api.ChooseAction{Action: common.URBANIZATION_SPECIAL_ACTION},
+	})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+	err = handler.handleAction(&api.ConfirmMoveRequest{
+		ActionName:   api.ChooseActionName,
+		ChooseAction: &api.ChooseAction{Action: common.ENGINEER_SPECIAL_ACTION},
+	})
+	require.NoError(t, err)
+}
+
+func intOption(value int) *int {
+	return &value
+}
+
+func TestCreateGameWithOptions(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	for _, options := range []*common.GameOptions{
+		{StartingCash: intOption(-5)},
+		{StartingCash: intOption(common.MAX_STARTING_CASH + 1)},
+		{StartingShares: intOption(-1)},
+		{TurnLimit: -1},
+		{TurnLimit: common.MAX_TURN_LIMIT + 1},
+		{SharesLimit: -1},
+		{SharesLimit: common.MAX_SHARES_LIMIT + 1},
+		{StartingShares: intOption(6), SharesLimit: 5},
+		{AuctionType: "dutch"},
+	} {
+		_, err := h.createGame(t, player1, &CreateGameRequest{Name: "game-name", MinPlayers: 2, MaxPlayers: 2, MapName: "rust_belt", Options: options})
+		var httpError *api.HttpError
+		require.ErrorAs(t, err, &httpError)
+		assert.Equal(t, http.StatusBadRequest, httpError.Code)
+	}
+
+	options := &common.GameOptions{StartingCash: intOption(20), StartingShares: intOption(3), SharesLimit: 5, TurnLimit: 4, NoTurnOrderPass: true}
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		Options:    options,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, options, viewRes.Options)
+	assert.Equal(t, options, viewRes.GameState.Options)
+	assert.Equal(t, map[string]int{player1: 3, player2: 3}, viewRes.GameState.PlayerShares)
+	assert.Equal(t, map[string]int{player1: 20, player2: 20}, viewRes.GameState.PlayerCash)
+
+	// The overridden shares limit applies
+	handler, err := h.gameServer.newConfirmMoveHandlerForGame(createRes.Id)
+	require.NoError(t, err)
+	err = handler.handleAction(&api.ConfirmMoveRequest{
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 3},
+	})
+	var httpError *api.HttpError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+	err = handler.handleAction(&api.ConfirmMoveRequest{
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 2},
+	})
+	require.NoError(t, err)
+
+	// Turn order pass has been taken out of the game
+	handler.gameState.GamePhase = common.CHOOSE_SPECIAL_ACTIONS_GAME_PHASE
+	err = handler.handleAction(&api.ConfirmMoveRequest{
+		ActionName:   api.ChooseActionName,
+		ChooseAction: &api.ChooseAction{Action: common.TURN_ORDER_PASS_SPECIAL_ACTION},
+	})
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusBadRequest, httpError.Code)
+}
+
+func TestCreateGameWithNoStartingCashOrShares(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	createRes, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "game-name",
+		MinPlayers: 2,
+		MaxPlayers: 2,
+		MapName:    "rust_belt",
+		Options:    &common.GameOptions{StartingCash: intOption(0), StartingShares: intOption(0)},
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, player1, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{player1: 0, player2: 0}, viewRes.GameState.PlayerShares)
+	assert.Equal(t, map[string]int{player1: 0, player2: 0}, viewRes.GameState.PlayerCash)
+}
+
 func TestJoinGame(t *testing.T) {
 	h := NewTestHarness(t)
 	defer h.Close()
@@ -533,7 +750,7 @@ func TestGameChat(t *testing.T) {
 	})
 	require.NoError(t, err)
 
-	time.Sleep(1 * time.Second)
+	// Messages sent in the same second don't collide
 	_, err = h.sendGameChat(t, player2, &SendGameChatRequest{
 		GameId:  game.Id,
 		Message: "Hi player1!",
@@ -552,6 +769,76 @@ func TestGameChat(t *testing.T) {
 	assert.Equal(t, player1, chatResp.Messages[0].UserId)
 	assert.Equal(t, "Hi player1!", chatResp.Messages[1].Message)
 	assert.Equal(t, player2, chatResp.Messages[1].UserId)
+	assert.Equal(t, 1, chatResp.Messages[0].Seq)
+	assert.Equal(t, 2, chatResp.Messages[1].Seq)
+
+	// Only messages after the given sequence number are returned
+	chatResp, err = h.getGameChat(t, player1, &GetGameChatRequest{
+		GameId: game.Id,
+		After:  chatResp.Messages[0].Seq,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(chatResp.Messages))
+	assert.Equal(t, "Hi player1!", chatResp.Messages[0].Message)
+}
+
+func TestGameChatConcurrentSends(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	player1 := h.createUser(t)
+	player2 := h.createUser(t)
+	game, err := h.createGame(t, player1, &CreateGameRequest{
+		Name:       "chat-test",
+		MinPlayers: 2,
+		MaxPlayers: 4,
+		MapName:    "rust_belt",
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, player2, &JoinGameRequest{GameId: game.Id})
+	require.NoError(t, err)
+
+	// Messages sent at the same time each get their own sequence number
+	const messageCount = 10
+	errs := make([]error, messageCount)
+	var wg sync.WaitGroup
+	for i := 0; i < messageCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sender := player1
+			if i%2 == 1 {
+				sender = player2
+			}
+			_, errs[i] = h.sendGameChat(t, sender, &SendGameChatRequest{
+				GameId:  game.Id,
+				Message: fmt.Sprintf("message %d", i),
+			})
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	chatResp, err := h.getGameChat(t, player1, &GetGameChatRequest{GameId: game.Id})
+	require.NoError(t, err)
+	require.Equal(t, messageCount, len(chatResp.Messages))
+	var messages []string
+	for i, message := range chatResp.Messages {
+		assert.Equal(t, i+1, message.Seq)
+		messages = append(messages, message.Message)
+	}
+	for i := 0; i < messageCount; i++ {
+		assert.Contains(t, messages, fmt.Sprintf("message %d", i))
+	}
+
+	// A message that loses the race for a sequence number is detected, so that it can be retried
+	_, err = h.gameServer.db.Exec("INSERT INTO game_chat (game_id, seq, timestamp, user_id, message) VALUES(?, ?, ?, ?, ?)",
+		game.Id, 1, time.Now().Unix(), player1, "duplicate")
+	require.Error(t, err)
+	assert.True(t, isDuplicateKeyError(err))
+	assert.False(t, isDuplicateKeyError(fmt.Errorf("some other error")))
 }
 
 func TestGameChatValidation(t *testing.T) {
@@ -14,7 +14,6 @@ import (
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
-	"os"
 	"testing"
 )
 
@@ -26,9 +25,7 @@ type TestHarness struct {
 func NewTestHarness(t *testing.T) *TestHarness {
 	db, err := sql.Open("sqlite", "file::memory:?cache=shared")
 	require.NoError(t, err)
-	bootstrapSql, err := os.ReadFile("bootstrap.sql")
-	require.NoError(t, err)
-	_, err = db.Exec(string(bootstrapSql))
+	err = migrateDatabase(db)
 	require.NoError(t, err)
 
 	gameMaps, err := maps.LoadMaps()
@@ -117,6 +114,39 @@ func (h *TestHarness) createUser(t *testing.T) string {
 	return userId.String()
 }
 
+// startTwoPlayerGame creates and starts a two player game on Rust Belt, returning the game's id and its owner
+func (h *TestHarness) startTwoPlayerGame(t *testing.T, provablyFair bool) (string, string) {
+	owner := h.createUser(t)
+	createRes, err := h.createGame(t, owner, &CreateGameRequest{
+		Name:         "game-name",
+		MinPlayers:   2,
+		MaxPlayers:   2,
+		MapName:      "rust_belt",
+		ProvablyFair: provablyFair,
+	})
+	require.NoError(t, err)
+	_, err = h.joinGame(t, h.createUser(t), &JoinGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	_, err = h.startGame(t, owner, &StartGameRequest{GameId: createRes.Id})
+	require.NoError(t, err)
+	return createRes.Id, owner
+}
+
+// playFirstTurn times out players until the game reaches its second turn, so that the log includes the goods growth
+// dice rolls at the end of the first one
+func (h *TestHarness) playFirstTurn(t *testing.T, gameId string, asUser string) {
+	for i := 0; i < 20; i++ {
+		err := h.gameServer.timeoutActivePlayer(gameId)
+		require.NoError(t, err)
+		viewRes, err := h.viewGame(t, asUser, &ViewGameRequest{GameId: gameId})
+		require.NoError(t, err)
+		if viewRes.GameState.TurnNumber > 1 {
+			return
+		}
+	}
+	require.Fail(t, "the game didn't reach its second turn")
+}
+
 // All of the API methods
 func (h *TestHarness) whoami(t *testing.T, asUser string, req *WhoAmIRequest) (*WhoAmIResponse, error) {
 	return doApiCall[WhoAmIRequest, WhoAmIResponse](h, t, asUser, "/api/whoami", req)
@@ -181,3 +211,39 @@ func (h *TestHarness) sendGameChat(t *testing.T, asUser string, req *SendGameCha
 func (h *TestHarness) pollGameStatus(t *testing.T, asUser string, req *PollGameStatusRequest) (*PollGameStatusResponse, error) {
 	return doApiCall[PollGameStatusRequest, PollGameStatusResponse](h, t, asUser, "/api/pollGameStatus", req)
 }
+
+func (h *TestHarness) getLegalMoves(t *testing.T, asUser string, req *GetLegalMovesRequest) (*GetLegalMovesResponse, error) {
+	return doApiCall[GetLegalMovesRequest, GetLegalMovesResponse](h, t, asUser, "/api/getLegalMoves", req)
+}
+
+func (h *TestHarness) previewMove(t *testing.T, asUser string, req *api.ConfirmMoveRequest) (*PreviewMoveResponse, error) {
+	return doApiCall[api.ConfirmMoveRequest, PreviewMoveResponse](h, t, asUser, "/api/previewMove", req)
+}
+
+func (h *TestHarness) undoMove(t *testing.T, asUser string, req *UndoMoveRequest) (*UndoMoveResponse, error) {
+	return doApiCall[UndoMoveRequest, UndoMoveResponse](h, t, asUser, "/api/undoMove", req)
+}
+
+func (h *TestHarness) getLeaderboard(t *testing.T, asUser string, req *GetLeaderboardRequest) (*GetLeaderboardResponse, error) {
+	return doApiCall[GetLeaderboardRequest, GetLeaderboardResponse](h, t, asUser, "/api/getLeaderboard", req)
+}
+
+func (h *TestHarness) uploadMap(t *testing.T, asUser string, req *UploadMapRequest) (*UploadMapResponse, error) {
+	return doApiCall[UploadMapRequest, UploadMapResponse](h, t, asUser, "/api/uploadMap", req)
+}
+
+func (h *TestHarness) listMaps(t *testing.T, asUser string, req *ListMapsRequest) (*ListMapsResponse, error) {
+	return doApiCall[ListMapsRequest, ListMapsResponse](h, t, asUser, "/api/listMaps", req)
+}
+
+func (h *TestHarness) exportGame(t *testing.T, asUser string, req *ExportGameRequest) (*ExportedGame, error) {
+	return doApiCall[ExportGameRequest, ExportedGame](h, t, asUser, "/api/exportGame", req)
+}
+
+func (h *TestHarness) rollbackGame(t *testing.T, asUser string, req *RollbackGameRequest) (*RollbackGameResponse, error) {
+	return doApiCall[RollbackGameRequest, RollbackGameResponse](h, t, asUser, "/api/rollbackGame", req)
+}
+
+func (h *TestHarness) voteRollback(t *testing.T, asUser string, req *VoteRollbackRequest) (*VoteRollbackResponse, error) {
+	return doApiCall[VoteRollbackRequest, VoteRollbackResponse](h, t, asUser, "/api/voteRollback", req)
+}
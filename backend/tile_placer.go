@@ -91,7 +91,7 @@ func (tp *tilePlacer) applyTrackTilePlacement(coordinate common.Coordinate,
 	// Every exit must lead to a passable terrain
 	for _, newRoute := range newRoutes {
 		for _, dir := range newRoute {
-			newHex := applyDirection(coordinate, dir)
+			newHex := common.ApplyDirection(coordinate, dir)
 			if newHex.X < 0 || newHex.Y < 0 ||
 				newHex.X >= tp.performer.gameMap.GetWidth() || newHex.Y >= tp.performer.gameMap.GetHeight() {
 				return invalidMoveErr("track cannot run off the edge of the board")
@@ -147,8 +147,8 @@ func (tp *tilePlacer) applyTrackTilePlacement(coordinate common.Coordinate,
 
 			if attachingLink == nil {
 				// Create a new route. The left or right side must be a city since it is not connecting to track
-				leftHex := applyDirection(coordinate, newRoute[0])
-				rightHex := applyDirection(coordinate, newRoute[1])
+				leftHex := common.ApplyDirection(coordinate, newRoute[0])
+				rightHex := common.ApplyDirection(coordinate, newRoute[1])
 				if tp.mapState.GetTileState(leftHex).isCity {
 					newLink := &common.Link{
 						SourceHex: leftHex,
@@ -191,7 +191,7 @@ func (tp *tilePlacer) applyTrackTilePlacement(coordinate common.Coordinate,
 					gameState.Links = DeleteFromSliceUnordered(slices.Index(gameState.Links, rightLink), gameState.Links)
 				} else {
 					// If the other side is a city, we need to mark the link as complete
-					rightHex := applyDirection(coordinate, remainingDirection)
+					rightHex := common.ApplyDirection(coordinate, remainingDirection)
 					if tp.mapState.GetTileState(rightHex).isCity {
 						attachingLink.Complete = true
 					}
@@ -204,7 +204,7 @@ func (tp *tilePlacer) applyTrackTilePlacement(coordinate common.Coordinate,
 }
 
 func (tp *tilePlacer) getAdjoiningLink(fromHex common.Coordinate, direction common.Direction) *common.Link {
-	hex := applyDirection(fromHex, direction)
+	hex := common.ApplyDirection(fromHex, direction)
 	ts := tp.mapState.GetTileState(hex)
 	opp := direction.Opposite()
 	for _, route := range ts.routes {
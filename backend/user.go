@@ -2,7 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
 )
 
 func (server *GameServer) getJoinedUsers(gameId string) (map[string]bool, error) {
@@ -27,6 +32,47 @@ func (server *GameServer) getJoinedUsers(gameId string) (map[string]bool, error)
 	return joinedUsers, nil
 }
 
+// assertCanViewGame returns an error if the user isn't allowed to see the game. Invite-only games can be seen by anyone
+// with the link until they start, so that invited players can join, and only by their players and admins after that.
+// A missing game is left for the caller to report.
+func (server *GameServer) assertCanViewGame(ctx *RequestContext, gameId string) error {
+	_, err := server.checkCanViewGame(ctx, gameId)
+	return err
+}
+
+// checkCanViewGame is assertCanViewGame, additionally returning whether the game has started. Once a started game can
+// be viewed it stays viewable, since neither its players nor its invite-only flag can change after that.
+func (server *GameServer) checkCanViewGame(ctx *RequestContext, gameId string) (started bool, err error) {
+	var inviteOnlyFlag int
+	var startedFlag int
+	err = server.db.QueryRow("SELECT invite_only,started FROM games WHERE id=?", gameId).Scan(&inviteOnlyFlag, &startedFlag)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch game row: %v", err)
+	}
+	if inviteOnlyFlag == 0 || startedFlag == 0 {
+		return startedFlag != 0, nil
+	}
+	return true, server.assertGamePlayerOrAdmin(ctx, gameId)
+}
+
+// assertGamePlayerOrAdmin returns an error unless the user has joined the game or is an admin
+func (server *GameServer) assertGamePlayerOrAdmin(ctx *RequestContext, gameId string) error {
+	if slices.Contains(server.config.AdminUserIds, ctx.User.Id) {
+		return nil
+	}
+	joinedUsers, err := server.getJoinedUsers(gameId)
+	if err != nil {
+		return err
+	}
+	if !joinedUsers[ctx.User.Id] {
+		return &api.HttpError{"you are not a player in this game", http.StatusForbidden}
+	}
+	return nil
+}
+
 func (server *GameServer) getUserById(userId string) (*User, error) {
 	stmt, err := server.db.Prepare("SELECT nickname FROM users WHERE id=?")
 	if err != nil {
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/JackOfMostTrades/eot/backend/api"
 	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 func DeleteFromSliceUnordered[T any](idx int, slice []T) []T {
@@ -27,18 +34,151 @@ func boolToInt(val bool) int {
 	return 0
 }
 
-func renderHexCoordinate(coordinate common.Coordinate) string {
-	var x int
-	if coordinate.Y%2 == 0 {
-		x = coordinate.X*2 + 1
-	} else {
-		x = coordinate.X*2 + 2
+// cloneGameState returns a deep copy of the given game state. It round-trips through JSON, which is how game states are
+// stored, so the copy always matches what would be loaded from the database and new fields never need handling here.
+func cloneGameState(gameState *common.GameState) (*common.GameState, error) {
+	gameStateBytes, err := json.Marshal(gameState)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal game state: %v", err)
 	}
+	clone := new(common.GameState)
+	err = json.Unmarshal(gameStateBytes, clone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game state: %v", err)
+	}
+	return clone, nil
+}
+
+// marshalRandomValues serializes the random values drawn during a move (or their bounds) for the game log
+func marshalRandomValues(values []int) (sql.NullString, error) {
+	if values == nil {
+		values = make([]int, 0)
+	}
+	valuesBytes, err := json.Marshal(values)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal random values: %v", err)
+	}
+	return sql.NullString{String: string(valuesBytes), Valid: true}, nil
+}
+
+// unmarshalRandomValues parses random values from the game log, returning nil if none were recorded
+func unmarshalRandomValues(valuesStr sql.NullString) ([]int, error) {
+	if !valuesStr.Valid {
+		return nil, nil
+	}
+	values := make([]int, 0)
+	err := json.Unmarshal([]byte(valuesStr.String), &values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse random values: %v", err)
+	}
+	return values, nil
+}
 
-	yLabel := string(rune((coordinate.Y % 26) + 'A'))
-	if coordinate.Y >= 26 {
-		yLabel = "A" + yLabel
+// marshalMapVariants serializes the variant rules a game was created with for the games table, or NULL if there are none
+func marshalMapVariants(variants []common.MapVariant) (sql.NullString, error) {
+	if len(variants) == 0 {
+		return sql.NullString{}, nil
 	}
+	variantsBytes, err := json.Marshal(variants)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal map variants: %v", err)
+	}
+	return sql.NullString{String: string(variantsBytes), Valid: true}, nil
+}
+
+// hideSealedBids blanks out the other players' bids in a sealed-bid auction that's still under way. Who has bid is
+// left in, since that's already in the game log.
+func hideSealedBids(gameState *common.GameState, viewer string) {
+	if gameState == nil || gameState.GamePhase != common.AUCTION_GAME_PHASE ||
+		gameState.Options.GetAuctionType() != common.SEALED_BID_AUCTION_TYPE {
+		return
+	}
+	for playerId := range gameState.AuctionState {
+		if playerId != viewer {
+			gameState.AuctionState[playerId] = 0
+		}
+	}
+}
 
-	return fmt.Sprintf("%s%d", yLabel, x)
+// hideSealedBidAction blanks out the amount of a bid made by anyone but the viewer. It's only for games with a sealed-bid
+// auction that are still being played, since each auction's bids are revealed in the game log once it finishes.
+func hideSealedBidAction(action *api.ConfirmMoveRequest, bidder string, viewer string) {
+	if action != nil && action.BidAction != nil && bidder != viewer {
+		action.BidAction = &api.BidAction{}
+	}
+}
+
+// hideSealedBidLogAction does the same for a game log entry, where the action is stored as JSON. The entry is shared
+// with other viewers, so a copy is returned if anything needs hiding.
+func hideSealedBidLogAction(entry *GameLogEntry, viewer string) (*GameLogEntry, error) {
+	if entry.UserId == viewer || entry.Action == "" || entry.Action == undoActionName || entry.Action == rollbackActionName {
+		return entry, nil
+	}
+	action := new(api.ConfirmMoveRequest)
+	err := json.Unmarshal([]byte(entry.Action), action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse logged action: %v", err)
+	}
+	if action.BidAction == nil {
+		return entry, nil
+	}
+	hideSealedBidAction(action, entry.UserId, viewer)
+	actionBytes, err := json.Marshal(action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal logged action: %v", err)
+	}
+	hidden := *entry
+	hidden.Action = string(actionBytes)
+	return &hidden, nil
+}
+
+// marshalGameOptions serializes the house rules a game was created with for the games table, or NULL if there are none
+func marshalGameOptions(options *common.GameOptions) (sql.NullString, error) {
+	if options == nil {
+		return sql.NullString{}, nil
+	}
+	optionsBytes, err := json.Marshal(options)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal game options: %v", err)
+	}
+	return sql.NullString{String: string(optionsBytes), Valid: true}, nil
+}
+
+// unmarshalGameOptions parses the house rules a game was created with from the games table
+func unmarshalGameOptions(optionsStr sql.NullString) (*common.GameOptions, error) {
+	if !optionsStr.Valid {
+		return nil, nil
+	}
+	options := new(common.GameOptions)
+	err := json.Unmarshal([]byte(optionsStr.String), options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse game options: %v", err)
+	}
+	return options, nil
+}
+
+// unmarshalMapVariants parses the variant rules a game was created with from the games table
+func unmarshalMapVariants(variantsStr sql.NullString) ([]common.MapVariant, error) {
+	if !variantsStr.Valid {
+		return nil, nil
+	}
+	var variants []common.MapVariant
+	err := json.Unmarshal([]byte(variantsStr.String), &variants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse map variants: %v", err)
+	}
+	return variants, nil
+}
+
+// isDuplicateKeyError checks whether an insert failed because a row with the same key already exists
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+	return false
 }
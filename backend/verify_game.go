@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+)
+
+// Upper bound on the number of times a single logged action is replayed while searching for the random draws that
+// reproduce it
+const maxReplayAttempts = 100000
+
+// inferringRandProvider searches for the random draws made during a logged action. Each replay of the action follows
+// the current guess for every draw (defaulting to zero for draws not guessed yet), and backtrack moves on to the next
+// guess once a replay turns out not to match the logged state. If the draws were recorded in the log, it just replays
+// those.
+type inferringRandProvider struct {
+	// The state being replayed, and the logged state it should end up as
+	state  *common.GameState
+	target *common.GameState
+
+	draws    []int
+	bounds   []int
+	calls    int
+	recorded bool
+	// Number of draws known to be consistent with the logged state when the replay was abandoned, or -1
+	failedAt int
+	// Number of board cubes before the first draw of this replay
+	cubeBase int
+	// The state seen at each draw, keyed by the draw's index and the state, mapped to the earlier draws that led to it.
+	// Different draws often have the same effect (e.g. rolling for a city whose goods growth column is empty), and
+	// there's no point in searching the same state twice.
+	seen map[string]string
+}
+
+func (p *inferringRandProvider) reset(state *common.GameState) {
+	p.state = state
+	p.calls = 0
+	p.failedAt = -1
+	p.cubeBase = -1
+}
+
+func (p *inferringRandProvider) RandN(n int) (int, error) {
+	if p.recorded {
+		idx := p.calls
+		p.calls += 1
+		if idx >= len(p.draws) {
+			return 0, fmt.Errorf("the move drew more random values than were recorded")
+		}
+		if p.draws[idx] >= n {
+			return 0, fmt.Errorf("recorded draw %d greater than n=%d", p.draws[idx], n)
+		}
+		return p.draws[idx], nil
+	}
+
+	if p.cubeBase < 0 {
+		p.cubeBase = len(p.state.Cubes)
+	}
+	if !p.consistent() {
+		p.failedAt = p.calls
+		return 0, fmt.Errorf("random draws so far are inconsistent with the logged state")
+	}
+	if p.calls > 0 {
+		stateBytes, err := json.Marshal(p.state)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal game state: %v", err)
+		}
+		key := fmt.Sprintf("%d:%s", p.calls, stateBytes)
+		prefix := fmt.Sprint(p.draws[:p.calls])
+		if seenPrefix, ok := p.seen[key]; ok && seenPrefix != prefix {
+			p.failedAt = p.calls
+			return 0, fmt.Errorf("random draws so far lead to a state that has already been searched")
+		}
+		p.seen[key] = prefix
+	}
+
+	idx := p.calls
+	p.calls += 1
+	if idx == len(p.draws) {
+		p.draws = append(p.draws, 0)
+		p.bounds = append(p.bounds, n)
+	}
+	if p.draws[idx] >= n {
+		return 0, fmt.Errorf("inferred draw %d greater than n=%d", p.draws[idx], n)
+	}
+	return p.draws[idx], nil
+}
+
+// consistent checks the effects of the draws made so far against the logged state. Everything an action does before
+// its first draw (e.g. delivering goods) has already happened by then, and draws only ever add cubes to the board, take
+// cubes out of the bag and the goods growth chart, or fill in production cubes, so those are the only checks made.
+func (p *inferringRandProvider) consistent() bool {
+	for i := p.cubeBase; i < len(p.state.Cubes); i++ {
+		if i >= len(p.target.Cubes) || *p.state.Cubes[i] != *p.target.Cubes[i] {
+			return false
+		}
+	}
+	for color, count := range p.state.CubeBag {
+		if count < p.target.CubeBag[color] {
+			return false
+		}
+	}
+	if len(p.state.ProductionCubes) == len(p.target.ProductionCubes) {
+		for i, color := range p.state.ProductionCubes {
+			if color != common.NONE_COLOR && color != p.target.ProductionCubes[i] {
+				return false
+			}
+		}
+	}
+	for i, col := range p.state.GoodsGrowth {
+		for j, color := range col {
+			if color == common.NONE_COLOR && i < len(p.target.GoodsGrowth) && j < len(p.target.GoodsGrowth[i]) &&
+				p.target.GoodsGrowth[i][j] != common.NONE_COLOR {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// backtrack advances to the next guess for the draws, returning false once every possibility has been tried
+func (p *inferringRandProvider) backtrack() bool {
+	keep := p.calls
+	if p.failedAt >= 0 && p.failedAt < keep {
+		keep = p.failedAt
+	}
+	p.draws = p.draws[:keep]
+	p.bounds = p.bounds[:keep]
+	for len(p.draws) > 0 {
+		last := len(p.draws) - 1
+		p.draws[last] += 1
+		if p.draws[last] < p.bounds[last] {
+			return true
+		}
+		p.draws = p.draws[:last]
+		p.bounds = p.bounds[:last]
+	}
+	return false
+}
+
+// compareReplayedStep returns an error describing how the replayed action differs from the logged one, if it does
+func compareReplayedStep(handler *confirmMoveHandler, step *ExportedGameStep) error {
+	if handler.activePlayer != step.ExpectedActivePlayer {
+		return fmt.Errorf("active player is %s, but the log has %s", handler.activePlayer, step.ExpectedActivePlayer)
+	}
+
+	// Compare the serialized states, since that's what was stored
+	var replayed map[string]json.RawMessage
+	var logged map[string]json.RawMessage
+	for _, s := range []struct {
+		gameState *common.GameState
+		fields    *map[string]json.RawMessage
+	}{{handler.gameState, &replayed}, {step.ExpectedGameState, &logged}} {
+		stateBytes, err := json.Marshal(s.gameState)
+		if err != nil {
+			return fmt.Errorf("failed to marshal game state: %v", err)
+		}
+		err = json.Unmarshal(stateBytes, s.fields)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal game state: %v", err)
+		}
+	}
+	var differing []string
+	for field, value := range replayed {
+		if !bytes.Equal(value, logged[field]) {
+			differing = append(differing, field)
+		}
+	}
+	for field := range logged {
+		if _, ok := replayed[field]; !ok {
+			differing = append(differing, field)
+		}
+	}
+	if len(differing) > 0 {
+		sort.Strings(differing)
+		return fmt.Errorf("game state differs in %v", differing)
+	}
+	return nil
+}
+
+// replayStep applies a logged action to the given state, searching for random draws that reproduce the logged result
+func replayStep(gameMap maps.GameMap, playerIdToNick map[string]string, gameState *common.GameState,
+	activePlayer string, step *ExportedGameStep) (*confirmMoveHandler, []int, error) {
+
+	provider := &inferringRandProvider{target: step.ExpectedGameState, seen: make(map[string]string)}
+	if step.RandomValues != nil {
+		provider.draws = step.RandomValues
+		provider.recorded = true
+	}
+	var firstErr error
+	for attempt := 0; attempt < maxReplayAttempts; attempt++ {
+		state, err := cloneGameState(gameState)
+		if err != nil {
+			return nil, nil, err
+		}
+		provider.reset(state)
+		handler := &confirmMoveHandler{
+			gameMap:        gameMap,
+			gameState:      state,
+			activePlayer:   activePlayer,
+			playerIdToNick: playerIdToNick,
+			randProvider:   provider,
+			gameFinished:   false,
+			reversible:     true,
+		}
+
+		err = handler.handleAction(step.Action)
+		if provider.recorded {
+			if err != nil {
+				return nil, nil, fmt.Errorf("action failed with the recorded random draws: %v", err)
+			}
+			if provider.calls != len(provider.draws) {
+				return nil, nil, fmt.Errorf("the move drew %d random values, but %d were recorded", provider.calls, len(provider.draws))
+			}
+			err = compareReplayedStep(handler, step)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%v with the recorded random draws", err)
+			}
+			return handler, provider.draws, nil
+		}
+		if err == nil {
+			err = compareReplayedStep(handler, step)
+			if err == nil {
+				return handler, provider.draws[:provider.calls], nil
+			}
+		} else if provider.failedAt < 0 {
+			err = fmt.Errorf("action failed: %v", err)
+		} else {
+			err = nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+
+		if !provider.backtrack() {
+			if provider.calls == 0 {
+				// Nothing random happened, so there was only ever the one way to replay the action
+				return nil, nil, firstErr
+			}
+			break
+		}
+	}
+
+	if firstErr == nil {
+		return nil, nil, fmt.Errorf("no random draws reproduce the logged state")
+	}
+	return nil, nil, fmt.Errorf("no random draws reproduce the logged state (%v)", firstErr)
+}
+
+// verifyGameSteps replays the logged actions starting from the first logged state, and returns the random draws that
+// reproduce the logged states or an error describing the first step where the replay diverges from the log
+func verifyGameSteps(gameMap maps.GameMap, playerIdToNick map[string]string, steps []*ExportedGameStep) ([]int, error) {
+	if len(steps) == 0 || steps[0].ExpectedGameState == nil {
+		return nil, fmt.Errorf("game log has no starting state")
+	}
+	gameState := steps[0].ExpectedGameState
+	activePlayer := steps[0].ExpectedActivePlayer
+	// The replayed state before each step that can still be undone, for undos
+	var priorStates []*common.GameState
+	// The replayed result of every step, for rollbacks
+	history := []*confirmMoveHandler{{gameState: gameState, activePlayer: activePlayer}}
+
+	randomValues := make([]int, 0)
+	for idx := 1; idx < len(steps); idx++ {
+		step := steps[idx]
+		if step.Action == nil || step.ExpectedGameState == nil {
+			return nil, fmt.Errorf("step %d is missing its action or game state", idx)
+		}
+
+		var handler *confirmMoveHandler
+		var err error
+		if step.Action.ActionName == undoActionName {
+			// An undo restores the state from before the previous step, and gives the turn back to whoever undid it
+			if len(priorStates) == 0 {
+				err = fmt.Errorf("there is no move to undo")
+			} else {
+				handler = &confirmMoveHandler{gameState: priorStates[len(priorStates)-1], activePlayer: step.UserId}
+				priorStates = priorStates[:len(priorStates)-1]
+				err = compareReplayedStep(handler, step)
+			}
+		} else if step.Action.ActionName == rollbackActionName {
+			// A rollback restores the result of some earlier step, but the log doesn't say which one
+			err = fmt.Errorf("no earlier step has the same result")
+			for i := len(history) - 1; i >= 0; i-- {
+				if compareReplayedStep(history[i], step) == nil {
+					handler = history[i]
+					err = nil
+					break
+				}
+			}
+		} else {
+			var draws []int
+			handler, draws, err = replayStep(gameMap, playerIdToNick, gameState, activePlayer, step)
+			randomValues = append(randomValues, draws...)
+			priorStates = append(priorStates, gameState)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s by %s at %d) diverges from the log: %v", idx,
+				step.Action.ActionName, step.UserId, step.Timestamp, err)
+		}
+		history = append(history, handler)
+		gameState = handler.gameState
+		activePlayer = handler.activePlayer
+	}
+	return randomValues, nil
+}
+
+// verifyGame replays a stored game through the rules engine and returns an error if it doesn't reproduce the log
+func (server *GameServer) verifyGame(gameId string) error {
+	export, err := server.exportGameData(gameId)
+	if err != nil {
+		return err
+	}
+	gameMap, err := server.getGameMap(export.MapName)
+	if err != nil {
+		return err
+	}
+	if gameMap == nil {
+		return fmt.Errorf("unknown map: %s", export.MapName)
+	}
+	playerIdToNick := make(map[string]string)
+	for _, player := range export.Players {
+		playerIdToNick[player.Id] = player.Nickname
+	}
+
+	randomValues, err := verifyGameSteps(gameMap, playerIdToNick, export.Steps)
+	if err != nil {
+		return fmt.Errorf("game %s failed verification: %v", gameId, err)
+	}
+	if export.Game.RandomSeed != "" {
+		err = verifyRandomDraws(export.Game.RandomSeed, export.Game.RandomSeedCommitment, export.Steps)
+		if err != nil {
+			return fmt.Errorf("game %s failed verification: %v", gameId, err)
+		}
+	}
+	slog.Info("Verified game log", "gameId", gameId, "steps", len(export.Steps), "randomDraws", len(randomValues))
+	return nil
+}
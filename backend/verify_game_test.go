@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/JackOfMostTrades/eot/backend/api"
+	"github.com/JackOfMostTrades/eot/backend/common"
+	"github.com/JackOfMostTrades/eot/backend/maps"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyGameLogCases(t *testing.T) {
+	gameMaps, err := maps.LoadMaps()
+	require.NoError(t, err)
+
+	for _, gameId := range []string{"5f3b56c3-ae82-495e-b153-39245820a5ad", "62915bcc-04ed-48a2-a3c5-4b54782c5efb"} {
+		definition := new(ExportedGame)
+		defBytes, err := os.ReadFile("testlogs/" + gameId + ".json")
+		require.NoError(t, err)
+		err = json.Unmarshal(defBytes, definition)
+		require.NoError(t, err)
+
+		playerIdToNick := make(map[string]string)
+		for _, playerId := range definition.Steps[0].ExpectedGameState.PlayerOrder {
+			playerIdToNick[playerId] = playerId
+		}
+
+		// The draws aren't necessarily the same as the ones recorded in the test case, since different draws can have
+		// the same effect (e.g. rolling for a city whose goods growth column is empty), but they must replay the game
+		randomValues, err := verifyGameSteps(gameMaps[definition.MapName], playerIdToNick, definition.Steps)
+		require.NoError(t, err)
+		assert.NotEmpty(t, randomValues)
+
+		// Any change to a logged state is caught
+		lastStep := definition.Steps[len(definition.Steps)-1]
+		lastStep.ExpectedGameState.PlayerCash[lastStep.ExpectedGameState.PlayerOrder[0]] += 1
+		_, err = verifyGameSteps(gameMaps[definition.MapName], playerIdToNick, definition.Steps)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "playerCash")
+	}
+}
+
+func TestVerifyGame(t *testing.T) {
+	h := NewTestHarness(t)
+	defer h.Close()
+
+	gameId, player1 := h.startTwoPlayerGame(t, false)
+
+	// Include an undo in the log
+	viewRes, err := h.viewGame(t, player1, &ViewGameRequest{GameId: gameId})
+	require.NoError(t, err)
+	_, err = h.confirmMove(t, viewRes.ActivePlayer, &api.ConfirmMoveRequest{
+		GameId:       gameId,
+		ActionName:   api.SharesActionName,
+		SharesAction: &api.SharesAction{Amount: 1},
+	})
+	require.NoError(t, err)
+	_, err = h.undoMove(t, viewRes.ActivePlayer, &UndoMoveRequest{GameId: gameId})
+	require.NoError(t, err)
+
+	h.playFirstTurn(t, gameId, player1)
+
+	err = runTask(h.gameServer, []string{"verify-game", gameId})
+	require.NoError(t, err)
+
+	// Tamper with the cubes added to the board in the log entry for the goods growth phase
+	var seq int
+	var gameStateStr string
+	err = h.gameServer.db.QueryRow("SELECT seq,new_game_state FROM game_log WHERE game_id=? ORDER BY seq DESC LIMIT 1", gameId).
+		Scan(&seq, &gameStateStr)
+	require.NoError(t, err)
+	gameState := new(common.GameState)
+	err = json.Unmarshal([]byte(gameStateStr), gameState)
+	require.NoError(t, err)
+	lastCube := gameState.Cubes[len(gameState.Cubes)-1]
+	lastCube.Color = (lastCube.Color % common.WHITE) + 1
+	gameStateBytes, err := json.Marshal(gameState)
+	require.NoError(t, err)
+	_, err = h.gameServer.db.Exec("UPDATE game_log SET new_game_state=? WHERE game_id=? AND seq=?", string(gameStateBytes), gameId, seq)
+	require.NoError(t, err)
+
+	err = runTask(h.gameServer, []string{"verify-game", gameId})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "diverges from the log")
+}
@@ -25,6 +25,7 @@ import DevLogin from "./pages/DevLogin.tsx";
 import SignInPage from "./pages/SignInPage.tsx";
 import RegisterPage from "./pages/RegisterPage.tsx";
 import MyGames from "./pages/MyGames.tsx";
+import Leaderboard from "./pages/Leaderboard.tsx";
 import {createMedia} from '@artsy/fresnel'
 import ProfilePage from "./pages/ProfilePage.tsx";
 import LoginPage from "./pages/LoginPage.tsx";
@@ -82,6 +83,9 @@ function DesktopMainMenu() {
                     <Menu.Item>
                         <NavLink to='/games'>All Games</NavLink>
                     </Menu.Item>
+                    <Menu.Item>
+                        <NavLink to='/leaderboard'>Leaderboard</NavLink>
+                    </Menu.Item>
                 </> : null}
 
                 <MenuMenu position='right'>
@@ -133,6 +137,7 @@ function MobileMainMenu() {
                         <DropdownItem onClick={() => navigate('/about')}>About</DropdownItem>
                         {userSessionContext.userInfo ? <>
                         <DropdownItem onClick={() => navigate('/games')}>All Games</DropdownItem>
+                            <DropdownItem onClick={() => navigate('/leaderboard')}>Leaderboard</DropdownItem>
                             <DropdownItem onClick={() => navigate('/mygames')}>My Games</DropdownItem>
                             <DropdownItem onClick={() => logout()}>Logout</DropdownItem>
                         </> : null}
@@ -166,6 +171,7 @@ function App() {
                                 <Route path="/games/:gameId" element={<ViewGamePage />}/>
                                 <Route path="/games" element={<Games />}/>
                                 <Route path="/mygames" element={<MyGames />}/>
+                                <Route path="/leaderboard" element={<Leaderboard />}/>
                                 <Route path="/about" element={<About />}/>
                                 <Route path="/dev/login/:nickname" element={<DevLogin />}/>
                                 <Route path="/signin" element={<SignInPage />}/>
@@ -69,6 +69,9 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
         playerById[player.id] = player;
     }
 
+    // Sealed bids are hidden from the other players until everyone has bid
+    let sealedBid = game.gameState.options?.auctionType === 'sealed_bid';
+
     let currentBids: ReactNode[] = [];
     for (let playerId of game.gameState.playerOrder) {
         let player = playerById[playerId];
@@ -76,7 +79,11 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
         if (game.gameState.auctionState) {
             bid = game.gameState.auctionState[playerId] || 0;
         }
-        if (bid < 0) {
+        if (sealedBid) {
+            let hasBid = game.gameState.auctionState !== undefined && playerId in game.gameState.auctionState;
+            let bidText = !hasBid ? "no bid yet" : playerId === userSession.userInfo?.user.id ? bid : "bid placed";
+            currentBids.push(<ListItem>{player.nickname}: <span style={{fontStyle: "italic"}}>{bidText}</span></ListItem>)
+        } else if (bid < 0) {
             let position = game.gameState.playerOrder.length + bid + 1;
             currentBids.push(<ListItem>{player.nickname}: <span style={{fontStyle: "italic"}}>passed (player {position})</span></ListItem>)
         } else {
@@ -92,7 +99,7 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
     } else {
         let currentCash = game.gameState.playerCash[game.activePlayer];
         let currentMaxBid = 0;
-        for (let playerId of game.gameState.playerOrder) {
+        for (let playerId of sealedBid ? [] : game.gameState.playerOrder) {
             let bid = 0;
             if (game.gameState.auctionState) {
                 bid = game.gameState.auctionState[playerId] || 0;
@@ -113,6 +120,7 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
             setLoading(true);
             ConfirmMove({
                 gameId: game.id,
+                version: game.version,
                 actionName: "bid",
                 bidAction: {
                     amount: val,
@@ -126,13 +134,15 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
             });
         }
 
-        let hasTurnOrderPass = game.gameState.playerActions[game.activePlayer] === 'turn_order_pass';
+        // Turn order pass wins ties in a sealed-bid auction, so there's nothing to choose
+        let hasTurnOrderPass = !sealedBid && game.gameState.playerActions[game.activePlayer] === 'turn_order_pass';
         let turnOrderPassButton: ReactNode;
         if (hasTurnOrderPass) {
             turnOrderPassButton = <><Button secondary loading={loading} onClick={() => doBid(0)}>Turn Order Pass</Button></>
         }
 
         content = <>
+            {sealedBid ? <p>Everyone makes one secret bid and pays it. The highest bids go first, with ties going to turn order pass and then to the current turn order.</p> : null}
             <p>Choose your bid: </p>
             <Dropdown selection
                       value={amount}
@@ -152,7 +162,7 @@ function AuctionAction({game, onDone}: {game: ViewGameResponse, onDone: () => Pr
                 } else {
                     doBid(-1)
                 }
-            }}>Pass</Button>
+            }}>{sealedBid ? "Bid Nothing" : "Pass"}</Button>
             <ConfirmBidWithTopModal open={showBidWithTopModal} onConfirm={() => {
                 setShowBidWithTopModal(false);
                 doBid(amount);
@@ -155,6 +155,7 @@ function BuildActionSelector({game, onDone}: {game: ViewGameResponse, onDone: ()
                 setLoading(true);
                 ConfirmMove({
                     gameId: game.id,
+                    version: game.version,
                     actionName: "build",
                     buildAction: action,
                 }).then(() => {
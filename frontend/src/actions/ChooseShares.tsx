@@ -28,7 +28,7 @@ function ChooseShares({game, map, onDone}: {game: ViewGameResponse, map: GameMap
     }
 
     let currentShares = game.gameState.playerShares[game.activePlayer];
-    let sharesLimit = map.getSharesLimit();
+    let sharesLimit = game.gameState.options?.sharesLimit || map.getSharesLimit();
     let options: DropdownItemProps[] = [];
     for (let i = 0; i <= sharesLimit-currentShares; i++) {
         options.push({
@@ -48,6 +48,7 @@ function ChooseShares({game, map, onDone}: {game: ViewGameResponse, map: GameMap
             setLoading(true);
             ConfirmMove({
                 gameId: game.id,
+                version: game.version,
                 actionName: "shares",
                 sharesAction: {
                     amount: amount,
@@ -37,58 +37,78 @@ function FinalScore({game}: {game: ViewGameResponse}) {
         scores.set(row, new Map());
     }
 
-    //let scores: { [playerId: string]: number} = {}
+    let bankrupt: Set<string> = new Set();
+    let playerIds: string[] = [];
+    let playersById: { [playerId: string]: User} = {};
     for (let player of game.joinedUsers) {
-        let income = game.gameState.playerIncome[player.id];
-        if (income < 0) {
-            scores.get(ScoreRow.TOTAL_VPS)!.set(player.id, -1);
-            continue;
-        }
-
-        let shares = game.gameState.playerShares[player.id];
+        playersById[player.id] = player;
+    }
 
-        let trackCount = 0;
-        for (let link of game.gameState.links) {
-            if (!link.complete || link.owner !== player.id) {
+    if (game.finalScores) {
+        // Games scored by the server list players in order of their final place
+        for (let score of game.finalScores) {
+            playerIds.push(score.playerId);
+            if (score.bankrupt) {
+                bankrupt.add(score.playerId);
+                continue;
+            }
+            scores.get(ScoreRow.INCOME_VPS)!.set(score.playerId, score.incomePoints);
+            scores.get(ScoreRow.SHARE_VPS)!.set(score.playerId, score.sharesPoints);
+            scores.get(ScoreRow.TRACK_VPS)!.set(score.playerId, score.trackPoints);
+            scores.get(ScoreRow.TOTAL_VPS)!.set(score.playerId, score.total);
+        }
+    } else {
+        // Older games were finished before the server computed scores
+        for (let player of game.joinedUsers) {
+            playerIds.push(player.id);
+            let income = game.gameState.playerIncome[player.id];
+            if (income < 0) {
+                bankrupt.add(player.id);
                 continue;
             }
 
-            let hex = link.sourceHex;
-            // Count steps that aren't part of a city (always adding one for teleport links)
-            for (let i = 0; i < link.steps.length; i++) {
-                if (!isCity(game.gameState, map, hex)) {
-                    trackCount += 1;
+            let shares = game.gameState.playerShares[player.id];
+
+            let trackCount = 0;
+            for (let link of game.gameState.links) {
+                if (!link.complete || link.owner !== player.id) {
+                    continue;
                 }
-                let teleportEdge = applyTeleport(map, game.gameState, undefined, hex, link.steps[i]);
-                let nextHex: Coordinate;
-                if (teleportEdge !== undefined) {
+
+                let hex = link.sourceHex;
+                // Count steps that aren't part of a city (always adding one for teleport links)
+                for (let i = 0; i < link.steps.length; i++) {
+                    if (!isCity(game.gameState, map, hex)) {
+                        trackCount += 1;
+                    }
+                    let teleportEdge = applyTeleport(map, game.gameState, undefined, hex, link.steps[i]);
+                    let nextHex: Coordinate;
+                    if (teleportEdge !== undefined) {
+                        trackCount += 1;
+                        nextHex = teleportEdge.hex;
+                    } else {
+                        nextHex = applyDirection(hex, link.steps[i]);
+                    }
+                    hex = nextHex;
+                }
+                if (!isCity(game.gameState, map, hex)) {
                     trackCount += 1;
-                    nextHex = teleportEdge.hex;
-                } else {
-                    nextHex = applyDirection(hex, link.steps[i]);
                 }
-                hex = nextHex;
             }
-            if (!isCity(game.gameState, map, hex)) {
-                trackCount += 1;
-            }
-        }
 
-        scores.get(ScoreRow.INCOME_VPS)!.set(player.id, income*3);
-        scores.get(ScoreRow.SHARE_VPS)!.set(player.id, shares*-3);
-        scores.get(ScoreRow.TRACK_VPS)!.set(player.id, trackCount);
-        scores.get(ScoreRow.TOTAL_VPS)!.set(player.id, income*3 - shares*3 + trackCount);
-    }
+            scores.get(ScoreRow.INCOME_VPS)!.set(player.id, income*3);
+            scores.get(ScoreRow.SHARE_VPS)!.set(player.id, shares*-3);
+            scores.get(ScoreRow.TRACK_VPS)!.set(player.id, trackCount);
+            scores.get(ScoreRow.TOTAL_VPS)!.set(player.id, income*3 - shares*3 + trackCount);
+        }
 
-    let playerIds = [];
-    let playersById: { [playerId: string]: User} = {};
-    for (let player of game.joinedUsers) {
-        playerIds.push(player.id);
-        playersById[player.id] = player;
+        playerIds.sort((a, b) => {
+            if (bankrupt.has(a) || bankrupt.has(b)) {
+                return (bankrupt.has(a) ? 1 : 0) - (bankrupt.has(b) ? 1 : 0);
+            }
+            return scores.get(ScoreRow.TOTAL_VPS)!.get(b)! - scores.get(ScoreRow.TOTAL_VPS)!.get(a)!;
+        });
     }
-    playerIds.sort((a, b) => {
-        return scores.get(ScoreRow.TOTAL_VPS)!.get(b)! - scores.get(ScoreRow.TOTAL_VPS)!.get(a)!;
-    });
 
     return <>
         <Header as='h2'>Final Scores</Header>
@@ -103,23 +123,26 @@ function FinalScore({game}: {game: ViewGameResponse}) {
                 <TableBody>
                     <TableRow>
                         <TableCell>Income VPs</TableCell>
-                        {playerIds.map(playerId => <TableCell key={playerId}>{scores.get(ScoreRow.TOTAL_VPS)!.get(playerId)! >= 0 ? scores.get(ScoreRow.INCOME_VPS)!.get(playerId) : ""}</TableCell>)}
+                        {playerIds.map(playerId => <TableCell key={playerId}>{!bankrupt.has(playerId) ? scores.get(ScoreRow.INCOME_VPS)!.get(playerId) : ""}</TableCell>)}
                     </TableRow>
                     <TableRow>
                         <TableCell>Shares VPs</TableCell>
-                        {playerIds.map(playerId => <TableCell key={playerId}>{scores.get(ScoreRow.TOTAL_VPS)!.get(playerId)! >= 0 ? scores.get(ScoreRow.SHARE_VPS)!.get(playerId) : ""}</TableCell>)}
+                        {playerIds.map(playerId => <TableCell key={playerId}>{!bankrupt.has(playerId) ? scores.get(ScoreRow.SHARE_VPS)!.get(playerId) : ""}</TableCell>)}
                     </TableRow>
                     <TableRow>
                         <TableCell>Track VPs</TableCell>
-                        {playerIds.map(playerId => <TableCell key={playerId}>{scores.get(ScoreRow.TOTAL_VPS)!.get(playerId)! >= 0 ? scores.get(ScoreRow.TRACK_VPS)!.get(playerId) : ""}</TableCell>)}
+                        {playerIds.map(playerId => <TableCell key={playerId}>{!bankrupt.has(playerId) ? scores.get(ScoreRow.TRACK_VPS)!.get(playerId) : ""}</TableCell>)}
                     </TableRow>
                     <TableRow warning>
                         <TableCell>Total VPs</TableCell>
-                        {playerIds.map(playerId => <TableCell key={playerId}>{scores.get(ScoreRow.TOTAL_VPS)!.get(playerId)! >= 0 ? scores.get(ScoreRow.TOTAL_VPS)!.get(playerId) : <span style={{fontStyle: "italic"}}>bankrupt</span>}</TableCell>)}
+                        {playerIds.map(playerId => <TableCell key={playerId}>{!bankrupt.has(playerId) ? scores.get(ScoreRow.TOTAL_VPS)!.get(playerId) : <span style={{fontStyle: "italic"}}>bankrupt</span>}</TableCell>)}
                     </TableRow>
                 </TableBody>
             </Table>
         </div>
+        {game.randomSeed ? <p>
+            This game used provably fair randomness. Random seed: <code>{game.randomSeed}</code> (SHA-256: <code>{game.randomSeedCommitment}</code>)
+        </p> : null}
     </>
 }
 
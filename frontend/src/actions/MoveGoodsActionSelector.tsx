@@ -203,6 +203,7 @@ function MoveGoodsActionSelector({game, onDone}: {game: ViewGameResponse, onDone
                     setShowConfirmPassModal(false);
                     ConfirmMove({
                         gameId: game.id,
+                        version: game.version,
                         actionName: "move_goods",
                         moveGoodsAction: {},
                     }).then(() => {
@@ -220,6 +221,7 @@ function MoveGoodsActionSelector({game, onDone}: {game: ViewGameResponse, onDone
                     setLoading(true);
                     ConfirmMove({
                         gameId: game.id,
+                        version: game.version,
                         actionName: "move_goods",
                         moveGoodsAction: {
                             startingLocation: step.selectedOrigin,
@@ -239,6 +241,7 @@ function MoveGoodsActionSelector({game, onDone}: {game: ViewGameResponse, onDone
                     setLoading(true);
                     ConfirmMove({
                         gameId: game.id,
+                        version: game.version,
                         actionName: "move_goods",
                         moveGoodsAction: {loco: true},
                     }).then(() => {
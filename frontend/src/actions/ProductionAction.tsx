@@ -70,6 +70,7 @@ function ProductionAction({game, onDone}: {game: ViewGameResponse, onDone: () =>
                 setLoading(true);
                 ConfirmMove({
                     gameId: game.id,
+                    version: game.version,
                     actionName: "produce_goods",
                     produceGoodsAction: action,
                 }).then(() => {
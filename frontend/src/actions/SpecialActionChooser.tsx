@@ -54,6 +54,7 @@ function SpecialActionChooser({game, onDone}: {game: ViewGameResponse, onDone: (
         setLoading(true);
         ConfirmMove({
             gameId: game.id,
+            version: game.version,
             actionName: "choose_action",
             chooseAction: {
                 action: action as SpecialAction,
@@ -68,6 +69,12 @@ function SpecialActionChooser({game, onDone}: {game: ViewGameResponse, onDone: (
     }
 
     let availableSpecialActions: SpecialAction[] = ['first_move', 'first_build', 'engineer', 'loco', 'urbanization', 'production', 'turn_order_pass'];
+    if (game.gameState.turnNumber === 1 && game.gameState.mapVariants?.includes('no_first_turn_urbanization')) {
+        availableSpecialActions = availableSpecialActions.filter(specialAction => specialAction !== 'urbanization');
+    }
+    if (game.gameState.options?.noTurnOrderPass || game.gameState.options?.auctionType === 'fixed_order') {
+        availableSpecialActions = availableSpecialActions.filter(specialAction => specialAction !== 'turn_order_pass');
+    }
     for (let playerId of Object.keys(game.gameState.playerActions)) {
         let action = game.gameState.playerActions[playerId];
         if (action) {
@@ -105,6 +105,12 @@ export interface CreateGameRequest {
     maxPlayers: number;
     mapName: string;
     inviteOnly: boolean;
+    moveTimeLimitHours: number;
+    provablyFair: boolean;
+    // Variant rules to play with, which the map must support
+    mapVariants?: MapVariant[];
+    // House rules to play with
+    options?: GameOptions;
 }
 export interface CreateGameResponse {
     id: string;
@@ -116,6 +122,23 @@ export function CreateGame(req: CreateGameRequest): Promise<CreateGameResponse>
 
 export type SpecialAction = 'first_move' | 'first_build' | 'engineer' | 'loco' | 'urbanization' | 'production' | 'turn_order_pass'
 
+export type MapVariant = 'no_first_turn_urbanization' | 'fixed_starting_cubes' | 'first_turn_extra_share'
+
+export type AuctionType = 'standard' | 'sealed_bid' | 'pay_full_bid' | 'fixed_order'
+
+// House rules chosen when creating a game. Options left unset keep the normal rule.
+export interface GameOptions {
+    startingCash?: number;
+    startingShares?: number;
+    // Overrides the map's limit on how many shares a player can take
+    sharesLimit?: number;
+    // Overrides the map's number of turns
+    turnLimit?: number;
+    noTurnOrderPass?: boolean;
+    // Replaces the standard auction, mostly for learning games
+    auctionType?: AuctionType;
+}
+
 export interface User {
     nickname: string;
     id: string;
@@ -210,6 +233,10 @@ export interface GameState {
 
     // State specific to the map
     mapState: any|undefined
+    // The variant rules the game was created with
+    mapVariants?: MapVariant[];
+    // The house rules the game was created with
+    options?: GameOptions;
 }
 export interface ViewGameRequest {
     gameId: string;
@@ -227,6 +254,33 @@ export interface ViewGameResponse {
     joinedUsers: User[];
     gameState?: GameState;
     inviteOnly: boolean;
+    moveTimeLimitHours: number;
+    moveDeadline?: number;
+    finalScores?: PlayerScore[];
+    randomSeedCommitment?: string;
+    // Only revealed once the game has finished
+    randomSeed?: string;
+    pendingRollback?: PendingRollback;
+    version: number;
+    customMap?: CustomMapData;
+    mapVariants?: MapVariant[];
+    options?: GameOptions;
+}
+
+export interface CustomMapData {
+    name: string;
+    // In the same format as the files of the built-in maps
+    map: any;
+}
+
+export interface PlayerScore {
+    playerId: string;
+    incomePoints: number;
+    sharesPoints: number;
+    trackPoints: number;
+    total: number;
+    bankrupt: boolean;
+    place: number;
 }
 
 export function ViewGame(req: ViewGameRequest): Promise<ViewGameResponse> {
@@ -235,6 +289,7 @@ export function ViewGame(req: ViewGameRequest): Promise<ViewGameResponse> {
 
 export interface JoinGameRequest {
     gameId: string
+    botType?: string
 }
 export interface JoinGameResponse {
 }
@@ -332,6 +387,8 @@ export interface ConfirmMoveRequest {
     buildAction?: BuildAction
     moveGoodsAction?: MoveGoodsAction
     produceGoodsAction?: ProduceGoodsAction
+    // Version of the game the move was made against; the move is rejected if the game has changed since
+    version?: number
 }
 export interface ConfirmMoveResponse {
 }
@@ -340,13 +397,40 @@ export function ConfirmMove(req: ConfirmMoveRequest): Promise<ConfirmMoveRespons
     return doApiCall('/api/confirmMove', req);
 }
 
+export interface PreviewMoveResponse {
+    gameState: GameState;
+    activePlayer: string;
+    logs?: string[];
+    buildCost: number;
+    reversible: boolean;
+    gameFinished: boolean;
+}
+export function PreviewMove(req: ConfirmMoveRequest): Promise<PreviewMoveResponse> {
+    return doApiCall('/api/previewMove', req);
+}
+
+export interface GetLegalMovesRequest {
+    gameId: string;
+}
+export interface GetLegalMovesResponse {
+    activePlayer: string;
+    moves?: ConfirmMoveRequest[];
+}
+export function GetLegalMoves(req: GetLegalMovesRequest): Promise<GetLegalMovesResponse> {
+    return doApiCall('/api/getLegalMoves', req);
+}
+
 
 export interface GameLogEntry {
+    seq: number;
     timestamp: number;
     userId: string;
     action: string;
     description: string;
     reversible: boolean;
+    // Random values drawn during the move, if they were recorded
+    randomValues?: number[];
+    randomBounds?: number[];
 }
 
 export interface GetGameLogsRequest {
@@ -397,9 +481,11 @@ export function SetMyProfile(req: SetMyProfileRequest): Promise<SetMyProfileResp
 
 export interface GetGameChatRequest {
     gameId: string;
+    // Sequence number of the last message already seen
     after?: number;
 }
 export interface GameChatMessage {
+    seq: number;
     userId: string;
     timestamp: number;
     message: string;
@@ -432,6 +518,16 @@ export function PollGameStatus(req: PollGameStatusRequest): Promise<PollGameStat
     return doApiCall('/api/pollGameStatus', req);
 }
 
+// Events pushed over the /api/gameEvents stream, when supported by the server
+export interface GameEvent {
+    type: 'move' | 'chat' | 'rollback';
+    log?: GameLogEntry;
+    activePlayer?: string;
+    finished?: boolean;
+    chat?: GameChatMessage;
+    rollback?: PendingRollback;
+}
+
 export interface UndoMoveRequest {
     gameId: string;
 }
@@ -440,3 +536,134 @@ export interface UndoMoveResponse {
 export function UndoMove(req: UndoMoveRequest): Promise<UndoMoveResponse> {
     return doApiCall('/api/undoMove', req);
 }
+
+export interface PendingRollback {
+    requestedBy: string;
+    seq: number;
+    timestamp: number;
+    requestedAt: number;
+    approvals: string[];
+}
+
+export interface RollbackGameRequest {
+    gameId: string;
+    seq: number;
+}
+export interface RollbackGameResponse {
+    pendingRollback?: PendingRollback;
+}
+export function RollbackGame(req: RollbackGameRequest): Promise<RollbackGameResponse> {
+    return doApiCall('/api/rollbackGame', req);
+}
+
+export interface VoteRollbackRequest {
+    gameId: string;
+    approve: boolean;
+}
+export interface VoteRollbackResponse {
+    pendingRollback?: PendingRollback;
+}
+export function VoteRollback(req: VoteRollbackRequest): Promise<VoteRollbackResponse> {
+    return doApiCall('/api/voteRollback', req);
+}
+
+export interface GetLeaderboardRequest {
+    mapName: string;
+    playerCount: number;
+}
+export interface LeaderboardEntry {
+    user: User;
+    rating: number;
+    gamesPlayed: number;
+}
+export interface GetLeaderboardResponse {
+    entries: LeaderboardEntry[];
+}
+export function GetLeaderboard(req: GetLeaderboardRequest): Promise<GetLeaderboardResponse> {
+    return doApiCall('/api/getLeaderboard', req);
+}
+
+export interface ExportGameRequest {
+    gameId: string;
+}
+export interface ExportedGameStep {
+    action: ConfirmMoveRequest|null;
+    expectedGameState: GameState|null;
+    expectedActivePlayer: string;
+    timestamp: number;
+    userId: string;
+    description: string;
+    reversible: boolean;
+    randomValues: number[]|null;
+    randomBounds: number[]|null;
+}
+export interface ExportedGameInfo {
+    id: string;
+    createdAt: number;
+    name: string;
+    minPlayers: number;
+    maxPlayers: number;
+    ownerUserId: string;
+    started: boolean;
+    finished: boolean;
+    gameState: GameState|null;
+    activePlayer: string;
+    inviteOnly: boolean;
+    moveTimeLimitHours: number;
+    finalScores?: PlayerScore[];
+    randomSeedCommitment?: string;
+    randomSeed?: string;
+}
+export interface ExportedPlayer {
+    id: string;
+    nickname: string;
+    botType?: string;
+}
+export interface ExportedGame {
+    mapName: string;
+    steps: ExportedGameStep[];
+    randomValues: number[]|null;
+    game: ExportedGameInfo;
+    players: ExportedPlayer[];
+    chat: GameChatMessage[]|null;
+}
+export function ExportGame(req: ExportGameRequest): Promise<ExportedGame> {
+    return doApiCall('/api/exportGame', req);
+}
+
+export type MapVisibility = 'public' | 'private';
+export interface UploadMapRequest {
+    name: string;
+    visibility: MapVisibility;
+    map: any;
+}
+export interface UploadMapResponse {
+    // To be used as the map name when creating a game
+    mapName: string;
+}
+export function UploadMap(req: UploadMapRequest): Promise<UploadMapResponse> {
+    return doApiCall('/api/uploadMap', req);
+}
+
+export interface MapInfo {
+    // The map name to create a game with
+    id: string;
+    displayName: string;
+    custom?: boolean;
+    playerCounts: number[];
+    variants?: MapVariant[];
+    // Keyed by player count
+    turnLimits: { [playerCount: number]: number };
+    goodsGrowthDiceCount: { [playerCount: number]: number };
+    sharesLimit: number;
+    width: number;
+    height: number;
+}
+export interface ListMapsRequest {
+}
+export interface ListMapsResponse {
+    maps: MapInfo[];
+}
+export function ListMaps(req: ListMapsRequest): Promise<ListMapsResponse> {
+    return doApiCall('/api/listMaps', req);
+}
@@ -31,7 +31,7 @@ function GameChat(props: Props) {
             }
             setMessages(newMessages);
             if (newMessages.length > 0) {
-                setLastRefresh({gameId: gameId, after: newMessages[newMessages.length - 1].timestamp});
+                setLastRefresh({gameId: gameId, after: newMessages[newMessages.length - 1].seq});
             }
         });
     };
@@ -53,7 +53,7 @@ function GameChat(props: Props) {
         }}>
             {messages.map(message => {
                 let ts = new Date(message.timestamp * 1000).toLocaleString();
-                return <div key={message.timestamp} className="chatline">
+                return <div key={message.seq} className="chatline">
                     <span className="timestamp">{ts}</span>
                     <span className="nickname">{playerIdToNickMap[message.userId] || message.userId}</span>{' '}
                     <span className="message">{message.message}</span>
@@ -1,6 +1,5 @@
 import {ReactNode} from "react";
 import {BasicMap} from "./basic_map.tsx";
-import {GameState} from "../api/api.ts";
 
 class Australia extends BasicMap {
 
@@ -10,16 +9,14 @@ class Australia extends BasicMap {
       <p>Engineering allows you to build one tile for free. (It does not grant you the usual extra build.)</p>
       <p>Urbanization limits you to only 2 builds.</p>
       <p>Delivering to Perth (the Blue white #1 city) earns a bonus 3 income per delivery.</p>
+      <p>Each dark city starts with two extra blue goods, taken from the bag before the usual starting goods are
+        drawn.</p>
+      <p>The outback is the plains in the middle of the map, around Alice Springs and Uluru. Only simple
+        track can be built there, and new track costs $2 more than usual.</p>
+      <p>Income reduction never takes a player's income below 10.</p>
     </>;
   }
 
-  public getBuildLimit(gameState: GameState | undefined, player: string): number {
-    if (gameState && gameState.playerActions[player] === 'urbanization') {
-      return 2;
-    }
-    return 3;
-  }
-
   public getRiverLayer(): React.ReactNode {
     return null;
   }
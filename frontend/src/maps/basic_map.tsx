@@ -1,5 +1,5 @@
 import {ReactNode} from "react";
-import {BuildAction, Color, Coordinate, Direction, GameState} from "../api/api.ts";
+import {BuildAction, Color, Coordinate, Direction, GameState, SpecialAction} from "../api/api.ts";
 import {CityProperties, GameMap, HexType} from "./index.tsx";
 
 interface BasicMapHex {
@@ -25,11 +25,23 @@ export interface TeleportLink {
     costLocationEdge: Direction|-1;
 }
 
+// Overrides the number of builds; a limit without an action applies to any action without a limit of its own
+interface BuildLimit {
+    action?: SpecialAction;
+    limit: number;
+}
+
+// Only the rules from the map file that the frontend needs
+interface MapRules {
+    buildLimits?: BuildLimit[];
+}
+
 export const RIVER_COLOR = "#009bb2";
 
 export class BasicMap implements GameMap {
     protected hexes: BasicMapHex[][] = [];
     protected teleportLinks: TeleportLink[] = [];
+    protected rules: MapRules|undefined;
 
     public getWidth(): number {
         return this.hexes[0].length;
@@ -124,6 +136,17 @@ export class BasicMap implements GameMap {
     }
 
     public getBuildLimit(gameState: GameState | undefined, player: string): number {
+        if (this.rules?.buildLimits) {
+            let action = gameState?.playerActions[player];
+            let actionLimit = this.rules.buildLimits.find(limit => limit.action && limit.action === action);
+            let defaultLimit = this.rules.buildLimits.find(limit => !limit.action);
+            if (actionLimit) {
+                return actionLimit.limit;
+            }
+            if (defaultLimit) {
+                return defaultLimit.limit;
+            }
+        }
         if (gameState && gameState.playerActions[player] === 'engineer') {
             return 4;
         }
@@ -141,6 +164,7 @@ export class BasicMap implements GameMap {
     protected initializeFromJson(src: any) {
         this.hexes = src.hexes;
         this.teleportLinks = src.teleportLinks;
+        this.rules = src.rules;
     }
 
     public static fromJson(src: any): BasicMap {
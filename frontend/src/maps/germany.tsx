@@ -28,10 +28,6 @@ class Germany extends BasicMap {
         return cityProperties;
     }
 
-    public getBuildLimit(_gameState: GameState | undefined, _player: string): number {
-        return 3;
-    }
-
     public getMapInfo(): ReactNode {
         return <>
             <p>Plays 3-6, best 4-5.</p>
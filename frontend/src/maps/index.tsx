@@ -1,13 +1,17 @@
 import {ReactNode} from "react";
 import * as australiaRaw from "../../../backend/maps/australia.json";
 import * as germanyRaw from "../../../backend/maps/germany.json";
+import * as irelandRaw from "../../../backend/maps/ireland.json";
+import * as koreaRaw from "../../../backend/maps/korea.json";
 import * as rustBeltRaw from "../../../backend/maps/rust_belt.json";
 import * as scotlandRaw from "../../../backend/maps/scotland.json";
 import * as southernUsRaw from "../../../backend/maps/southern_us.json";
-import {BuildAction, Color, Coordinate, GameState} from "../api/api.ts";
+import {BuildAction, Color, Coordinate, CustomMapData, GameState} from "../api/api.ts";
 import Australia from "./australia.tsx";
 import {BasicMap, TeleportLink} from "./basic_map.tsx";
 import Germany from "./germany.tsx";
+import Ireland from "./ireland.tsx";
+import Korea from "./korea.tsx";
 import RustBelt from "./rust_belt.tsx";
 import Scotland from "./scotland.tsx";
 import SouthernUS from "./southern_us.tsx";
@@ -50,6 +54,8 @@ const southernUs = SouthernUS.fromJson(southernUsRaw);
 const germany = Germany.fromJson(germanyRaw);
 const scotland = Scotland.fromJson(scotlandRaw);
 const australia = Australia.fromJson(australiaRaw);
+const ireland = Ireland.fromJson(irelandRaw);
+const korea = Korea.fromJson(koreaRaw);
 
 export const maps: { [mapName: string]: BasicMap } = {
     "rust_belt": rustBelt,
@@ -57,4 +63,15 @@ export const maps: { [mapName: string]: BasicMap } = {
     "germany": germany,
     "scotland": scotland,
     "australia": australia,
+    "ireland": ireland,
+    "korea": korea,
+}
+
+// Display names of the custom maps that have been registered, keyed by map name
+export const customMapNames: { [mapName: string]: string } = {};
+
+// Custom maps are only known once a game on one has been loaded
+export function registerCustomMap(mapName: string, customMap: CustomMapData) {
+    maps[mapName] = BasicMap.fromJson(customMap.map);
+    customMapNames[mapName] = customMap.name;
 }
@@ -0,0 +1,34 @@
+import {ReactNode} from "react";
+import {BasicMap} from "./basic_map.tsx";
+
+class Ireland extends BasicMap {
+
+  public getMapInfo(): ReactNode {
+    return <>
+      <p>Plays 3-4.</p>
+      <p>Goods are limited: delivered goods are removed from the game instead of going back in the bag.</p>
+      <p>Three dice are rolled for each of the light and dark goods growth phases (regardless of number of
+        players).</p>
+      <p>The game lasts 8 turns with three players and 7 turns with four.</p>
+    </>;
+  }
+
+  public getTurnLimit(playerCount: number): number {
+    if (playerCount >= 4) {
+      return 7;
+    }
+    return 8;
+  }
+
+  public getRiverLayer(): React.ReactNode {
+    return null;
+  }
+
+  public static fromJson(src: any): Ireland {
+    let map = new Ireland();
+    map.initializeFromJson(src);
+    return map;
+  }
+}
+
+export default Ireland
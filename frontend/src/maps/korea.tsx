@@ -0,0 +1,25 @@
+import {ReactNode} from "react";
+import {BasicMap} from "./basic_map.tsx";
+
+class Korea extends BasicMap {
+
+  public getMapInfo(): ReactNode {
+    return <>
+      <p>Plays 3-5.</p>
+      <p>Nothing can be built in the DMZ (the two rows of hexes between Kaesong and Seoul). The only way across is
+        the $6 link from Kaesong to Seoul.</p>
+    </>;
+  }
+
+  public getRiverLayer(): React.ReactNode {
+    return null;
+  }
+
+  public static fromJson(src: any): Korea {
+    let map = new Korea();
+    map.initializeFromJson(src);
+    return map;
+  }
+}
+
+export default Korea
@@ -1,6 +1,7 @@
 import {ReactNode} from "react";
 import {GameLogEntry, GetGameLogsResponse, User, ViewGameResponse} from "../api/api.ts";
 import {
+    Button,
     Container,
     Header,
     Loader,
@@ -13,18 +14,20 @@ import {
     TableRow,
 } from "semantic-ui-react";
 
-function LogRow({playerById, entry}: {playerById: { [playerId: string]: User }, entry: GameLogEntry}) {
-    let nick = playerById[entry.userId].nickname;
+function LogRow({playerById, entry, onRollback}: {playerById: { [playerId: string]: User }, entry: GameLogEntry, onRollback?: () => void}) {
+    // Rollbacks can be requested by admins who aren't playing
+    let nick = playerById[entry.userId]?.nickname ?? "";
     let ts = new Date(entry.timestamp*1000).toLocaleString();
 
     return <TableRow>
         <TableCell>{ts}</TableCell>
         <TableCell>{nick}</TableCell>
         <TableCell><div style={{whiteSpace: "pre-line"}}>{entry.description}</div></TableCell>
+        {!onRollback ? null : <TableCell><Button size="mini" onClick={onRollback}>Roll back to here</Button></TableCell>}
     </TableRow>
 }
 
-function GameLogsComponent({ game, gameLogs }: {game: ViewGameResponse, gameLogs: GetGameLogsResponse|undefined}) {
+function GameLogsComponent({ game, gameLogs, onRollback }: {game: ViewGameResponse, gameLogs: GetGameLogsResponse|undefined, onRollback?: (entry: GameLogEntry) => void}) {
     let content: ReactNode;
     if (!gameLogs) {
         content = <Loader active />
@@ -37,7 +40,10 @@ function GameLogsComponent({ game, gameLogs }: {game: ViewGameResponse, gameLogs
         let entries: ReactNode[] = [];
         if (gameLogs.logs) {
             for (let idx = gameLogs.logs.length-1; idx >= 0; idx--) {
-                entries.push(<LogRow key={idx} playerById={playerById} entry={gameLogs.logs[idx]} />);
+                let entry = gameLogs.logs[idx];
+                // The latest entry is the current state, so there's nothing to roll back to
+                let rollbackHandler = (onRollback && idx < gameLogs.logs.length-1) ? () => onRollback(entry) : undefined;
+                entries.push(<LogRow key={idx} playerById={playerById} entry={entry} onRollback={rollbackHandler} />);
             }
         }
 
@@ -49,6 +55,7 @@ function GameLogsComponent({ game, gameLogs }: {game: ViewGameResponse, gameLogs
                                 <TableHeaderCell>When</TableHeaderCell>
                                 <TableHeaderCell>Who</TableHeaderCell>
                                 <TableHeaderCell>What</TableHeaderCell>
+                                {!onRollback ? null : <TableHeaderCell/>}
                             </TableRow>
                         </TableHeader>
                         <TableBody>
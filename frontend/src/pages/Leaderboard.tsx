@@ -0,0 +1,87 @@
+import {Dropdown, Form, FormField, Header, Loader, Table, TableBody, TableCell, TableHeader, TableHeaderCell, TableRow} from "semantic-ui-react";
+import {useEffect, useState} from "react";
+import {GetLeaderboard, GetLeaderboardRequest, GetLeaderboardResponse} from "../api/api.ts";
+import {mapNameToDisplayName} from "../util.ts";
+
+function Leaderboard() {
+    let [req, setReq] = useState<GetLeaderboardRequest>({
+        mapName: "",
+        playerCount: 0,
+    });
+    let [leaderboard, setLeaderboard] = useState<GetLeaderboardResponse|undefined>(undefined);
+    useEffect(() => {
+        setLeaderboard(undefined);
+        GetLeaderboard(req).then(res => {
+            setLeaderboard(res);
+        }).catch(err => {
+            console.error(err);
+        });
+    }, [req]);
+
+    let table;
+    if (!leaderboard) {
+        table = <Loader active={true} />
+    } else {
+        table = <Table celled>
+            <TableHeader>
+                <TableRow>
+                    <TableHeaderCell>Rank</TableHeaderCell>
+                    <TableHeaderCell>Player</TableHeaderCell>
+                    <TableHeaderCell>Rating</TableHeaderCell>
+                    <TableHeaderCell>Games Played</TableHeaderCell>
+                </TableRow>
+            </TableHeader>
+            <TableBody>
+                {leaderboard.entries.map((entry, idx) => <TableRow key={entry.user.id}>
+                    <TableCell>{idx + 1}</TableCell>
+                    <TableCell>{entry.user.nickname}</TableCell>
+                    <TableCell>{entry.rating}</TableCell>
+                    <TableCell>{entry.gamesPlayed}</TableCell>
+                </TableRow>)}
+            </TableBody>
+        </Table>
+    }
+
+    return <>
+        <Header as='h1'>Leaderboard</Header>
+        <Form>
+            <FormField>
+                <label>Map</label>
+                <Dropdown
+                    selection
+                    value={req.mapName}
+                    onChange={(_, { value }) => {
+                        let newReq = Object.assign({}, req);
+                        newReq.mapName = value as string;
+                        setReq(newReq);
+                    }}
+                    options={["", "rust_belt", "southern_us", "germany", "scotland", "australia", "ireland", "korea"].map(mapName => ({
+                        key: mapName,
+                        value: mapName,
+                        text: mapName === "" ? "All Maps" : mapNameToDisplayName(mapName)
+                    }))}
+                />
+            </FormField>
+            <FormField>
+                <label>Number of players</label>
+                <Dropdown
+                    selection
+                    value={req.playerCount}
+                    onChange={(_, { value }) => {
+                        let newReq = Object.assign({}, req);
+                        newReq.playerCount = value as number;
+                        setReq(newReq);
+                    }}
+                    options={[0, 2, 3, 4, 5, 6].map(playerCount => ({
+                        key: playerCount.toString(),
+                        value: playerCount,
+                        text: playerCount === 0 ? "Any" : playerCount.toString()
+                    }))}
+                />
+            </FormField>
+        </Form>
+        {table}
+    </>
+}
+
+export default Leaderboard
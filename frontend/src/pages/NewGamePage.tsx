@@ -1,8 +1,8 @@
 import {Button, Checkbox, Dropdown, Form, FormField, Header, Input, Segment} from "semantic-ui-react";
 import {useNavigate} from "react-router";
-import {CreateGame, CreateGameRequest} from "../api/api.ts";
-import {useState} from "react";
-import {mapNameToDisplayName} from "../util.ts";
+import {AuctionType, CreateGame, CreateGameRequest, GameOptions, ListMaps, MapInfo, MapVariant} from "../api/api.ts";
+import {useEffect, useState} from "react";
+import {auctionTypeToDisplayName, mapNameToDisplayName, mapVariantToDisplayName} from "../util.ts";
 import {GameMap, maps} from "../maps";
 import ViewMapComponent from "./ViewMapComponent.tsx";
 
@@ -14,10 +14,38 @@ function NewGamePage() {
         maxPlayers: 6,
         mapName: "rust_belt",
         inviteOnly: false,
+        moveTimeLimitHours: 0,
+        provablyFair: false,
+        mapVariants: [],
+        options: {},
     });
     let [loading, setLoading] = useState<boolean>(false);
+    let [mapInfos, setMapInfos] = useState<MapInfo[]|undefined>(undefined);
 
-    let map: GameMap = maps[req.mapName];
+    useEffect(() => {
+        ListMaps({}).then(res => {
+            setMapInfos(res.maps);
+        }).catch(err => {
+            console.error(err);
+        });
+    }, []);
+
+    // Custom maps can't be previewed until a game on them has been loaded
+    let map: GameMap|undefined = maps[req.mapName];
+    let mapInfo = mapInfos?.find(info => info.id === req.mapName);
+    // Blank house rule fields keep the normal rule
+    let setNumberOption = (option: 'startingCash'|'startingShares'|'sharesLimit'|'turnLimit', value: string) => {
+        let newReq = Object.assign({}, req);
+        newReq.options = Object.assign({}, req.options) as GameOptions;
+        newReq.options[option] = value === "" ? undefined : parseInt(value);
+        setReq(newReq);
+    };
+
+    let playerCountOptions = (mapInfo ? mapInfo.playerCounts : [2, 3, 4, 5, 6]).map(playerCount => ({
+        key: playerCount.toString(),
+        text: playerCount.toString(),
+        value: playerCount,
+    }));
 
     return <>
         <Header as='h1'>New Game</Header>
@@ -41,33 +69,7 @@ function NewGamePage() {
                         newReq.maxPlayers = Math.max(newReq.maxPlayers, newReq.minPlayers);
                         setReq(newReq);
                     }}
-                    options={[
-                        {
-                            key: "2",
-                            text: "2",
-                            value: 2,
-                        },
-                        {
-                            key: "3",
-                            text: "3",
-                            value: 3,
-                        },
-                        {
-                            key: "4",
-                            text: "4",
-                            value: 4,
-                        },
-                        {
-                            key: "5",
-                            text: "5",
-                            value: 5,
-                        },
-                        {
-                            key: "6",
-                            text: "6",
-                            value: 6,
-                        }
-                    ]}
+                    options={playerCountOptions}
                 />
             </FormField>
             <FormField>
@@ -81,33 +83,7 @@ function NewGamePage() {
                         newReq.minPlayers = Math.min(newReq.minPlayers, newReq.maxPlayers);
                         setReq(newReq);
                     }}
-                    options={[
-                        {
-                            key: "2",
-                            text: "2",
-                            value: 2,
-                        },
-                        {
-                            key: "3",
-                            text: "3",
-                            value: 3,
-                        },
-                        {
-                            key: "4",
-                            text: "4",
-                            value: 4,
-                        },
-                        {
-                            key: "5",
-                            text: "5",
-                            value: 5,
-                        },
-                        {
-                            key: "6",
-                            text: "6",
-                            value: 6,
-                        }
-                    ]}
+                    options={playerCountOptions}
                 />
             </FormField>
             <FormField>
@@ -118,12 +94,95 @@ function NewGamePage() {
                     onChange={(_, { value }) => {
                         let newReq = Object.assign({}, req);
                         newReq.mapName = value as string;
+                        // Variants are specific to each map
+                        newReq.mapVariants = [];
+                        // Keep the player counts within what the new map supports
+                        let playerCounts = mapInfos?.find(info => info.id === newReq.mapName)?.playerCounts;
+                        if (playerCounts && playerCounts.length > 0) {
+                            newReq.minPlayers = Math.max(newReq.minPlayers, playerCounts[0]);
+                            newReq.maxPlayers = Math.min(newReq.maxPlayers, playerCounts[playerCounts.length - 1]);
+                            if (newReq.minPlayers > newReq.maxPlayers) {
+                                newReq.minPlayers = playerCounts[0];
+                                newReq.maxPlayers = playerCounts[playerCounts.length - 1];
+                            }
+                        }
                         setReq(newReq);
                     }}
-                    options={["rust_belt", "southern_us", "germany", "scotland", "australia"].map(mapName => ({
-                        key: mapName,
-                        value: mapName,
-                        text: mapNameToDisplayName(mapName)
+                    options={(mapInfos ? mapInfos : []).map(info => ({
+                        key: info.id,
+                        value: info.id,
+                        text: info.displayName
+                    }))}
+                />
+            </FormField>
+            {!mapInfo?.variants ? null : <FormField>
+                <label>Variants</label>
+                {mapInfo.variants.map((variant: MapVariant) => <div key={variant}>
+                    <Checkbox toggle label={mapVariantToDisplayName(variant)}
+                              checked={req.mapVariants?.includes(variant)}
+                              onChange={(_, val) => {
+                                  let newReq = Object.assign({}, req);
+                                  newReq.mapVariants = (req.mapVariants || []).filter(v => v !== variant);
+                                  if (val.checked) {
+                                      newReq.mapVariants.push(variant);
+                                  }
+                                  setReq(newReq);
+                              }} />
+                </div>)}
+            </FormField>}
+            <FormField>
+                <label>House Rules</label>
+                <p>Leave these blank to play with the normal rules: $10 and 2 shares to start, and the map's limits on shares and turns.</p>
+                <Form.Group widths='equal'>
+                    <Form.Input type='number' min={0} max={100} label='Starting cash' value={req.options?.startingCash ?? ""}
+                                onChange={(_, { value }) => setNumberOption('startingCash', value)} />
+                    <Form.Input type='number' min={0} label='Starting shares' value={req.options?.startingShares ?? ""}
+                                onChange={(_, { value }) => setNumberOption('startingShares', value)} />
+                    <Form.Input type='number' min={1} max={30} label='Shares limit' placeholder={mapInfo?.sharesLimit.toString()}
+                                value={req.options?.sharesLimit ?? ""}
+                                onChange={(_, { value }) => setNumberOption('sharesLimit', value)} />
+                    <Form.Input type='number' min={1} max={20} label='Turn limit' value={req.options?.turnLimit ?? ""}
+                                onChange={(_, { value }) => setNumberOption('turnLimit', value)} />
+                </Form.Group>
+                <Checkbox toggle label='No turn order pass' checked={!!req.options?.noTurnOrderPass} onChange={(_, val) => {
+                    let newReq = Object.assign({}, req);
+                    newReq.options = Object.assign({}, req.options, {noTurnOrderPass: !!val.checked});
+                    setReq(newReq);
+                }} />
+            </FormField>
+            <FormField>
+                <label>Auction</label>
+                <p>Simpler ways of deciding the turn order, which can help when learning the game.</p>
+                <Dropdown
+                    selection
+                    value={req.options?.auctionType || 'standard'}
+                    onChange={(_, { value }) => {
+                        let newReq = Object.assign({}, req);
+                        newReq.options = Object.assign({}, req.options, {auctionType: value as AuctionType});
+                        setReq(newReq);
+                    }}
+                    options={(['standard', 'sealed_bid', 'pay_full_bid', 'fixed_order'] as AuctionType[]).map(auctionType => ({
+                        key: auctionType,
+                        value: auctionType,
+                        text: auctionTypeToDisplayName(auctionType)
+                    }))}
+                />
+            </FormField>
+            <FormField>
+                <label>Move time limit</label>
+                <p>If a player takes longer than this to make a move, a default move will be made for them (e.g. taking no shares, passing or building nothing).</p>
+                <Dropdown
+                    selection
+                    value={req.moveTimeLimitHours}
+                    onChange={(_, { value }) => {
+                        let newReq = Object.assign({}, req);
+                        newReq.moveTimeLimitHours = value as number;
+                        setReq(newReq);
+                    }}
+                    options={[0, 24, 48, 72].map(hours => ({
+                        key: hours.toString(),
+                        value: hours,
+                        text: hours === 0 ? "None" : `${hours} hours`
                     }))}
                 />
             </FormField>
@@ -136,6 +195,15 @@ function NewGamePage() {
                     setReq(newReq);
                 }} />
             </FormField>
+            <FormField>
+                <label>Provably Fair</label>
+                <p>All dice rolls and cube draws will come from a secret seed. A hash of the seed is shown when the game is created and the seed itself is revealed when the game ends, so that anyone can check that the rolls weren't tampered with.</p>
+                <Checkbox toggle checked={req.provablyFair} onChange={(_, val) => {
+                    let newReq = Object.assign({}, req);
+                    newReq.provablyFair = !!val.checked;
+                    setReq(newReq);
+                }} />
+            </FormField>
             <Button primary loading={loading} type='submit' onClick={() => {
                 setLoading(true);
                 CreateGame(req).then(res => {
@@ -148,11 +216,11 @@ function NewGamePage() {
             }}>Create</Button>
         </Form>
 
-        <Segment>
-            <Header as="h2">{mapNameToDisplayName(req.mapName)}</Header>
+        {!map ? null : <Segment>
+            <Header as="h2">{mapInfo ? mapInfo.displayName : mapNameToDisplayName(req.mapName)}</Header>
             {map.getMapInfo()}
             <ViewMapComponent gameState={undefined} activePlayer="" map={map} />
-        </Segment>
+        </Segment>}
     </>
 }
 
@@ -18,6 +18,7 @@ import {ReactNode, useContext, useEffect, useState} from "react";
 import {useNavigate, useParams} from "react-router";
 import {
     DeleteGame,
+    GameLogEntry,
     GamePhase,
     GetGameLogs,
     GetGameLogsResponse,
@@ -25,11 +26,14 @@ import {
     LeaveGame,
     PlayerColor,
     PollGameStatus,
+    GameEvent,
+    RollbackGame,
     StartGame,
     UndoMove,
     User,
     ViewGame,
-    ViewGameResponse
+    ViewGameResponse,
+    VoteRollback
 } from "../api/api.ts";
 import UserSessionContext from "../UserSessionContext.tsx";
 import ChooseShares from "../actions/ChooseShares.tsx";
@@ -43,9 +47,9 @@ import ProductionAction from "../actions/ProductionAction.tsx";
 import {playerColorToHtml} from "../actions/renderer/HexRenderer.tsx";
 import GameLogsComponent from "./GameLogsComponent.tsx";
 import FinalScore from "../actions/FinalScore.tsx";
-import {GameMap, maps} from "../maps";
+import {GameMap, maps, registerCustomMap} from "../maps";
 import "./ViewGamePage.css";
-import {mapNameToDisplayName, specialActionToDisplayName} from "../util.ts";
+import {describeGameOptions, mapNameToDisplayName, mapVariantToDisplayName, specialActionToDisplayName} from "../util.ts";
 import GameChat from "../components/GameChat.tsx";
 import ErrorContext from "../ErrorContext.tsx";
 import PartsCountComponent from "./PartsCountComponent.tsx";
@@ -262,7 +266,7 @@ function PlayerStatus({game, map, onConfirmMove}: { game: ViewGameResponse, map:
                             <LabelDetail>{playerById[playerId].nickname}</LabelDetail>
                         </Label>
                     </>})}<br/>
-            Turn: {game.gameState.turnNumber} / {map.getTurnLimit(game.joinedUsers.length)} <br/>
+            Turn: {game.gameState.turnNumber} / {game.gameState.options?.turnLimit || map.getTurnLimit(game.joinedUsers.length)} <br/>
         </Segment>
         <Segment className={"action-holder " + (game.activePlayer === userSession.userInfo?.user.id ? "my-turn" : "other-player-turn") }>
             {actionHolder}
@@ -289,6 +293,47 @@ function UndoSegment({gameId, reload}: {gameId: string, reload: () => Promise<vo
     </Segment>
 }
 
+function RollbackSegment({game, reload}: {game: ViewGameResponse, reload: () => Promise<void>}) {
+    let [loading, setLoading] = useState<boolean>(false);
+    let userSession = useContext(UserSessionContext);
+    let {setError} = useContext(ErrorContext);
+    let rollback = game.pendingRollback;
+    if (!rollback) {
+        return null;
+    }
+
+    let playerById: { [playerId: string]: User } = {};
+    for (let player of game.joinedUsers) {
+        playerById[player.id] = player;
+    }
+    let userId = userSession.userInfo?.user.id;
+    let canVote = userId !== undefined && playerById[userId] !== undefined && !rollback.approvals.includes(userId);
+    let approvedBy = rollback.approvals.map(id => playerById[id]?.nickname ?? id);
+
+    const vote = (approve: boolean) => {
+        setLoading(true);
+        return VoteRollback({gameId: game.id, approve: approve})
+            .then(() => {
+                return reload();
+            }).catch(err => {
+                setError(err);
+            }).finally(() => {
+                setLoading(false);
+            })
+    };
+
+    return <Segment>
+        <Header as='h2'>Rollback Requested</Header>
+        {playerById[rollback.requestedBy]?.nickname ?? "An admin"} has asked to roll the game back
+        to {new Date(rollback.timestamp * 1000).toLocaleString()}. Every player must approve.<br/>
+        Approved by: {approvedBy.join(", ")}<br/>
+        {!canVote ? null : <>
+            <Button primary loading={loading} onClick={() => vote(true)}>Approve</Button>
+            <Button negative loading={loading} onClick={() => vote(false)}>Reject</Button>
+        </>}
+    </Segment>
+}
+
 function ViewGamePage() {
     let params = useParams();
     let userSession = useContext(UserSessionContext);
@@ -297,11 +342,15 @@ function ViewGamePage() {
     let [game, setGame] = useState<ViewGameResponse|undefined>(undefined);
     let [gameLogs, setGameLogs] = useState<GetGameLogsResponse|undefined>(undefined);
     let [lastChat, setLastChat] = useState<number>(0);
+    let {setError} = useContext(ErrorContext);
 
     const reload: () => Promise<void> = () => {
         userSession.reload();
         if (gameId) {
             return ViewGame({gameId: gameId}).then(res => {
+                if (res.customMap) {
+                    registerCustomMap(res.mapName, res.customMap);
+                }
                 setGame(res);
             }).then(() => GetGameLogs({gameId: gameId}).then(res => {
                 setGameLogs(res);
@@ -316,19 +365,54 @@ function ViewGamePage() {
         reload();
 
         let lastMove = 0;
-        let pollInterval = setInterval(() => {
-            if (gameId) {
-                PollGameStatus({gameId: gameId}).then(res => {
-                    setLastChat(res.lastChat);
-                    if (res.lastMove !== lastMove) {
-                        lastMove = res.lastMove;
-                        reload();
-                    }
-                });
-            }
-        }, 5000);
+        let pollInterval: ReturnType<typeof setInterval>|undefined = undefined;
+        const startPolling = () => {
+            pollInterval = setInterval(() => {
+                if (gameId) {
+                    PollGameStatus({gameId: gameId}).then(res => {
+                        setLastChat(res.lastChat);
+                        if (res.lastMove !== lastMove) {
+                            lastMove = res.lastMove;
+                            reload();
+                        }
+                    });
+                }
+            }, 5000);
+        };
+
+        // Prefer live events, but fall back to polling if the server doesn't support them (e.g. in CGI mode)
+        let events: EventSource|undefined = undefined;
+        if (gameId && window.EventSource) {
+            events = new EventSource('/api/gameEvents?gameId=' + encodeURIComponent(gameId));
+            events.addEventListener('move', () => {
+                reload();
+            });
+            events.addEventListener('rollback', () => {
+                reload();
+            });
+            events.addEventListener('chat', (e: MessageEvent) => {
+                let event: GameEvent = JSON.parse(e.data);
+                if (event.chat) {
+                    setLastChat(event.chat.seq);
+                }
+            });
+            events.onerror = () => {
+                if (events && events.readyState === EventSource.CLOSED && pollInterval === undefined) {
+                    startPolling();
+                }
+            };
+        } else {
+            startPolling();
+        }
 
-        return () => clearInterval(pollInterval);
+        return () => {
+            if (events) {
+                events.close();
+            }
+            if (pollInterval !== undefined) {
+                clearInterval(pollInterval);
+            }
+        };
     }, [gameId]);
 
     if (!game) {
@@ -351,6 +435,11 @@ function ViewGamePage() {
                 Player Count: {playerCount}<br/>
                 Table Owner: {game.ownerUser.nickname}<br/>
                 {game.inviteOnly ? <><span style={{fontStyle: "italic"}}>Invite Only</span><br/></> : null}
+                {game.mapVariants?.length ? <>Variants: {game.mapVariants.map(mapVariantToDisplayName).join(", ")}<br/></> : null}
+                {describeGameOptions(game.options).length ? <>House Rules: {describeGameOptions(game.options).join(", ")}<br/></> : null}
+                {game.moveTimeLimitHours ? <>Move Time Limit: {game.moveTimeLimitHours} hours<br/></> : null}
+                {game.moveDeadline ? <>Current Move Due: {new Date(game.moveDeadline * 1000).toLocaleString()}<br/></> : null}
+                {game.randomSeedCommitment ? <>Provably Fair: random seed SHA-256 is <code>{game.randomSeedCommitment}</code><br/></> : null}
             </Segment>
             <Segment>
                 <Header as='h2'>Chat</Header>
@@ -362,15 +451,28 @@ function ViewGamePage() {
 
     let canUndo = false;
     if (gameLogs && gameLogs.logs) {
-        let maxTimestamp = 0;
+        let maxSeq = 0;
         for (let log of gameLogs.logs) {
-            if (log.timestamp > maxTimestamp) {
-                maxTimestamp = log.timestamp;
+            if (log.seq > maxSeq) {
+                maxSeq = log.seq;
                 canUndo = (log.reversible && log.userId === userSession.userInfo?.user.id);
             }
         }
     }
 
+    let canRollback = !game.finished && game.ownerUser.id === userSession.userInfo?.user.id;
+    const requestRollback = (entry: GameLogEntry) => {
+        if (!gameId || !window.confirm("Ask every player to approve rolling the game back to " + new Date(entry.timestamp * 1000).toLocaleString() + "?")) {
+            return;
+        }
+        RollbackGame({gameId: gameId, seq: entry.seq})
+            .then(() => {
+                return reload();
+            }).catch(err => {
+                setError(err);
+            });
+    };
+
     let map = maps[game.mapName];
     let mapInfo = map.getMapInfo();
     return <>
@@ -381,10 +483,11 @@ function ViewGamePage() {
         </Segment>
         <PlayerStatus game={game} map={map} onConfirmMove={() => reload()}/>
         {!canUndo ? null : <UndoSegment gameId={game.id} reload={reload} />}
+        <RollbackSegment game={game} reload={reload} />
         <ViewMapComponent gameState={game.gameState} activePlayer={game.activePlayer} map={map} />
         <GoodsGrowthTable game={game} map={map} />
         {!mapInfo ? null : <Segment><Header as='h2'>Map Info</Header>{mapInfo}</Segment>}
-        <GameLogsComponent game={game} gameLogs={gameLogs} />
+        <GameLogsComponent game={game} gameLogs={gameLogs} onRollback={canRollback ? requestRollback : undefined} />
         <PartsCountComponent map={map} game={game} />
     </>
 }
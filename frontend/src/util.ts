@@ -1,5 +1,5 @@
-import {BuildAction, Coordinate, Direction, GameState, SpecialAction} from "./api/api.ts";
-import {GameMap} from "./maps";
+import {AuctionType, BuildAction, Coordinate, Direction, GameOptions, GameState, MapVariant, SpecialAction} from "./api/api.ts";
+import {customMapNames, GameMap} from "./maps";
 import {TeleportLinkEdge} from "./maps/basic_map.tsx";
 
 export function applyTeleport(map: GameMap, gameState: GameState|undefined, pendingBuildAction: BuildAction|undefined,
@@ -84,6 +84,15 @@ export function mapNameToDisplayName(mapName: string): string {
     if (mapName === 'scotland') {
         return "Scotland";
     }
+    if (mapName === 'ireland') {
+        return "Ireland";
+    }
+    if (mapName === 'korea') {
+        return "Korea";
+    }
+    if (customMapNames[mapName]) {
+        return customMapNames[mapName];
+    }
     return mapName;
 }
 
@@ -112,6 +121,58 @@ export function specialActionToDisplayName(specialAction: SpecialAction): string
     return specialAction;
 }
 
+export function mapVariantToDisplayName(variant: MapVariant): string {
+    if (variant === 'no_first_turn_urbanization') {
+        return "No Urbanization on the First Turn";
+    }
+    if (variant === 'fixed_starting_cubes') {
+        return "Fixed Starting Cubes";
+    }
+    if (variant === 'first_turn_extra_share') {
+        return "Start With an Extra Share";
+    }
+    return variant;
+}
+
+export function auctionTypeToDisplayName(auctionType: AuctionType): string {
+    if (auctionType === 'standard') {
+        return "Standard Auction";
+    }
+    if (auctionType === 'sealed_bid') {
+        return "Sealed-Bid Auction";
+    }
+    if (auctionType === 'pay_full_bid') {
+        return "Everyone Pays Their Full Bid";
+    }
+    if (auctionType === 'fixed_order') {
+        return "Fixed Turn Order (No Auction)";
+    }
+    return auctionType;
+}
+
+export function describeGameOptions(options: GameOptions|undefined): string[] {
+    let descriptions: string[] = [];
+    if (options?.startingCash !== undefined) {
+        descriptions.push(`Start With $${options.startingCash}`);
+    }
+    if (options?.startingShares !== undefined) {
+        descriptions.push(`Start With ${options.startingShares} Shares`);
+    }
+    if (options?.sharesLimit) {
+        descriptions.push(`Shares Limit of ${options.sharesLimit}`);
+    }
+    if (options?.turnLimit) {
+        descriptions.push(`${options.turnLimit} Turns`);
+    }
+    if (options?.noTurnOrderPass) {
+        descriptions.push("No Turn Order Pass");
+    }
+    if (options?.auctionType && options.auctionType !== 'standard') {
+        descriptions.push(auctionTypeToDisplayName(options.auctionType));
+    }
+    return descriptions;
+}
+
 export function renderHexCoordinate(coordinate: Coordinate): string {
     let x: number
     if (coordinate.y%2 === 0) {